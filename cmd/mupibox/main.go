@@ -5,21 +5,45 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
+	"time"
 
+	"mupibox/internal/admin"
+	"mupibox/internal/button"
+	"mupibox/internal/card"
 	"mupibox/internal/catalog"
+	"mupibox/internal/config"
+	"mupibox/internal/events"
+	"mupibox/internal/favorites"
+	"mupibox/internal/gpio"
+	"mupibox/internal/history"
+	"mupibox/internal/library"
+	"mupibox/internal/lirc"
+	"mupibox/internal/mixer"
 	"mupibox/internal/player"
+	"mupibox/internal/power"
+	"mupibox/internal/profile"
+	"mupibox/internal/rfid"
+	"mupibox/internal/rotary"
 	"mupibox/internal/state"
+	"mupibox/internal/status"
+	"mupibox/internal/volume"
+	"mupibox/internal/wifi"
 )
 
 type HomeItem struct {
 	ID          string `json:"id"`
 	Title       string `json:"title"`
-	Type        string `json:"type"` // artist, playlist, podcast, continue
+	Type        string `json:"type"` // artist, playlist, podcast, continue, album, episode
 	Image       string `json:"image"`
 	CanResume   bool   `json:"can_resume"`
 	ResumePos   int    `json:"resume_pos_sec,omitempty"`
 	ResumeLabel string `json:"resume_label,omitempty"`
+
+	Progress *float64 `json:"progress,omitempty"` // 0..1, live while loaded
+	Favorite bool     `json:"favorite,omitempty"`
 }
 
 type HomeSection struct {
@@ -29,8 +53,13 @@ type HomeSection struct {
 
 func main() {
 	// --------------------------------------------------
-	// Load catalog + state
+	// Load config, catalog + state
 	// --------------------------------------------------
+	cfg, err := config.Load("config/mupibox.json")
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	cat, err := catalog.LoadCatalog("config/catalog.json")
 	if err != nil {
 		log.Fatal(err)
@@ -41,39 +70,359 @@ func main() {
 		log.Fatal(err)
 	}
 
+	profiles, err := profile.NewManager("data/profiles.json", "data", stateStore)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	historyStore, err := history.NewStore("data/history.json")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	cardStore, err := card.NewStore("data/cards.json")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	volumePolicy := volume.NewPolicy("data/volume.json", cfg.Volume)
+
+	bus := events.NewBus()
+
+	// --------------------------------------------------
+	// Hardware status (battery, wifi, system health)
+	// --------------------------------------------------
+	statusSvc := status.NewService(bus,
+		status.NewBatteryProvider(cfg.Status.Root),
+		status.NewWifiProvider(cfg.Status.Root, cfg.Status.WifiInterface),
+		status.NewSystemProvider(cfg.Status.Root, "data"),
+	)
+
 	// --------------------------------------------------
 	// Init player (memory mock)
 	// --------------------------------------------------
-	p := player.NewMemoryPlayer()
+	var p player.Player = player.NewMemoryPlayer()
+
+	// system volume instead of the player's software volume (optional)
+	if cfg.Mixer.Type == "alsa" {
+		mc := cfg.Mixer
+		alsa := mixer.NewALSA(mc.Card, mc.Control, mc.MinDB, mc.StateFile)
+		// the restored level only stays with volume.startup_volume = 0
+		if err := alsa.Restore(); err != nil {
+			log.Println(err)
+		}
+		p = mixer.NewPlayer(p, alsa)
+	}
+
+	// current position -> resume state of the active profile; every opened
+	// profile store is synced to disk
+	saveResume := func() error {
+		st := p.Status()
+		key := st.AlbumID
+		if key == "" {
+			key = st.ItemID
+		}
+		if key != "" {
+			store := profiles.State()
+			// keeps the speed only set explicitly (rememberSpeed); the
+			// profile default must not be frozen into every item
+			rs, _ := store.Get(key)
+			rs.ItemID = st.ItemID
+			rs.AlbumID = st.AlbumID
+			rs.TrackIndex = st.AlbumTrack - 1
+			rs.PositionSec = st.Position
+			rs.Shuffle = st.Shuffle
+			rs.ShuffleSeed = st.ShuffleSeed
+			if err := store.Set(key, rs); err != nil {
+				return err
+			}
+		}
+		return profiles.FlushAll()
+	}
+
+	// Low battery: cap volume, pause + save, shutdown
+	batteryPolicy := power.NewBatteryPolicy(power.Thresholds{
+		WarnPercent:     cfg.Power.WarnPercent,
+		WarnMaxVolume:   cfg.Power.WarnMaxVolume,
+		SavePercent:     cfg.Power.SavePercent,
+		CriticalPercent: cfg.Power.CriticalPercent,
+	}, p, bus, saveResume, cfg.Power.ShutdownCommand)
+	statusSvc.Watch(func(st status.Status) { batteryPolicy.Update(st.Battery) })
+
+	// Idle power-off after playback stayed paused/stopped
+	idle := power.NewIdleManager(p, bus, saveResume, cfg.Power.IdleCommand,
+		time.Duration(cfg.Power.IdleMinutes)*time.Minute,
+		time.Duration(cfg.Power.IdleWarningSec)*time.Second)
+	idle.Start()
+	statusSvc.ShutdownIn = idle.ShutdownIn
+
+	statusSvc.Start(time.Duration(cfg.Status.IntervalSec) * time.Second)
+
+	// volume limits: parents (global, quiet hours), profile, battery
+	lp := player.NewLimited(p, func() int {
+		return lowestLimit(volumePolicy.Limit(), profiles.Active().MaxVolume, batteryPolicy.MaxVolume())
+	})
+	profiles.OnSwitch = func(profile.Profile) {
+		lp.Enforce()
+	}
+	volumePolicy.OnChange = func(int) {
+		lp.Enforce()
+		bus.Publish("volume.limit", map[string]any{"max_volume": lp.Max()})
+	}
+	volumePolicy.Start()
+
+	if v := volumePolicy.StartupVolume(); v > 0 {
+		lp.SetVolume(v)
+	} else {
+		lp.Enforce()
+	}
+
+	// sleep timer on top; inputs and the API control the box through sp
+	sp := player.NewSleepTimer(lp)
+	sp.OnDone = func() { bus.Publish("sleep.done", nil) }
+
+	// Listening history (sampled from player status)
+	rec := history.NewRecorder(p, historyStore)
+	rec.SetProfile(func() string { return profiles.Active().ID })
+
+	// Resume state from another box (optional)
+	if cfg.Sync.PeerURL != "" {
+		state.NewSyncer(profiles.IDs, profiles.StateFor, cfg.Sync.PeerURL).Start(time.Duration(cfg.Sync.IntervalSec) * time.Second)
+	}
 
 	// --------------------------------------------------
 	// Helpers
 	// --------------------------------------------------
-	findCatalogItem := func(id string) *catalog.Item {
-		for _, c := range cat.Categories {
-			for _, it := range c.Items {
-				if it.ID == id {
-					tmp := it
-					return &tmp
-				}
+	lib := library.New(cat, profiles.State, "webui/static")
+	lib.DefaultSpeed = func() float64 { return profiles.Active().Speed }
+	findCatalogItem := lib.FindItem
+	pickCover := lib.Cover
+
+	// speed set explicitly (UI, buttons, cards): remember it for the audiobook
+	rememberSpeed := func(st player.PlayerStatus) {
+		key := st.AlbumID
+		if key == "" {
+			key = st.ItemID
+		}
+		store := profiles.State()
+		rs, ok := store.Get(key)
+		if !ok {
+			if key == "" || !lib.Resumes(st.ItemID) {
+				return
+			}
+			rs = state.ResumeState{
+				ItemID:      st.ItemID,
+				AlbumID:     st.AlbumID,
+				TrackIndex:  st.AlbumTrack - 1,
+				PositionSec: st.Position,
+				Shuffle:     st.Shuffle,
+				ShuffleSeed: st.ShuffleSeed,
 			}
 		}
+		rs.Speed = st.Speed
+		if err := store.Set(key, rs); err != nil {
+			log.Println("speed:", err)
+		}
+	}
+
+	// commands of buttons, remotes and cards; their speed changes are
+	// remembered like those from the UI
+	runCommand := func(name string) error {
+		if err := player.Command(sp, name); err != nil {
+			return err
+		}
+		switch name {
+		case player.CmdSpeedUp, player.CmdSpeedDown, player.CmdSpeedReset:
+			rememberSpeed(sp.Status())
+		}
 		return nil
 	}
 
-	pickCover := func(it *catalog.Item) string {
-		if it == nil {
-			return "/covers/placeholder.png"
+	// --------------------------------------------------
+	// GPIO buttons
+	// --------------------------------------------------
+	if len(cfg.Buttons.Buttons) > 0 {
+		bc := cfg.Buttons
+		src, err := gpio.Open(bc.Source, bc.Chip, button.Lines(bc.Buttons))
+		if err != nil {
+			log.Println("buttons:", err)
+		} else {
+			timing := button.Timing{
+				Debounce: time.Duration(bc.DebounceMs) * time.Millisecond,
+				Long:     time.Duration(bc.LongMs) * time.Millisecond,
+				Double:   time.Duration(bc.DoubleMs) * time.Millisecond,
+				Repeat:   time.Duration(bc.RepeatMs) * time.Millisecond,
+			}
+			button.New(src, bc.Buttons, timing, func(b button.Binding, press button.Press) {
+				idle.Touch()
+				cmd := b.Command(press)
+				if cmd == "" {
+					return
+				}
+				if err := runCommand(cmd); err != nil {
+					log.Printf("buttons: %s %s: %v", b.Name, press, err)
+				}
+			}).Start()
+		}
+	}
+
+	// --------------------------------------------------
+	// Rotary encoder
+	// --------------------------------------------------
+	if ec := cfg.Encoder; ec.Enabled {
+		rc := rotary.Config{
+			LineA:          ec.LineA,
+			LineB:          ec.LineB,
+			LineSW:         ec.LineSW,
+			StepsPerDetent: ec.StepsPerDetent,
+			Reverse:        ec.Reverse,
+			VolumeStep:     ec.VolumeStep,
+			SkipSeconds:    ec.SkipSeconds,
+			Mode:           rotary.Mode(ec.Mode),
+			ModeSwitch:     ec.ModeSwitch,
+		}
+		src, err := gpio.Open(cfg.Buttons.Source, cfg.Buttons.Chip, rotary.Lines(rc))
+		if err != nil {
+			log.Println("encoder:", err)
+		} else {
+			enc := rotary.NewEncoder(rc, src, sp, bus)
+			enc.OnActivity = idle.Touch
+			enc.Start()
+		}
+	}
+
+	// --------------------------------------------------
+	// IR remote (lircd)
+	// --------------------------------------------------
+	if cfg.LIRC.Socket != "" {
+		keymap := lirc.Keymap{
+			Keys:        cfg.LIRC.Keys,
+			Remote:      cfg.LIRC.Remote,
+			RepeatDelay: cfg.LIRC.RepeatDelay,
 		}
-		for _, src := range it.Sources {
-			if src.CoverPath != "" {
-				if _, err := os.Stat("webui/static" + src.CoverPath); err == nil {
-					return src.CoverPath
+		lirc.NewClient(cfg.LIRC.Socket, func(k lirc.Key) {
+			// keys of other remotes (e.g. the TV) must not keep the box awake
+			cmd := keymap.Command(k)
+			if cmd == "" {
+				return
+			}
+			idle.Touch()
+			if err := runCommand(cmd); err != nil {
+				log.Printf("lirc: %s: %v", k.Name, err)
+			}
+		}).Start()
+	}
+
+	// --------------------------------------------------
+	// RFID cards
+	// --------------------------------------------------
+	cards := card.NewDispatcher(cardStore, sp, lib)
+	cards.Sleep = sp.Start
+	cards.SwitchProfile = profiles.SwitchCapped
+	cards.Command = runCommand
+	cards.OnPlay = func() { rec.Attribute(history.SourceCard) }
+	learner := card.NewLearner(cardStore, bus)
+
+	if len(cfg.RFID.Devices) > 0 {
+		rfid.NewReader(cfg.RFID.Devices, cfg.RFID.Grab, func(id string) {
+			log.Println("rfid: card", id)
+			idle.Touch()
+			if learned, err := learner.Scanned(id); learned {
+				if err != nil {
+					log.Printf("rfid: learn card %s: %v", id, err)
 				}
+				return
+			}
+
+			c, err := cards.Handle(id)
+			bus.Publish("card.scanned", map[string]any{"id": id, "known": err != card.ErrNotFound, "name": c.Name})
+			if err == card.ErrNotFound {
+				learner.Remember(id)
+			} else if err != nil {
+				log.Printf("rfid: card %s: %v", id, err)
+			}
+		}).Start()
+	}
+
+	// favorite -> display data with live progress: from the player while
+	// it is the loaded content, otherwise from the resume state
+	resolveFavorite := func(f favorites.Favorite) (HomeItem, string) {
+		itemID := f.ItemID
+		if f.Kind == favorites.KindItem {
+			itemID = f.ID
+		}
+		it := findCatalogItem(itemID)
+
+		hi := HomeItem{
+			ID:       f.Key(),
+			Title:    f.ID,
+			Type:     string(f.Kind),
+			Image:    pickCover(it),
+			Favorite: true,
+		}
+		series := ""
+		if it != nil {
+			series = it.DisplayName
+			hi.CanResume = it.Resume
+			if f.Kind == favorites.KindItem {
+				hi.Title = it.DisplayName
+				hi.Type = it.Type
+			}
+		}
+
+		st := p.Status()
+		live := st.AlbumID == f.ID || (f.Kind == favorites.KindItem && st.ItemID == f.ID)
+		if live {
+			hi.ResumePos = st.Position
+			if st.AlbumTrackCount > 0 && st.Duration > 0 {
+				progress := (float64(st.AlbumTrack-1) + float64(st.Position)/float64(st.Duration)) / float64(st.AlbumTrackCount)
+				hi.Progress = &progress
+			}
+		} else if rs, ok := profiles.State().Get(f.ID); ok {
+			hi.ResumePos = rs.PositionSec
+		}
+		if hi.ResumePos > 0 {
+			hi.CanResume = true
+			hi.ResumeLabel = "Weiter"
+		}
+		return hi, series
+	}
+
+	isOrphan := func(itemID string) bool {
+		return findCatalogItem(itemID) == nil
+	}
+
+	// Retention for all profiles: at startup and then daily
+	applyRetention := func() {
+		opts := state.PruneOptions{
+			OlderThan:  time.Duration(cfg.Retention.MaxAgeDays) * 24 * time.Hour,
+			MaxEntries: cfg.Retention.MaxEntries,
+		}
+		if cfg.Retention.PruneOrphans {
+			opts.Orphan = isOrphan
+		}
+		if opts.Empty() {
+			return
+		}
+		for _, pr := range profiles.List() {
+			st, err := profiles.StateFor(pr.ID)
+			if err != nil {
+				log.Println("retention:", err)
+				continue
+			}
+			if removed, err := st.Prune(opts); err != nil {
+				log.Println("retention:", err)
+			} else if len(removed) > 0 {
+				log.Printf("retention: %s: removed %d entries", pr.ID, len(removed))
 			}
 		}
-		return "/covers/placeholder.png"
 	}
+	applyRetention()
+	go func() {
+		for range time.Tick(24 * time.Hour) {
+			applyRetention()
+		}
+	}()
 
 	// --------------------------------------------------
 	// HOME API
@@ -84,6 +433,8 @@ func main() {
 		}
 
 		// --- Continue section ---
+		stateStore := profiles.State()
+
 		recent := stateStore.ListRecent(15)
 		if len(recent) > 0 {
 			sec := HomeSection{Title: "Weiter abspielen"}
@@ -108,6 +459,17 @@ func main() {
 			resp.Sections = append(resp.Sections, sec)
 		}
 
+		// --- Favorites section ---
+		favs := profiles.Favorites().List()
+		if len(favs) > 0 {
+			sec := HomeSection{Title: "Favoriten"}
+			for _, f := range favs {
+				hi, _ := resolveFavorite(f)
+				sec.Items = append(sec.Items, hi)
+			}
+			resp.Sections = append(resp.Sections, sec)
+		}
+
 		// --- Catalog sections ---
 		for _, c := range cat.Categories {
 			sec := HomeSection{Title: c.Title}
@@ -131,6 +493,7 @@ func main() {
 					CanResume:   canResume,
 					ResumePos:   resumePos,
 					ResumeLabel: resumeLabel,
+					Favorite:    profiles.Favorites().Has(favorites.Favorite{Kind: favorites.KindItem, ID: it.ID}.Key()),
 				})
 			}
 			resp.Sections = append(resp.Sections, sec)
@@ -145,7 +508,28 @@ func main() {
 	// --------------------------------------------------
 	http.HandleFunc("/api/continue/", func(w http.ResponseWriter, r *http.Request) {
 		key := strings.TrimPrefix(r.URL.Path, "/api/continue/")
-		st, ok := stateStore.Get(key)
+
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodDelete:
+			ok, err := profiles.State().Delete(key)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"ok": true})
+			return
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		st, ok := profiles.State().Get(key)
 		if !ok {
 			http.NotFound(w, r)
 			return
@@ -171,6 +555,91 @@ func main() {
 		_ = json.NewEncoder(w).Encode(resp)
 	})
 
+	// --------------------------------------------------
+	// COLLECTIONS (UI tiles)
+	// --------------------------------------------------
+	http.HandleFunc("/api/collections", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		favItems := []map[string]any{}
+		for _, f := range profiles.Favorites().List() {
+			hi, series := resolveFavorite(f)
+
+			badges := []string{string(f.Kind)}
+			if hi.ResumePos > 0 {
+				badges = []string{"resume"}
+			}
+			favItems = append(favItems, map[string]any{
+				"key":      hi.ID,
+				"cover":    hi.Image,
+				"series":   series,
+				"title":    hi.Title,
+				"progress": hi.Progress,
+				"duration": nil,
+				"badges":   badges,
+				"playable": true,
+				"favorite": true,
+				"fav":      f,
+			})
+		}
+
+		collections := []map[string]any{}
+		if len(favItems) > 0 {
+			collections = append(collections, map[string]any{
+				"id":    "favorites",
+				"title": "Favoriten",
+				"items": favItems,
+			})
+		}
+
+		collections = append(collections, map[string]any{
+			"id":    "goodnight",
+			"title": "Gute Nacht",
+			"items": []map[string]any{
+				{
+					"key":      "spotify:playlist:goodnight",
+					"cover":    "/covers/placeholder.png",
+					"series":   "Spotify",
+					"title":    "Schlaflieder",
+					"progress": nil,
+					"duration": nil,
+					"badges":   []string{"shuffle"},
+					"playable": true,
+				},
+			},
+		})
+
+		for _, c := range cat.Categories {
+			items := []map[string]any{}
+			for _, it := range c.Items {
+				f := favorites.Favorite{Kind: favorites.KindItem, ID: it.ID}
+				items = append(items, map[string]any{
+					"key":      f.Key(),
+					"cover":    pickCover(&it),
+					"series":   c.Title,
+					"title":    it.DisplayName,
+					"progress": nil,
+					"duration": nil,
+					"badges":   []string{it.Type},
+					"playable": true,
+					"favorite": profiles.Favorites().Has(f.Key()),
+					"fav":      f,
+				})
+			}
+			collections = append(collections, map[string]any{
+				"id":    c.ID,
+				"title": c.Title,
+				"items": items,
+			})
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(collections)
+	})
+
 	// --------------------------------------------------
 	// ARTIST DETAILS (mock)
 	// --------------------------------------------------
@@ -199,13 +668,124 @@ func main() {
 	// --------------------------------------------------
 	// PLAYER API (NEU)
 	// --------------------------------------------------
-	player.NewAPI(p).Register(http.DefaultServeMux)
+	playerAPI := player.NewAPI(sp)
+	playerAPI.Sleep = sp
+	playerAPI.OnSpeed = rememberSpeed
+	playerAPI.Entries = lib.QueueEntries
+	playerAPI.Register(http.DefaultServeMux)
+
+	// --------------------------------------------------
+	// PROFILES API
+	// --------------------------------------------------
+	profileAPI := profile.NewAPI(profiles)
+	profileAPI.OnVolumeChange = lp.Enforce
+	profileAPI.Register(http.DefaultServeMux)
+
+	// --------------------------------------------------
+	// VOLUME LIMITS (read-only; editing via admin API)
+	// --------------------------------------------------
+	volumeAPI := volume.NewAPI(volumePolicy, lp.Max)
+	volumeAPI.Register(http.DefaultServeMux)
+
+	// --------------------------------------------------
+	// STATUS + EVENTS
+	// --------------------------------------------------
+	status.NewAPI(statusSvc).Register(http.DefaultServeMux)
+	http.HandleFunc("/api/events", bus.Handler())
+
+	// --------------------------------------------------
+	// STATE EXPORT / IMPORT
+	// --------------------------------------------------
+	stateAPI := state.NewAPI(profiles.State)
+	stateAPI.StoreFor = profiles.StateFor
+	stateAPI.Orphan = isOrphan
+	stateAPI.Register(http.DefaultServeMux)
+
+	// --------------------------------------------------
+	// FAVORITES API
+	// --------------------------------------------------
+	favorites.NewAPI(profiles.Favorites).Register(http.DefaultServeMux)
+
+	// --------------------------------------------------
+	// STATS API
+	// --------------------------------------------------
+	history.NewAPI(historyStore).Register(http.DefaultServeMux)
+
+	// --------------------------------------------------
+	// CARDS API
+	// --------------------------------------------------
+	cardAPI := card.NewAPI(cardStore, learner)
+	cardAPI.Register(http.DefaultServeMux)
+
+	// --------------------------------------------------
+	// ADMIN API (Eltern, PIN-geschützt)
+	// --------------------------------------------------
+	// Without a pin the routes stay mounted but admin.Require answers 403
+	// "admin pin not configured", so the UI can tell why wifi setup etc.
+	// are unavailable instead of getting a 404.
+	adminMux := http.NewServeMux()
+	wifi.NewAPI(wifi.NewManager(cfg.Wifi.CtrlPath)).Register(adminMux)
+	volumeAPI.RegisterAdmin(adminMux)
+	profileAPI.RegisterAdmin(adminMux)
+	cardAPI.RegisterAdmin(adminMux)
+	stateAPI.RegisterAdmin(adminMux)
+	http.Handle("/api/admin/", admin.Require(cfg.Admin.PIN, adminMux))
+	if cfg.Admin.PIN == "" {
+		log.Println("admin: no pin configured (admin.pin), parent API disabled")
+	}
 
 	// --------------------------------------------------
 	// Static UI
 	// --------------------------------------------------
 	http.Handle("/", http.FileServer(http.Dir("webui/static")))
 
+	// every API call resets the idle timer, except passive polling
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/api/") && !isPassiveRequest(r) {
+			idle.Touch()
+		}
+		http.DefaultServeMux.ServeHTTP(w, r)
+	})
+
+	srv := &http.Server{Addr: ":8080", Handler: handler}
+	go func() {
+		if err := srv.ListenAndServe(); err != http.ErrServerClosed {
+			log.Fatal(err)
+		}
+	}()
 	log.Println("MuPiBox running on http://localhost:8080")
-	log.Fatal(http.ListenAndServe(":8080", nil))
+
+	// stop, reboot and power-off (systemd sends SIGTERM): keep the running
+	// listening session and the position
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	log.Println("shutdown:", <-sig)
+	rec.Close()
+	if err := saveResume(); err != nil {
+		log.Println("shutdown: save:", err)
+	}
+}
+
+// lowestLimit returns the smallest of the given limits; 0 means no limit.
+func lowestLimit(limits ...int) int {
+	out := 0
+	for _, l := range limits {
+		if l > 0 && (out == 0 || l < out) {
+			out = l
+		}
+	}
+	return out
+}
+
+// isPassiveRequest reports requests the UI sends on its own (status
+// polling, event stream); they must not keep the box awake.
+func isPassiveRequest(r *http.Request) bool {
+	if r.Method != http.MethodGet {
+		return false
+	}
+	switch r.URL.Path {
+	case "/api/status", "/api/events", "/api/player/status", "/api/player/sleep":
+		return true
+	}
+	return false
 }
@@ -0,0 +1,24 @@
+package admin
+
+import (
+	"crypto/subtle"
+	"net/http"
+)
+
+// Require protects the parent API. Requests must send the PIN in the
+// X-Admin-Pin header. Without a configured PIN every request is refused:
+// the parent API is never open by default.
+func Require(pin string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if pin == "" {
+			http.Error(w, "admin pin not configured", http.StatusForbidden)
+			return
+		}
+		got := r.Header.Get("X-Admin-Pin")
+		if subtle.ConstantTimeCompare([]byte(got), []byte(pin)) != 1 {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
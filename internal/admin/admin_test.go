@@ -0,0 +1,36 @@
+package admin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequire(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	tests := []struct {
+		name   string
+		pin    string
+		header string
+		url    string
+		code   int
+	}{
+		{"no pin configured", "", "", "/api/admin/volume", http.StatusForbidden},
+		{"missing header", "1234", "", "/api/admin/volume", http.StatusForbidden},
+		{"wrong pin", "1234", "4321", "/api/admin/volume", http.StatusForbidden},
+		{"query pin is not accepted", "1234", "", "/api/admin/volume?pin=1234", http.StatusForbidden},
+		{"right pin", "1234", "1234", "/api/admin/volume", http.StatusOK},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, tt.url, nil)
+		if tt.header != "" {
+			req.Header.Set("X-Admin-Pin", tt.header)
+		}
+		rec := httptest.NewRecorder()
+		Require(tt.pin, ok).ServeHTTP(rec, req)
+		if rec.Code != tt.code {
+			t.Errorf("%s: status %d, want %d", tt.name, rec.Code, tt.code)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package button
+
+import (
+	"sync"
+	"time"
+
+	"mupibox/internal/gpio"
+)
+
+// Binding maps the presses of one button to command names
+// (see player.Command). Empty = press is ignored.
+type Binding struct {
+	Name       string `json:"name"`
+	Line       int    `json:"line"`
+	ActiveHigh bool   `json:"active_high,omitempty"` // Standard: gegen GND geschaltet, Pull-up
+
+	Short  string `json:"short,omitempty"`
+	Long   string `json:"long,omitempty"`
+	Double string `json:"double,omitempty"`
+	Repeat bool   `json:"repeat,omitempty"` // long press repeats while held
+}
+
+// Command returns the command bound to a press.
+func (b Binding) Command(p Press) string {
+	switch p {
+	case PressShort:
+		return b.Short
+	case PressLong:
+		return b.Long
+	case PressDouble:
+		return b.Double
+	}
+	return ""
+}
+
+type button struct {
+	Binding
+	det *Detector
+}
+
+// Buttons reads edges from a gpio.Source and reports presses per binding.
+type Buttons struct {
+	Source gpio.Source
+
+	// OnPress is called for every detected press.
+	OnPress func(b Binding, p Press)
+
+	mu      sync.Mutex
+	buttons map[int]*button // by line
+	done    chan struct{}
+}
+
+func New(src gpio.Source, bindings []Binding, t Timing, onPress func(Binding, Press)) *Buttons {
+	b := &Buttons{
+		Source:  src,
+		OnPress: onPress,
+		buttons: map[int]*button{},
+	}
+	for _, bd := range bindings {
+		b.buttons[bd.Line] = &button{
+			Binding: bd,
+			det:     NewDetector(t, bd.Double != "", bd.Repeat),
+		}
+	}
+	return b
+}
+
+// Lines returns the gpio lines of all bindings, for opening the source.
+func Lines(bindings []Binding) []int {
+	lines := make([]int, 0, len(bindings))
+	for _, b := range bindings {
+		lines = append(lines, b.Line)
+	}
+	return lines
+}
+
+func (b *Buttons) Start() {
+	b.done = make(chan struct{})
+	go b.loop()
+}
+
+func (b *Buttons) Close() {
+	close(b.done)
+	b.Source.Close()
+}
+
+func (b *Buttons) loop() {
+	// fine enough for long presses and the double-click window
+	t := time.NewTicker(20 * time.Millisecond)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-b.done:
+			return
+		case e, ok := <-b.Source.Edges():
+			if !ok {
+				return
+			}
+			b.Edge(e)
+		case now := <-t.C:
+			b.Tick(now)
+		}
+	}
+}
+
+// Edge feeds one edge; unknown lines are ignored.
+func (b *Buttons) Edge(e gpio.Edge) {
+	b.mu.Lock()
+	btn, ok := b.buttons[e.Line]
+	var presses []Press
+	if ok {
+		presses = btn.det.Edge(e.Rising == btn.ActiveHigh, e.Time)
+	}
+	b.mu.Unlock()
+
+	if ok {
+		b.report(btn.Binding, presses)
+	}
+}
+
+func (b *Buttons) Tick(now time.Time) {
+	type fired struct {
+		bd      Binding
+		presses []Press
+	}
+	var out []fired
+
+	b.mu.Lock()
+	for _, btn := range b.buttons {
+		if p := btn.det.Tick(now); len(p) > 0 {
+			out = append(out, fired{btn.Binding, p})
+		}
+	}
+	b.mu.Unlock()
+
+	for _, f := range out {
+		b.report(f.bd, f.presses)
+	}
+}
+
+func (b *Buttons) report(bd Binding, presses []Press) {
+	if b.OnPress == nil {
+		return
+	}
+	for _, p := range presses {
+		b.OnPress(bd, p)
+	}
+}
@@ -0,0 +1,156 @@
+package button
+
+import "time"
+
+type Press string
+
+const (
+	PressShort  Press = "short"
+	PressLong   Press = "long"
+	PressDouble Press = "double"
+)
+
+// Timing of press detection. Zero values fall back to DefaultTiming.
+type Timing struct {
+	Debounce time.Duration // edges closer than this are contact bounce
+	Long     time.Duration // held at least this long = long press
+	Double   time.Duration // max gap between the clicks of a double press
+	Repeat   time.Duration // long press repeat interval while held
+}
+
+var DefaultTiming = Timing{
+	Debounce: 30 * time.Millisecond,
+	Long:     800 * time.Millisecond,
+	Double:   300 * time.Millisecond,
+	Repeat:   300 * time.Millisecond,
+}
+
+func (t Timing) withDefaults() Timing {
+	if t.Debounce <= 0 {
+		t.Debounce = DefaultTiming.Debounce
+	}
+	if t.Long <= 0 {
+		t.Long = DefaultTiming.Long
+	}
+	if t.Double <= 0 {
+		t.Double = DefaultTiming.Double
+	}
+	if t.Repeat <= 0 {
+		t.Repeat = DefaultTiming.Repeat
+	}
+	return t
+}
+
+// Detector turns the debounced level changes of one button into presses.
+// It never reads the clock itself: Edge and Tick get the time, so edge
+// sequences with timestamps can be replayed.
+//
+// A long press fires while the button is still held. A short press waits
+// for the double-click window only if Double is enabled.
+type Detector struct {
+	Timing Timing
+	Double bool // detect double presses (delays short presses)
+	Repeat bool // repeat the long press while held, e.g. for volume
+
+	pressed   bool      // debounced level
+	raw       bool      // level of the last edge, bounce included
+	lastEdge  time.Time // last accepted change
+	downAt    time.Time
+	longFired bool
+	second    bool // current press is the second click
+	nextLong  time.Time
+
+	pendingShort bool
+	upAt         time.Time
+}
+
+func NewDetector(t Timing, double, repeat bool) *Detector {
+	return &Detector{Timing: t.withDefaults(), Double: double, Repeat: repeat}
+}
+
+// Edge feeds a level change (pressed = button down). Changes within
+// Debounce of the last accepted one are bounce: the level seen last is
+// taken once the window is over (by the next Edge or Tick), so a release
+// that bounced can't leave the button stuck down.
+func (d *Detector) Edge(pressed bool, t time.Time) []Press {
+	out := d.settle(t)
+	d.raw = pressed
+	if !d.lastEdge.IsZero() && t.Sub(d.lastEdge) < d.Timing.Debounce {
+		return out
+	}
+	return append(out, d.level(pressed, t)...)
+}
+
+// settle applies the last raw level after the debounce window.
+func (d *Detector) settle(t time.Time) []Press {
+	if d.raw == d.pressed || d.lastEdge.IsZero() {
+		return nil
+	}
+	at := d.lastEdge.Add(d.Timing.Debounce)
+	if t.Before(at) {
+		return nil
+	}
+	return d.level(d.raw, at)
+}
+
+// level handles an accepted level change.
+func (d *Detector) level(pressed bool, t time.Time) []Press {
+	if pressed == d.pressed {
+		return nil
+	}
+	d.lastEdge = t
+	d.pressed = pressed
+
+	if pressed {
+		d.downAt = t
+		d.longFired = false
+		d.nextLong = t.Add(d.Timing.Long)
+		if !d.pendingShort {
+			return nil
+		}
+		d.pendingShort = false
+		if t.Sub(d.upAt) <= d.Timing.Double {
+			d.second = true
+			return nil
+		}
+		// no Tick since the first click
+		return []Press{PressShort}
+	}
+
+	switch {
+	case d.longFired:
+		return nil
+	case d.second:
+		d.second = false
+		return []Press{PressDouble}
+	case d.Double:
+		d.pendingShort = true
+		d.upAt = t
+		return nil
+	}
+	return []Press{PressShort}
+}
+
+// Tick fires presses that depend on time passing: long presses (and their
+// repeats), short presses after the double-click window and levels that
+// settled after bounce.
+func (d *Detector) Tick(t time.Time) []Press {
+	out := d.settle(t)
+	return append(out, d.tick(t)...)
+}
+
+func (d *Detector) tick(t time.Time) []Press {
+	if d.pressed && !d.second && !t.Before(d.nextLong) {
+		if d.longFired && !d.Repeat {
+			return nil
+		}
+		d.longFired = true
+		d.nextLong = t.Add(d.Timing.Repeat)
+		return []Press{PressLong}
+	}
+	if d.pendingShort && t.Sub(d.upAt) > d.Timing.Double {
+		d.pendingShort = false
+		return []Press{PressShort}
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package button
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// step is an edge ("down"/"up") or a "tick" at ms after the start.
+type step struct {
+	ms   int
+	what string
+}
+
+func TestDetector(t *testing.T) {
+	tests := []struct {
+		name   string
+		double bool
+		repeat bool
+		steps  []step
+		want   []string // "<ms>:<press>"
+	}{
+		{
+			name:  "short",
+			steps: []step{{0, "down"}, {100, "up"}, {2000, "tick"}},
+			want:  []string{"100:short"},
+		},
+		{
+			name:  "bounce on press",
+			steps: []step{{0, "down"}, {5, "up"}, {10, "down"}, {400, "tick"}, {500, "up"}},
+			want:  []string{"500:short"},
+		},
+		{
+			name: "release bounce ends released",
+			// the last edge of the release is inside the window: without
+			// settling, the button stayed down and long-pressed forever
+			steps: []step{{0, "down"}, {300, "up"}, {305, "down"}, {310, "up"}, {320, "down"}, {325, "up"}, {360, "tick"}, {2000, "tick"}, {3000, "tick"}},
+			want:  []string{"300:short"},
+		},
+		{
+			name:  "release inside the debounce window of the press",
+			steps: []step{{0, "down"}, {10, "up"}, {40, "tick"}, {2000, "tick"}},
+			want:  []string{"40:short"},
+		},
+		{
+			name:  "press inside the debounce window settles down",
+			steps: []step{{0, "down"}, {100, "up"}, {110, "down"}, {140, "tick"}, {1000, "tick"}, {1100, "up"}},
+			want:  []string{"100:short", "1000:long"},
+		},
+		{
+			name:   "long with repeat",
+			repeat: true,
+			steps:  []step{{0, "down"}, {800, "tick"}, {900, "tick"}, {1100, "tick"}, {1200, "up"}, {2000, "tick"}},
+			want:   []string{"800:long", "1100:long"},
+		},
+		{
+			name:  "long without repeat",
+			steps: []step{{0, "down"}, {800, "tick"}, {1100, "tick"}, {1200, "up"}},
+			want:  []string{"800:long"},
+		},
+		{
+			name:   "double",
+			double: true,
+			steps:  []step{{0, "down"}, {100, "up"}, {200, "down"}, {300, "up"}, {1000, "tick"}},
+			want:   []string{"300:double"},
+		},
+		{
+			name:   "short waits for the double window",
+			double: true,
+			steps:  []step{{0, "down"}, {100, "up"}, {300, "tick"}, {450, "tick"}},
+			want:   []string{"450:short"},
+		},
+		{
+			name:   "second click after the window",
+			double: true,
+			steps:  []step{{0, "down"}, {100, "up"}, {500, "down"}, {600, "up"}, {1000, "tick"}},
+			want:   []string{"500:short", "1000:short"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewDetector(Timing{}, tt.double, tt.repeat)
+			start := time.Date(2026, 1, 1, 7, 0, 0, 0, time.UTC)
+
+			var got []string
+			for _, s := range tt.steps {
+				at := start.Add(time.Duration(s.ms) * time.Millisecond)
+				var presses []Press
+				switch s.what {
+				case "down", "up":
+					presses = d.Edge(s.what == "down", at)
+				case "tick":
+					presses = d.Tick(at)
+				}
+				for _, p := range presses {
+					got = append(got, fmt.Sprintf("%d:%s", s.ms, p))
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("presses = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
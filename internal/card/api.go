@@ -0,0 +1,194 @@
+package card
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type API struct {
+	Store   *Store
+	Learner *Learner
+}
+
+func NewAPI(store *Store, learner *Learner) *API {
+	return &API{Store: store, Learner: learner}
+}
+
+// Register adds the read-only card endpoints.
+func (a *API) Register(mux *http.ServeMux) {
+	// List (GET)
+	mux.HandleFunc("/api/cards", a.handleCards(false))
+
+	// Recently scanned cards without mapping
+	mux.HandleFunc("/api/cards/unknown", a.handleUnknown)
+
+	// GET /api/cards/{id}
+	mux.HandleFunc("/api/cards/", a.handleCard("/api/cards/", false))
+}
+
+// RegisterAdmin adds editing and learning. Cards can switch profiles and
+// set the volume, so mux is expected to be behind admin.Require.
+func (a *API) RegisterAdmin(mux *http.ServeMux) {
+	// List (GET) + create (POST)
+	mux.HandleFunc("/api/admin/cards", a.handleCards(true))
+
+	// Learn mode: arm (POST), state (GET), cancel (DELETE)
+	mux.HandleFunc("/api/admin/cards/learn", a.handleLearn)
+
+	// GET / PUT / DELETE /api/admin/cards/{id}
+	mux.HandleFunc("/api/admin/cards/", a.handleCard("/api/admin/cards/", true))
+}
+
+func (a *API) handleCards(admin bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet:
+			writeJSON(w, a.Store.List())
+		case r.Method == http.MethodPost && admin:
+			var c Card
+			if err := json.NewDecoder(r.Body).Decode(&c); err != nil {
+				http.Error(w, "invalid json", http.StatusBadRequest)
+				return
+			}
+			a.save(w, c)
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+func (a *API) handleCard(prefix string, admin bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := strings.TrimPrefix(r.URL.Path, prefix)
+		if id == "" {
+			http.NotFound(w, r)
+			return
+		}
+
+		switch {
+		case r.Method == http.MethodGet:
+			c, ok := a.Store.Get(id)
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			writeJSON(w, c)
+		case r.Method == http.MethodPut && admin:
+			var c Card
+			if err := json.NewDecoder(r.Body).Decode(&c); err != nil {
+				http.Error(w, "invalid json", http.StatusBadRequest)
+				return
+			}
+			c.ID = id
+			a.save(w, c)
+		case r.Method == http.MethodDelete && admin:
+			if err := a.Store.Delete(id); err != nil {
+				writeError(w, err)
+				return
+			}
+			writeOK(w)
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+type learnRequest struct {
+	Name string `json:"name,omitempty"`
+
+	// target: an item, an album of an item, or any action
+	ItemID  string  `json:"item_id,omitempty"`
+	AlbumID string  `json:"album_id,omitempty"`
+	Action  *Action `json:"action,omitempty"`
+
+	// overwrite the action of a card that already has one
+	Replace bool `json:"replace,omitempty"`
+
+	TimeoutSec int `json:"timeout_sec,omitempty"`
+}
+
+func (req learnRequest) action() Action {
+	switch {
+	case req.Action != nil:
+		return *req.Action
+	case req.AlbumID != "":
+		return Action{Type: ActionPlayAlbum, ItemID: req.ItemID, AlbumID: req.AlbumID}
+	default:
+		return Action{Type: ActionPlayItem, ItemID: req.ItemID}
+	}
+}
+
+func (a *API) handleLearn(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		resp := map[string]any{"learning": false}
+		if lr, ok := a.Learner.Current(); ok {
+			resp = map[string]any{"learning": true, "request": lr}
+		}
+		if last, ok := a.Learner.Last(); ok {
+			resp["last"] = last
+		}
+		writeJSON(w, resp)
+	case http.MethodPost:
+		var req learnRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "invalid json", http.StatusBadRequest)
+			return
+		}
+		lr, err := a.Learner.Arm(req.Name, req.action(), req.Replace, time.Duration(req.TimeoutSec)*time.Second)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, map[string]any{"learning": true, "request": lr})
+	case http.MethodDelete:
+		if err := a.Learner.Cancel(); err != nil {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		writeOK(w)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (a *API) handleUnknown(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	writeJSON(w, a.Learner.Unknown())
+}
+
+func (a *API) save(w http.ResponseWriter, c Card) {
+	c, err := a.Store.Set(c)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, c)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, ErrInvalidID), errors.Is(err, ErrInvalidAction):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func writeOK(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{"ok": true})
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
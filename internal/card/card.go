@@ -0,0 +1,153 @@
+package card
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"mupibox/internal/player"
+)
+
+type ActionType string
+
+const (
+	ActionPlayItem  ActionType = "play_item"  // ItemID
+	ActionPlayAlbum ActionType = "play_album" // ItemID + AlbumID
+	ActionResume    ActionType = "resume"     // Key ("Weiter abspielen")
+	ActionProfile   ActionType = "profile"    // Profile
+	ActionVolume    ActionType = "volume"     // Volume
+	ActionSleep     ActionType = "sleep"      // Minutes
+	ActionCommand   ActionType = "command"    // Command (player.Commands), z.B. pause, next
+)
+
+var (
+	ErrNotFound      = errors.New("card not found")
+	ErrInvalidID     = errors.New("invalid card id")
+	ErrInvalidAction = errors.New("invalid card action")
+)
+
+type Action struct {
+	Type ActionType `json:"type"`
+
+	ItemID  string `json:"item_id,omitempty"`
+	AlbumID string `json:"album_id,omitempty"`
+	Key     string `json:"key,omitempty"`
+	Profile string `json:"profile,omitempty"`
+	Volume  int    `json:"volume,omitempty"`
+	Minutes int    `json:"minutes,omitempty"`
+	Command string `json:"command,omitempty"`
+}
+
+func (a Action) Validate() error {
+	ok := false
+	switch a.Type {
+	case ActionPlayItem:
+		ok = a.ItemID != ""
+	case ActionPlayAlbum:
+		ok = a.ItemID != "" && a.AlbumID != ""
+	case ActionResume:
+		ok = a.Key != ""
+	case ActionProfile:
+		ok = a.Profile != ""
+	case ActionVolume:
+		ok = a.Volume >= 0 && a.Volume <= 100
+	case ActionSleep:
+		ok = a.Minutes > 0
+	case ActionCommand:
+		ok = player.IsCommand(a.Command)
+	}
+	if !ok {
+		return ErrInvalidAction
+	}
+	return nil
+}
+
+type Card struct {
+	ID     string `json:"id"`             // wie vom Leser getippt
+	Name   string `json:"name,omitempty"` // Notiz für Eltern
+	Action Action `json:"action"`
+
+	UpdatedAt string `json:"updated_at,omitempty"` // RFC3339
+}
+
+// Store maps card ids to actions. Cards are physical, so they are shared
+// by all profiles.
+type Store struct {
+	path string
+	mu   sync.Mutex
+	data map[string]Card
+}
+
+func NewStore(path string) (*Store, error) {
+	s := &Store{
+		path: path,
+		data: map[string]Card{},
+	}
+
+	raw, err := os.ReadFile(path)
+	if err == nil {
+		_ = json.Unmarshal(raw, &s.data)
+	}
+
+	if os.IsNotExist(err) {
+		_ = os.WriteFile(path, []byte("{}"), 0644)
+	}
+
+	return s, nil
+}
+
+func (s *Store) Get(id string) (Card, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.data[id]
+	return c, ok
+}
+
+// List returns all cards sorted by id.
+func (s *Store) List() []Card {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := make([]Card, 0, len(s.data))
+	for _, c := range s.data {
+		out = append(out, c)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+// Set creates or replaces a card.
+func (s *Store) Set(c Card) (Card, error) {
+	if c.ID == "" {
+		return c, ErrInvalidID
+	}
+	if err := c.Action.Validate(); err != nil {
+		return c, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+	s.data[c.ID] = c
+	return c, s.persist()
+}
+
+func (s *Store) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.data[id]; !ok {
+		return ErrNotFound
+	}
+	delete(s.data, id)
+	return s.persist()
+}
+
+func (s *Store) persist() error {
+	raw, _ := json.MarshalIndent(s.data, "", "  ")
+	return os.WriteFile(s.path, raw, 0644)
+}
@@ -0,0 +1,109 @@
+package card
+
+import (
+	"errors"
+
+	"mupibox/internal/player"
+)
+
+var ErrUnsupported = errors.New("card action not available")
+
+// Resolver turns play actions into media (see library.Library).
+type Resolver interface {
+	ItemMedia(itemID string) (player.Media, error)
+	AlbumMedia(itemID, albumID string) (player.Media, error)
+	ContinueMedia(key string) (player.Media, error)
+}
+
+// Dispatcher runs the action bound to a scanned card.
+type Dispatcher struct {
+	Store    *Store
+	Player   player.Player
+	Resolver Resolver
+
+	SwitchProfile func(id string) error   // optional
+	Sleep         func(minutes int) error // optional
+	OnPlay        func()                  // before a card starts playback (optional)
+
+	// Command runs command actions; default player.Command on Player.
+	Command func(name string) error
+}
+
+func NewDispatcher(store *Store, p player.Player, r Resolver) *Dispatcher {
+	return &Dispatcher{Store: store, Player: p, Resolver: r}
+}
+
+// Handle runs the action of card id. It returns ErrNotFound for unknown cards.
+func (d *Dispatcher) Handle(id string) (Card, error) {
+	c, ok := d.Store.Get(id)
+	if !ok {
+		return Card{}, ErrNotFound
+	}
+	return c, d.Run(c.Action)
+}
+
+func (d *Dispatcher) Run(a Action) error {
+	switch a.Type {
+	case ActionPlayItem:
+		return d.play(a, func() (player.Media, error) { return d.Resolver.ItemMedia(a.ItemID) })
+	case ActionPlayAlbum:
+		return d.play(a, func() (player.Media, error) { return d.Resolver.AlbumMedia(a.ItemID, a.AlbumID) })
+	case ActionResume:
+		return d.play(a, func() (player.Media, error) { return d.Resolver.ContinueMedia(a.Key) })
+	case ActionProfile:
+		if d.SwitchProfile == nil {
+			return ErrUnsupported
+		}
+		return d.SwitchProfile(a.Profile)
+	case ActionVolume:
+		d.Player.SetVolume(a.Volume)
+		return nil
+	case ActionSleep:
+		if d.Sleep == nil {
+			return ErrUnsupported
+		}
+		return d.Sleep(a.Minutes)
+	case ActionCommand:
+		if d.Command != nil {
+			return d.Command(a.Command)
+		}
+		return player.Command(d.Player, a.Command)
+	}
+	return ErrInvalidAction
+}
+
+// play loads new content, or toggles pause if the card's content is
+// already loaded, so putting the same card back does not restart it.
+func (d *Dispatcher) play(a Action, resolve func() (player.Media, error)) error {
+	if d.isLoaded(a) {
+		d.Player.Toggle()
+		return nil
+	}
+
+	m, err := resolve()
+	if err != nil {
+		return err
+	}
+	if d.OnPlay != nil {
+		d.OnPlay()
+	}
+	d.Player.Load(m)
+	d.Player.Play()
+	return nil
+}
+
+func (d *Dispatcher) isLoaded(a Action) bool {
+	st := d.Player.Status()
+	if st.State == player.StateStopped {
+		return false
+	}
+	switch a.Type {
+	case ActionPlayItem:
+		return st.ItemID != "" && st.ItemID == a.ItemID
+	case ActionPlayAlbum:
+		return st.AlbumID != "" && st.AlbumID == a.AlbumID
+	case ActionResume:
+		return st.AlbumID != "" && st.AlbumID == a.Key || st.AlbumID == "" && st.ItemID == a.Key
+	}
+	return false
+}
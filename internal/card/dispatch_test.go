@@ -0,0 +1,106 @@
+package card
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"mupibox/internal/admin"
+	"mupibox/internal/player"
+	"mupibox/internal/profile"
+	"mupibox/internal/state"
+)
+
+func newTestStore(t *testing.T) *Store {
+	t.Helper()
+	s, err := NewStore(filepath.Join(t.TempDir(), "cards.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestProfileCardIsCapped(t *testing.T) {
+	dir := t.TempDir()
+	st, err := state.NewStore(filepath.Join(dir, "state.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	profiles, err := profile.NewManager(filepath.Join(dir, "profiles.json"), dir, st)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []profile.Profile{{ID: "lena", MaxVolume: 40}, {ID: "baby", MaxVolume: 30}} {
+		if err := profiles.Save(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := profiles.Switch("lena"); err != nil {
+		t.Fatal(err)
+	}
+
+	cards := newTestStore(t)
+	for _, c := range []Card{
+		{ID: "1", Action: Action{Type: ActionProfile, Profile: profile.DefaultID}},
+		{ID: "2", Action: Action{Type: ActionProfile, Profile: "baby"}},
+	} {
+		if _, err := cards.Set(c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mp := player.NewMemoryPlayer()
+	mp.Close()
+	d := NewDispatcher(cards, mp, nil)
+	d.SwitchProfile = profiles.SwitchCapped
+
+	if _, err := d.Handle("1"); err != profile.ErrNeedsAdmin {
+		t.Fatalf("uncapped profile card: err = %v, want %v", err, profile.ErrNeedsAdmin)
+	}
+	if id := profiles.Active().ID; id != "lena" {
+		t.Fatalf("active = %q after an uncapped profile card", id)
+	}
+	if _, err := d.Handle("2"); err != nil {
+		t.Fatal(err)
+	}
+	if id := profiles.Active().ID; id != "baby" {
+		t.Fatalf("active = %q, want the stricter profile", id)
+	}
+}
+
+func TestEditingNeedsPin(t *testing.T) {
+	store := newTestStore(t)
+	api := NewAPI(store, NewLearner(store, nil))
+	mux := http.NewServeMux()
+	api.Register(mux)
+	adminMux := http.NewServeMux()
+	api.RegisterAdmin(adminMux)
+	mux.Handle("/api/admin/", admin.Require("1234", adminMux))
+
+	card := `{"id":"1","action":{"type":"profile","profile":"default"}}`
+	tests := []struct {
+		name, method, url, pin, body string
+		code                         int
+	}{
+		{"create", http.MethodPost, "/api/cards", "", card, http.StatusMethodNotAllowed},
+		{"update", http.MethodPut, "/api/cards/1", "", card, http.StatusMethodNotAllowed},
+		{"learn", http.MethodPost, "/api/admin/cards/learn", "", `{"item_id":"bibi"}`, http.StatusForbidden},
+		{"admin create without pin", http.MethodPost, "/api/admin/cards", "", card, http.StatusForbidden},
+		{"admin create", http.MethodPost, "/api/admin/cards", "1234", card, http.StatusOK},
+		{"read", http.MethodGet, "/api/cards/1", "", "", http.StatusOK},
+		{"delete", http.MethodDelete, "/api/cards/1", "", "", http.StatusMethodNotAllowed},
+		{"admin delete", http.MethodDelete, "/api/admin/cards/1", "1234", "", http.StatusOK},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.url, strings.NewReader(tt.body))
+		if tt.pin != "" {
+			req.Header.Set("X-Admin-Pin", tt.pin)
+		}
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		if rec.Code != tt.code {
+			t.Errorf("%s: status %d, want %d (%s)", tt.name, rec.Code, tt.code, rec.Body)
+		}
+	}
+}
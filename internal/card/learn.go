@@ -0,0 +1,257 @@
+package card
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"mupibox/internal/events"
+)
+
+const (
+	DefaultLearnTimeout = 60 * time.Second
+	maxUnknown          = 20
+)
+
+var (
+	ErrNotLearning   = errors.New("not learning")
+	ErrAlreadyMapped = errors.New("card already has an action; learn again with replace to overwrite it")
+)
+
+// Learning is an armed learn request: the next scanned card gets Action.
+// A card that already has an action is only overwritten with Replace.
+type Learning struct {
+	Name      string `json:"name,omitempty"`
+	Action    Action `json:"action"`
+	Replace   bool   `json:"replace,omitempty"`
+	ExpiresAt string `json:"expires_at"` // RFC3339
+}
+
+// Result is the outcome of the last learn request that got a card.
+type Result struct {
+	ID      string `json:"id"`
+	Learned bool   `json:"learned"`
+	Card    Card   `json:"card"` // the new mapping, or the existing one if not learned
+
+	Replaced *Card  `json:"replaced,omitempty"` // previous mapping, overwritten with Replace
+	Error    string `json:"error,omitempty"`
+}
+
+// Unknown is a scanned card without a mapping.
+type Unknown struct {
+	ID       string `json:"id"`
+	LastSeen string `json:"last_seen"` // RFC3339
+	Count    int    `json:"count"`
+}
+
+// Learner binds the next scanned card to an action, so parents don't have
+// to type card ids. It also remembers recently scanned unknown cards.
+type Learner struct {
+	Store *Store
+	Bus   *events.Bus
+
+	// Now and AfterFunc are the clock; tests replace them.
+	Now       func() time.Time
+	AfterFunc func(d time.Duration, f func()) (stop func() bool)
+
+	mu      sync.Mutex
+	armed   *Learning
+	expires time.Time
+	stop    func() bool
+	last    *Result
+	unknown []Unknown // newest first
+}
+
+func NewLearner(store *Store, bus *events.Bus) *Learner {
+	return &Learner{
+		Store: store,
+		Bus:   bus,
+		Now:   time.Now,
+		AfterFunc: func(d time.Duration, f func()) func() bool {
+			return time.AfterFunc(d, f).Stop
+		},
+	}
+}
+
+// Arm waits up to timeout for the next card. A running learn request is
+// replaced. Without replace, a card that already has an action is left
+// alone and reported as a conflict.
+func (l *Learner) Arm(name string, a Action, replace bool, timeout time.Duration) (Learning, error) {
+	if err := a.Validate(); err != nil {
+		return Learning{}, err
+	}
+	if timeout <= 0 {
+		timeout = DefaultLearnTimeout
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.stopLocked()
+	l.expires = l.Now().Add(timeout)
+	lr := Learning{
+		Name:      name,
+		Action:    a,
+		Replace:   replace,
+		ExpiresAt: l.expires.UTC().Format(time.RFC3339),
+	}
+	l.armed = &lr
+	l.stop = l.AfterFunc(timeout, func() { l.expire(&lr) })
+
+	l.publish("card.learn", lr)
+	return lr, nil
+}
+
+// Cancel stops a running learn request.
+func (l *Learner) Cancel() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.armed == nil {
+		return ErrNotLearning
+	}
+	l.stopLocked()
+	l.publish("card.learn.cancel", nil)
+	return nil
+}
+
+func (l *Learner) Current() (Learning, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !l.armedLocked() {
+		return Learning{}, false
+	}
+	return *l.armed, true
+}
+
+// Last returns the result of the last learn request that got a card.
+func (l *Learner) Last() (Result, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.last == nil {
+		return Result{}, false
+	}
+	return *l.last, true
+}
+
+// Scanned binds id if a learn request is armed. It returns false if the
+// card should be handled normally. A card that already has an action is
+// only overwritten if the request allows it, otherwise the result is
+// ErrAlreadyMapped and the existing mapping stays.
+func (l *Learner) Scanned(id string) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.armedLocked() {
+		return false, nil
+	}
+	lr := *l.armed
+	l.stopLocked()
+
+	res := Result{ID: id}
+	if old, ok := l.Store.Get(id); ok {
+		if !lr.Replace {
+			res.Card = old
+			res.Error = ErrAlreadyMapped.Error()
+			l.last = &res
+			l.publish("card.learn.conflict", res)
+			return true, ErrAlreadyMapped
+		}
+		res.Replaced = &old
+	}
+
+	c, err := l.Store.Set(Card{ID: id, Name: lr.Name, Action: lr.Action})
+	if err != nil {
+		res.Error = err.Error()
+		l.last = &res
+		l.publish("card.learn.failed", res)
+		return true, err
+	}
+	res.Learned = true
+	res.Card = c
+	l.last = &res
+	l.forgetLocked(id)
+	l.publish("card.learned", res)
+	return true, nil
+}
+
+// Remember records a scanned card that has no mapping.
+func (l *Learner) Remember(id string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	u := Unknown{ID: id, Count: 1}
+	for i, old := range l.unknown {
+		if old.ID == id {
+			u.Count = old.Count + 1
+			l.unknown = append(l.unknown[:i], l.unknown[i+1:]...)
+			break
+		}
+	}
+	u.LastSeen = l.Now().UTC().Format(time.RFC3339)
+
+	l.unknown = append([]Unknown{u}, l.unknown...)
+	if len(l.unknown) > maxUnknown {
+		l.unknown = l.unknown[:maxUnknown]
+	}
+}
+
+// Unknown lists recently scanned unknown cards, newest first. Cards that
+// got a mapping in the meantime are left out.
+func (l *Learner) Unknown() []Unknown {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	out := []Unknown{}
+	for _, u := range l.unknown {
+		if _, ok := l.Store.Get(u.ID); !ok {
+			out = append(out, u)
+		}
+	}
+	return out
+}
+
+func (l *Learner) expire(lr *Learning) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// a newer request or a scan got there first
+	if l.armed != lr {
+		return
+	}
+	l.stopLocked()
+	l.publish("card.learn.timeout", nil)
+}
+
+// armedLocked reports a running learn request. It also checks the
+// deadline, the timer may not have fired yet.
+func (l *Learner) armedLocked() bool {
+	if l.armed != nil && !l.Now().Before(l.expires) {
+		l.stopLocked()
+		l.publish("card.learn.timeout", nil)
+	}
+	return l.armed != nil
+}
+
+func (l *Learner) stopLocked() {
+	if l.stop != nil {
+		l.stop()
+		l.stop = nil
+	}
+	l.armed = nil
+}
+
+func (l *Learner) forgetLocked(id string) {
+	for i, u := range l.unknown {
+		if u.ID == id {
+			l.unknown = append(l.unknown[:i], l.unknown[i+1:]...)
+			return
+		}
+	}
+}
+
+func (l *Learner) publish(typ string, data any) {
+	if l.Bus != nil {
+		l.Bus.Publish(typ, data)
+	}
+}
@@ -0,0 +1,249 @@
+package card
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"mupibox/internal/events"
+)
+
+// fakeClock drives the learner's time; timers fire on advance.
+type fakeClock struct {
+	now    time.Time
+	timers []*fakeTimer
+}
+
+type fakeTimer struct {
+	at      time.Time
+	f       func()
+	stopped bool
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) AfterFunc(d time.Duration, f func()) func() bool {
+	t := &fakeTimer{at: c.now.Add(d), f: f}
+	c.timers = append(c.timers, t)
+	return func() bool {
+		was := !t.stopped
+		t.stopped = true
+		return was
+	}
+}
+
+func (c *fakeClock) advance(d time.Duration) {
+	c.now = c.now.Add(d)
+	for _, t := range c.timers {
+		if !t.stopped && !t.at.After(c.now) {
+			t.stopped = true
+			t.f()
+		}
+	}
+}
+
+func newTestLearner(t *testing.T) (*Learner, *fakeClock, <-chan events.Event) {
+	t.Helper()
+	bus := events.NewBus()
+	evs, cancel := bus.Subscribe()
+	t.Cleanup(cancel)
+
+	clock := &fakeClock{now: time.Date(2026, 3, 14, 19, 0, 0, 0, time.UTC)}
+	l := NewLearner(newTestStore(t), bus)
+	l.Now = clock.Now
+	l.AfterFunc = clock.AfterFunc
+	return l, clock, evs
+}
+
+// eventTypes drains the published events.
+func eventTypes(evs <-chan events.Event) []string {
+	var out []string
+	for {
+		select {
+		case ev := <-evs:
+			out = append(out, ev.Type)
+		default:
+			return out
+		}
+	}
+}
+
+var playBibi = Action{Type: ActionPlayItem, ItemID: "bibi"}
+
+func TestLearn(t *testing.T) {
+	l, _, evs := newTestLearner(t)
+	if _, err := l.Arm("Bibi", playBibi, false, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if learned, err := l.Scanned("0012345678"); !learned || err != nil {
+		t.Fatalf("Scanned = %v, %v", learned, err)
+	}
+	if c, ok := l.Store.Get("0012345678"); !ok || c.Name != "Bibi" || c.Action != playBibi {
+		t.Fatalf("card = %+v, %v", c, ok)
+	}
+	if res, _ := l.Last(); !res.Learned || res.Replaced != nil {
+		t.Fatalf("last = %+v", res)
+	}
+
+	// the next scan plays again
+	if learned, _ := l.Scanned("0012345678"); learned {
+		t.Fatal("still learning after a card")
+	}
+	if got, want := eventTypes(evs), []string{"card.learn", "card.learned"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("events = %q, want %q", got, want)
+	}
+}
+
+func TestLearnMappedCard(t *testing.T) {
+	l, _, evs := newTestLearner(t)
+	old, err := l.Store.Set(Card{ID: "1", Name: "TKKG", Action: Action{Type: ActionPlayItem, ItemID: "tkkg"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := l.Arm("Bibi", playBibi, false, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if learned, err := l.Scanned("1"); !learned || err != ErrAlreadyMapped {
+		t.Fatalf("Scanned = %v, %v; want the conflict", learned, err)
+	}
+	if c, _ := l.Store.Get("1"); c.Action != old.Action {
+		t.Fatalf("mapping replaced without confirmation: %+v", c)
+	}
+	if res, _ := l.Last(); res.Learned || res.Card.Name != "TKKG" {
+		t.Fatalf("last = %+v, want the existing mapping", res)
+	}
+
+	// confirmed: overwrite and report what was replaced
+	if _, err := l.Arm("Bibi", playBibi, true, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if learned, err := l.Scanned("1"); !learned || err != nil {
+		t.Fatalf("Scanned = %v, %v", learned, err)
+	}
+	res, _ := l.Last()
+	if !res.Learned || res.Replaced == nil || res.Replaced.Name != "TKKG" || res.Card.Action != playBibi {
+		t.Fatalf("last = %+v", res)
+	}
+	want := []string{"card.learn", "card.learn.conflict", "card.learn", "card.learned"}
+	if got := eventTypes(evs); !reflect.DeepEqual(got, want) {
+		t.Fatalf("events = %q, want %q", got, want)
+	}
+}
+
+func TestLearnTimeout(t *testing.T) {
+	l, clock, evs := newTestLearner(t)
+	lr, err := l.Arm("", playBibi, false, 30*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lr.ExpiresAt != "2026-03-14T19:00:30Z" {
+		t.Fatalf("expires_at = %s", lr.ExpiresAt)
+	}
+
+	clock.advance(29 * time.Second)
+	if _, ok := l.Current(); !ok {
+		t.Fatal("expired early")
+	}
+	clock.advance(time.Second)
+	if _, ok := l.Current(); ok {
+		t.Fatal("still learning after the timeout")
+	}
+	if learned, _ := l.Scanned("1"); learned {
+		t.Fatal("card learned after the timeout")
+	}
+	if got, want := eventTypes(evs), []string{"card.learn", "card.learn.timeout"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("events = %q, want %q", got, want)
+	}
+}
+
+func TestLearnDeadlineBeforeTimer(t *testing.T) {
+	l, clock, _ := newTestLearner(t)
+	if _, err := l.Arm("", playBibi, false, 30*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	// the clock passed the deadline, the timer has not run yet
+	clock.now = clock.now.Add(31 * time.Second)
+	if learned, _ := l.Scanned("1"); learned {
+		t.Fatal("card learned after the deadline")
+	}
+}
+
+func TestLearnCancelAndRearm(t *testing.T) {
+	l, clock, evs := newTestLearner(t)
+	if err := l.Cancel(); err != ErrNotLearning {
+		t.Fatalf("Cancel = %v, want %v", err, ErrNotLearning)
+	}
+	if _, err := l.Arm("", Action{Type: ActionPlayItem}, false, 0); err != ErrInvalidAction {
+		t.Fatalf("Arm invalid = %v", err)
+	}
+
+	if _, err := l.Arm("A", playBibi, false, 30*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Cancel(); err != nil {
+		t.Fatal(err)
+	}
+	if learned, _ := l.Scanned("1"); learned {
+		t.Fatal("card learned after cancel")
+	}
+
+	// re-arming replaces the request; the first timer must not end it
+	if _, err := l.Arm("A", playBibi, false, 30*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	clock.advance(20 * time.Second)
+	if _, err := l.Arm("B", playBibi, false, 0); err != nil {
+		t.Fatal(err)
+	}
+	clock.advance(20 * time.Second)
+	if lr, ok := l.Current(); !ok || lr.Name != "B" {
+		t.Fatalf("current = %+v, %v; want B, default timeout", lr, ok)
+	}
+
+	want := []string{"card.learn", "card.learn.cancel", "card.learn", "card.learn"}
+	if got := eventTypes(evs); !reflect.DeepEqual(got, want) {
+		t.Fatalf("events = %q, want %q", got, want)
+	}
+}
+
+func TestUnknownCards(t *testing.T) {
+	l, clock, _ := newTestLearner(t)
+	l.Remember("1")
+	clock.advance(time.Minute)
+	l.Remember("2")
+	clock.advance(time.Minute)
+	l.Remember("1")
+
+	want := []Unknown{
+		{ID: "1", LastSeen: "2026-03-14T19:02:00Z", Count: 2},
+		{ID: "2", LastSeen: "2026-03-14T19:01:00Z", Count: 1},
+	}
+	if got := l.Unknown(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("unknown = %+v, want %+v", got, want)
+	}
+
+	// mapped in the meantime (e.g. via the API) or learned
+	if _, err := l.Store.Set(Card{ID: "2", Action: playBibi}); err != nil {
+		t.Fatal(err)
+	}
+	if got := l.Unknown(); len(got) != 1 || got[0].ID != "1" {
+		t.Fatalf("unknown = %+v, want only 1", got)
+	}
+	if _, err := l.Arm("", playBibi, false, 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.Scanned("1"); err != nil {
+		t.Fatal(err)
+	}
+	if got := l.Unknown(); len(got) != 0 {
+		t.Fatalf("unknown = %+v after learning", got)
+	}
+
+	for i := 0; i < maxUnknown+5; i++ {
+		l.Remember(string(rune('a' + i)))
+	}
+	if got := l.Unknown(); len(got) != maxUnknown || got[0].ID != string(rune('a'+maxUnknown+4)) {
+		t.Fatalf("%d unknown cards, newest %q", len(got), got[0].ID)
+	}
+}
@@ -0,0 +1,117 @@
+package chapters
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalid = errors.New("chapters: invalid data")
+
+// Chapter is a part of a single-file audiobook.
+type Chapter struct {
+	Title string
+	Start time.Duration
+	End   time.Duration // 0 = unknown, the file's length couldn't be read
+}
+
+func (c Chapter) Duration() time.Duration {
+	if c.End <= c.Start {
+		return 0
+	}
+	return c.End - c.Start
+}
+
+// Load reads the chapters of an audio file. A .cue sheet next to the file
+// wins over chapters embedded in the file. No chapters is not an error.
+func Load(path string) ([]Chapter, error) {
+	chs, err := load(path)
+	if err != nil || len(chs) == 0 || chs[len(chs)-1].End > 0 {
+		return chs, err
+	}
+	// cue sheets (and some ID3 tags) leave the end of the last chapter open
+	if total := length(path); total > 0 {
+		chs = finish(chs, total)
+	}
+	return chs, nil
+}
+
+func load(path string) ([]Chapter, error) {
+	for _, cue := range []string{strings.TrimSuffix(path, filepath.Ext(path)) + ".cue", path + ".cue"} {
+		f, err := os.Open(cue)
+		if err != nil {
+			continue
+		}
+		defer f.Close()
+		return ParseCUE(f)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".m4b", ".m4a", ".mp4":
+		return ParseMP4(f)
+	case ".mp3":
+		return ParseID3(f)
+	}
+	return nil, nil
+}
+
+// length returns the playing time of an audio file, 0 if unknown.
+func length(path string) time.Duration {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0
+	}
+	defer f.Close()
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".m4b", ".m4a", ".mp4":
+		moov, err := findBox(f, "moov")
+		if err != nil {
+			return 0
+		}
+		if mvhd := child(moov, "mvhd"); mvhd != nil {
+			return mvhdDuration(mvhd)
+		}
+	case ".mp3":
+		return mp3Duration(f)
+	}
+	return 0
+}
+
+// Locate returns the chapter index at pos and the offset within it.
+func Locate(chs []Chapter, pos time.Duration) (int, time.Duration) {
+	i := sort.Search(len(chs), func(i int) bool { return chs[i].Start > pos }) - 1
+	if i < 0 {
+		return 0, 0
+	}
+	return i, pos - chs[i].Start
+}
+
+// finish sorts chapters by start and fills in missing end times from the
+// next chapter (the last one ends at total, if known).
+func finish(chs []Chapter, total time.Duration) []Chapter {
+	sort.SliceStable(chs, func(i, j int) bool { return chs[i].Start < chs[j].Start })
+	for i := range chs {
+		next := total
+		if i+1 < len(chs) {
+			next = chs[i+1].Start
+		}
+		if chs[i].End <= chs[i].Start || (next > 0 && chs[i].End > next) {
+			chs[i].End = next
+		}
+		if chs[i].Title == "" {
+			chs[i].Title = "Kapitel " + strconv.Itoa(i+1)
+		}
+	}
+	return chs
+}
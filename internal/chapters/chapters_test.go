@@ -0,0 +1,542 @@
+package chapters
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func sec(s float64) time.Duration { return time.Duration(s * float64(time.Second)) }
+
+func TestParseCUE(t *testing.T) {
+	tests := []struct {
+		name    string
+		sheet   string
+		want    []Chapter
+		wantErr error
+	}{
+		{
+			name: "tracks",
+			sheet: "\ufeffPERFORMER \"Kirsten Boie\"\n" +
+				"FILE \"buch.mp3\" MP3\n" +
+				"  TRACK 01 AUDIO\n" +
+				"    TITLE \"Anfang\"\n" +
+				"    INDEX 01 00:00:00\n" +
+				"  TRACK 02 AUDIO\n" +
+				"    TITLE \"Im Wald\"\n" +
+				"    INDEX 00 04:59:00\n" +
+				"    INDEX 01 05:00:37\n" +
+				"  TRACK 03 AUDIO\n" +
+				"    INDEX 01 12:30:00\n",
+			want: []Chapter{
+				{Title: "Anfang", Start: 0, End: sec(300) + 37*time.Second/75},
+				{Title: "Im Wald", Start: sec(300) + 37*time.Second/75, End: sec(750)},
+				{Title: "Kapitel 3", Start: sec(750)},
+			},
+		},
+		{
+			name:  "latin1 title",
+			sheet: "TRACK 01 AUDIO\nTITLE \"Gr\xfc\xdfe\"\nINDEX 01 00:00:00\n",
+			want:  []Chapter{{Title: "Grüße"}},
+		},
+		{
+			name:  "track without index",
+			sheet: "TRACK 01 AUDIO\nTITLE \"x\"\n",
+		},
+		{
+			name:    "bad time",
+			sheet:   "TRACK 01 AUDIO\nINDEX 01 1:2\n",
+			wantErr: ErrInvalid,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseCUE(strings.NewReader(tt.sheet))
+			if err != tt.wantErr {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("chapters = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// id3Frame builds a frame; v2.4 sizes are syncsafe.
+func id3Frame(version byte, id string, data []byte) []byte {
+	var hdr [10]byte
+	copy(hdr[:4], id)
+	if version == 4 {
+		putSyncsafe(hdr[4:8], len(data))
+	} else {
+		binary.BigEndian.PutUint32(hdr[4:8], uint32(len(data)))
+	}
+	return append(hdr[:], data...)
+}
+
+func id3Tag(version, flags byte, frames ...[]byte) []byte {
+	body := bytes.Join(frames, nil)
+	hdr := []byte{'I', 'D', '3', version, 0, flags, 0, 0, 0, 0}
+	putSyncsafe(hdr[6:10], len(body))
+	return append(hdr, body...)
+}
+
+func putSyncsafe(b []byte, n int) {
+	for i := 3; i >= 0; i-- {
+		b[i] = byte(n & 0x7f)
+		n >>= 7
+	}
+}
+
+func chapFrame(version byte, id string, startMs, endMs uint32, title []byte) []byte {
+	b := append([]byte(id), 0)
+	var times [16]byte
+	binary.BigEndian.PutUint32(times[0:4], startMs)
+	binary.BigEndian.PutUint32(times[4:8], endMs)
+	binary.BigEndian.PutUint64(times[8:16], ^uint64(0)) // no byte offsets
+	b = append(b, times[:]...)
+	if title != nil {
+		b = append(b, id3Frame(version, "TIT2", title)...)
+	}
+	return id3Frame(version, "CHAP", b)
+}
+
+func ctocFrame(version byte, top bool, children ...string) []byte {
+	flags := byte(0x01) // ordered
+	if top {
+		flags |= 0x02
+	}
+	b := append([]byte("toc"), 0, flags, byte(len(children)))
+	for _, c := range children {
+		b = append(append(b, c...), 0)
+	}
+	return id3Frame(version, "CTOC", b)
+}
+
+// withDLI adds a v2.4 data length indicator to a frame built by id3Frame.
+func withDLI(fr []byte) []byte {
+	data := fr[10:]
+	out := append([]byte{}, fr[:10]...)
+	out[9] |= 0x01
+	putSyncsafe(out[4:8], len(data)+4)
+	var dli [4]byte
+	putSyncsafe(dli[:], len(data))
+	return append(append(out, dli[:]...), data...)
+}
+
+func TestParseID3(t *testing.T) {
+	utf16Title := []byte{1, 0xFF, 0xFE, 'K', 0, 0xE4, 0, 's', 0, 'e', 0}
+
+	tests := []struct {
+		name    string
+		tag     []byte
+		want    []Chapter
+		wantErr error
+	}{
+		{
+			name: "v2.3 with table of contents",
+			tag: id3Tag(3, 0,
+				id3Frame(3, "TIT2", []byte("\x00Buch")),
+				chapFrame(3, "ch2", 60000, 0, []byte("\x03Zwei")),
+				chapFrame(3, "ch1", 0, 60000, []byte("\x03Eins")),
+				chapFrame(3, "extra", 90000, 0, []byte("\x03nicht im toc")),
+				ctocFrame(3, true, "ch1", "ch2"),
+			),
+			want: []Chapter{
+				{Title: "Eins", Start: 0, End: sec(60)},
+				{Title: "Zwei", Start: sec(60)},
+			},
+		},
+		{
+			name: "v2.4 syncsafe sizes, utf-16 title, no toc",
+			tag: id3Tag(4, 0,
+				chapFrame(4, "a", 0, 0, utf16Title),
+				chapFrame(4, "b", 1500, 0, nil),
+			),
+			want: []Chapter{
+				{Title: "Käse", Start: 0, End: sec(1.5)},
+				{Title: "Kapitel 2", Start: sec(1.5)},
+			},
+		},
+		{
+			name: "v2.4 data length indicator",
+			tag: id3Tag(4, 0,
+				withDLI(chapFrame(4, "a", 0, 2000, []byte("\x03Eins"))),
+				id3Frame(4, "CHAP", append([]byte("b\x00"), append(
+					u32s(2000, 4000, ^uint32(0), ^uint32(0)),
+					withDLI(id3Frame(4, "TIT2", []byte("\x03Zwei")))...)...)),
+				withDLI(ctocFrame(4, true, "a", "b")),
+			),
+			want: []Chapter{
+				{Title: "Eins", Start: 0, End: sec(2)},
+				{Title: "Zwei", Start: sec(2), End: sec(4)},
+			},
+		},
+		{
+			name: "v2.4 data length indicator without room for it",
+			tag: id3Tag(4, 0,
+				[]byte{'C', 'H', 'A', 'P', 0, 0, 0, 2, 0, 0x01, 'x', 0},
+				chapFrame(4, "a", 0, 2000, nil),
+			),
+			want: []Chapter{{Title: "Kapitel 1", Start: 0, End: sec(2)}},
+		},
+		{
+			name: "sub toc is ignored",
+			tag: id3Tag(3, 0,
+				chapFrame(3, "ch1", 0, 0, nil),
+				ctocFrame(3, false, "nope"),
+			),
+			want: []Chapter{{Title: "Kapitel 1"}},
+		},
+		{
+			name: "no chapters",
+			tag:  id3Tag(3, 0, id3Frame(3, "TIT2", []byte("\x00Buch"))),
+		},
+		{
+			name: "v2.2 is not supported",
+			tag:  id3Tag(2, 0),
+		},
+		{
+			name: "no tag",
+			tag:  []byte("\xff\xfb\x90\x00 mp3 frames"),
+		},
+		{
+			name:    "truncated tag",
+			tag:     id3Tag(3, 0, chapFrame(3, "ch1", 0, 0, nil))[:20],
+			wantErr: ErrInvalid,
+		},
+		{
+			name:    "tag too large",
+			tag:     []byte{'I', 'D', '3', 4, 0, 0, 0x7f, 0x7f, 0x7f, 0x7f},
+			wantErr: ErrInvalid,
+		},
+		{
+			name: "frame size beyond the tag",
+			tag:  id3Tag(3, 0, append([]byte("CHAP\xff\xff\xff\xff\x00\x00"), make([]byte, 20)...)),
+		},
+		{
+			name: "extended header size beyond the tag",
+			tag:  id3Tag(3, 0x40, append([]byte{0xff, 0xff, 0xff, 0xfe}, chapFrame(3, "ch1", 0, 0, nil)...)),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseID3(bytes.NewReader(tt.tag))
+			if err != tt.wantErr {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("chapters = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func mp4Box(typ string, payload ...[]byte) []byte {
+	body := bytes.Join(payload, nil)
+	b := make([]byte, 8, 8+len(body))
+	binary.BigEndian.PutUint32(b[:4], uint32(8+len(body)))
+	copy(b[4:], typ)
+	return append(b, body...)
+}
+
+func u32s(v ...uint32) []byte {
+	b := make([]byte, 4*len(v))
+	for i, x := range v {
+		binary.BigEndian.PutUint32(b[4*i:], x)
+	}
+	return b
+}
+
+// fullBox payloads start with version + flags
+func mvhd(timescale, duration uint32) []byte {
+	return mp4Box("mvhd", u32s(0, 0, 0, timescale, duration), make([]byte, 80))
+}
+
+func tkhd(id uint32) []byte {
+	return mp4Box("tkhd", u32s(0, 0, 0, id), make([]byte, 68))
+}
+
+func mdhd(timescale uint32) []byte {
+	return mp4Box("mdhd", u32s(0, 0, 0, timescale, 0), make([]byte, 4))
+}
+
+func chpl(entries ...Chapter) []byte {
+	b := append(u32s(1<<24, 0), byte(len(entries)))
+	for _, c := range entries {
+		var start [8]byte
+		binary.BigEndian.PutUint64(start[:], uint64(c.Start/100))
+		b = append(append(b, start[:]...), byte(len(c.Title)))
+		b = append(b, c.Title...)
+	}
+	return mp4Box("chpl", b)
+}
+
+func textSample(s string) []byte {
+	b := make([]byte, 2, 2+len(s))
+	binary.BigEndian.PutUint16(b, uint16(len(s)))
+	return append(b, s...)
+}
+
+// quickTimeFile builds ftyp, mdat with one text sample per title and a
+// moov with an audio track that references the chapter text track.
+func quickTimeFile(titles []string, deltas []uint32, extra ...[]byte) []byte {
+	ftyp := mp4Box("ftyp", []byte("M4B \x00\x00\x00\x00"))
+
+	var samples, sizes []byte
+	for _, title := range titles {
+		s := textSample(title)
+		samples = append(samples, s...)
+		sizes = append(sizes, u32s(uint32(len(s)))...)
+	}
+	mdat := mp4Box("mdat", samples)
+	first := uint32(len(ftyp) + 8)
+
+	var stts []byte
+	for _, d := range deltas {
+		stts = append(stts, u32s(1, d)...)
+	}
+	audio := mp4Box("trak", tkhd(1), mp4Box("tref", mp4Box("chap", u32s(2))), mp4Box("mdia", mdhd(44100)))
+	text := mp4Box("trak", tkhd(2), mp4Box("mdia", mdhd(1000), mp4Box("minf", mp4Box("stbl",
+		mp4Box("stts", u32s(0, uint32(len(deltas))), stts),
+		mp4Box("stsz", u32s(0, 0, uint32(len(titles))), sizes),
+		mp4Box("stsc", u32s(0, 1, 1, uint32(len(titles)), 1)),
+		mp4Box("stco", u32s(0, 1, first)),
+	))))
+	moov := mp4Box("moov", append([][]byte{mvhd(1000, 600000), audio, text}, extra...)...)
+	return bytes.Join([][]byte{ftyp, mdat, moov}, nil)
+}
+
+func TestParseMP4(t *testing.T) {
+	nero := mp4Box("udta", chpl(Chapter{Title: "Nero 1"}, Chapter{Title: "Nero 2", Start: sec(42)}))
+
+	tests := []struct {
+		name    string
+		file    []byte
+		want    []Chapter
+		wantErr error
+	}{
+		{
+			name: "quicktime chapter track",
+			file: quickTimeFile([]string{"Prolog", "Kapitel Eins"}, []uint32{90000, 510000}),
+			want: []Chapter{
+				{Title: "Prolog", Start: 0, End: sec(90)},
+				{Title: "Kapitel Eins", Start: sec(90), End: sec(600)},
+			},
+		},
+		{
+			name: "quicktime wins over chpl",
+			file: quickTimeFile([]string{"QT"}, []uint32{600000}, nero),
+			want: []Chapter{{Title: "QT", End: sec(600)}},
+		},
+		{
+			name: "nero chpl",
+			file: bytes.Join([][]byte{
+				mp4Box("ftyp", []byte("M4A \x00\x00\x00\x00")),
+				mp4Box("moov", mvhd(1000, 100000), nero),
+			}, nil),
+			want: []Chapter{
+				{Title: "Nero 1", End: sec(42)},
+				{Title: "Nero 2", Start: sec(42), End: sec(100)},
+			},
+		},
+		{
+			name: "no moov",
+			file: mp4Box("ftyp", []byte("M4A \x00\x00\x00\x00")),
+		},
+		{
+			name:    "moov larger than the limit",
+			file:    append(u32s(maxMoovSize+16), "moov"...),
+			wantErr: ErrInvalid,
+		},
+		{
+			name: "child box size beyond its parent",
+			file: mp4Box("moov", append(u32s(0xffffffff), "udta"...)),
+		},
+		{
+			name: "64 bit child size",
+			file: mp4Box("moov", append(u32s(1), append([]byte("udta"), u32s(0xffffffff, 0x00000010)...)...)),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseMP4(bytes.NewReader(tt.file))
+			if err != tt.wantErr {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("chapters = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// mp3File is an optional tag followed by one frame with header hdr, an
+// optional Xing/VBRI header at offset at and zeros up to audio bytes.
+func mp3File(tag, hdr []byte, at int, vbr []byte, audio int) []byte {
+	frame := make([]byte, audio)
+	copy(frame, hdr)
+	copy(frame[at:], vbr)
+	return append(append([]byte{}, tag...), frame...)
+}
+
+var (
+	mpeg1Stereo128 = []byte{0xff, 0xfb, 0x90, 0x00} // 44.1 kHz
+	mpeg1Mono128   = []byte{0xff, 0xfb, 0x90, 0xc0}
+	mpeg2Mono64    = []byte{0xff, 0xf3, 0x80, 0xc0} // 22.05 kHz
+)
+
+func TestMP3Duration(t *testing.T) {
+	xing := func(id string, flags, frames uint32) []byte { return append([]byte(id), u32s(flags, frames)...) }
+	vbri := append(append([]byte("VBRI"), make([]byte, 10)...), u32s(500)...)
+	tag := id3Tag(3, 0, id3Frame(3, "TIT2", []byte("\x00Buch")))
+
+	tests := []struct {
+		name string
+		file []byte
+		want time.Duration
+	}{
+		{"cbr", mp3File(nil, mpeg1Stereo128, 0, nil, 16000), sec(1)},
+		{"cbr after a tag", mp3File(tag, mpeg1Stereo128, 0, nil, 32000), sec(2)},
+		{"cbr after junk", mp3File(make([]byte, 16000), mpeg1Stereo128, 0, nil, 16000), sec(1)},
+		{"xing stereo", mp3File(tag, mpeg1Stereo128, 36, xing("Xing", 1, 1000), 16000), 1000 * 1152 * time.Second / 44100},
+		{"xing mono", mp3File(nil, mpeg1Mono128, 21, xing("Xing", 1, 1000), 16000), 1000 * 1152 * time.Second / 44100},
+		{"info without frame count", mp3File(nil, mpeg1Stereo128, 36, xing("Info", 0, 1000), 16000), sec(1)},
+		{"mpeg 2 xing mono", mp3File(nil, mpeg2Mono64, 13, xing("Xing", 1, 500), 8000), 500 * 576 * time.Second / 22050},
+		{"vbri", mp3File(nil, mpeg2Mono64, 36, vbri, 8000), 500 * 576 * time.Second / 22050},
+		{"no frame", make([]byte, 1000), 0},
+		{"layer ii", mp3File(nil, []byte{0xff, 0xfd, 0x90, 0x00}, 0, nil, 16000), 0},
+		{"bad bitrate", mp3File(nil, []byte{0xff, 0xfb, 0xf0, 0x00}, 0, nil, 16000), 0},
+		{"empty", nil, 0},
+	}
+	for _, tt := range tests {
+		if got := mp3Duration(bytes.NewReader(tt.file)); got != tt.want {
+			t.Errorf("%s: duration = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestLoadEndsLastChapter(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, data []byte) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	cue := []byte("TRACK 01 AUDIO\nINDEX 01 00:00:00\nTRACK 02 AUDIO\nINDEX 01 00:30:00\n")
+
+	tests := []struct {
+		name  string
+		audio []byte
+		cue   bool
+		want  []Chapter
+	}{
+		{
+			name:  "cue next to an mp3",
+			audio: mp3File(nil, mpeg1Stereo128, 0, nil, 60*16000),
+			cue:   true,
+			want:  []Chapter{{Title: "Kapitel 1", End: sec(30)}, {Title: "Kapitel 2", Start: sec(30), End: sec(60)}},
+		},
+		{
+			name: "id3 chapter without end",
+			audio: mp3File(id3Tag(4, 0, chapFrame(4, "a", 0, 0, nil), chapFrame(4, "b", 10000, 0, nil)),
+				mpeg1Stereo128, 0, nil, 20*16000),
+			want: []Chapter{{Title: "Kapitel 1", End: sec(10)}, {Title: "Kapitel 2", Start: sec(10), End: sec(20)}},
+		},
+		{
+			name:  "length unknown",
+			audio: make([]byte, 1000),
+			cue:   true,
+			want:  []Chapter{{Title: "Kapitel 1", End: sec(30)}, {Title: "Kapitel 2", Start: sec(30)}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := write("buch.mp3", tt.audio)
+			os.Remove(filepath.Join(dir, "buch.cue"))
+			if tt.cue {
+				write("buch.cue", cue)
+			}
+			got, err := Load(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("chapters = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLocate(t *testing.T) {
+	chs := []Chapter{{Start: 0}, {Start: sec(60)}, {Start: sec(120)}}
+	for _, tt := range []struct {
+		pos    time.Duration
+		i      int
+		offset time.Duration
+	}{
+		{0, 0, 0},
+		{sec(59), 0, sec(59)},
+		{sec(60), 1, 0},
+		{sec(500), 2, sec(380)},
+	} {
+		if i, off := Locate(chs, tt.pos); i != tt.i || off != tt.offset {
+			t.Errorf("Locate(%s) = %d, %s; want %d, %s", tt.pos, i, off, tt.i, tt.offset)
+		}
+	}
+}
+
+// The fuzz targets only check that broken files never panic and never
+// produce chapters that run backwards.
+
+func checkChapters(t *testing.T, chs []Chapter) {
+	for i, c := range chs {
+		if c.End != 0 && c.End < c.Start {
+			t.Fatalf("chapter %d ends before it starts: %+v", i, c)
+		}
+		if c.Title == "" {
+			t.Fatalf("chapter %d without title", i)
+		}
+	}
+}
+
+func FuzzParseID3(f *testing.F) {
+	f.Add(id3Tag(3, 0, chapFrame(3, "ch1", 0, 1000, []byte("\x03Eins")), ctocFrame(3, true, "ch1")))
+	f.Add(id3Tag(4, 0x40, append([]byte{0, 0, 0, 6, 1, 0}, chapFrame(4, "a", 0, 0, nil)...)))
+	f.Add(id3Tag(3, 0x80, chapFrame(3, "ff", 0xff00, 0, []byte{0, 0xff, 0x00})))
+	f.Add(id3Tag(4, 0, withDLI(chapFrame(4, "a", 0, 1000, []byte("\x03Eins")))))
+	f.Fuzz(func(t *testing.T, b []byte) {
+		chs, err := ParseID3(bytes.NewReader(b))
+		if err == nil {
+			checkChapters(t, chs)
+		}
+	})
+}
+
+func FuzzParseMP4(f *testing.F) {
+	f.Add(quickTimeFile([]string{"Prolog", "Eins"}, []uint32{1000, 2000}))
+	f.Add(mp4Box("moov", mvhd(1000, 5000), mp4Box("udta", chpl(Chapter{Title: "x"}))))
+	f.Fuzz(func(t *testing.T, b []byte) {
+		chs, err := ParseMP4(bytes.NewReader(b))
+		if err == nil {
+			checkChapters(t, chs)
+		}
+	})
+}
+
+func FuzzParseCUE(f *testing.F) {
+	f.Add("TRACK 01 AUDIO\nTITLE \"Eins\"\nINDEX 01 00:00:00\nTRACK 02 AUDIO\nINDEX 01 01:00:00\n")
+	f.Fuzz(func(t *testing.T, s string) {
+		chs, err := ParseCUE(strings.NewReader(s))
+		if err == nil {
+			checkChapters(t, chs)
+		}
+	})
+}
@@ -0,0 +1,95 @@
+package chapters
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ParseCUE reads the tracks of a cue sheet as chapters. INDEX 01 marks
+// the start of a track; times are mm:ss:ff with 75 frames per second.
+func ParseCUE(r io.Reader) ([]Chapter, error) {
+	var chs []Chapter
+	var cur *Chapter
+
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := strings.TrimSpace(latin1(sc.Text()))
+		line = strings.TrimPrefix(line, "\ufeff")
+		cmd, rest, _ := strings.Cut(line, " ")
+
+		switch strings.ToUpper(cmd) {
+		case "TRACK":
+			chs = append(chs, Chapter{Start: -1})
+			cur = &chs[len(chs)-1]
+		case "TITLE":
+			if cur != nil {
+				cur.Title = unquote(rest)
+			}
+		case "INDEX":
+			nr, at, _ := strings.Cut(strings.TrimSpace(rest), " ")
+			if cur == nil || nr != "01" {
+				continue
+			}
+			start, ok := cueTime(strings.TrimSpace(at))
+			if !ok {
+				return nil, ErrInvalid
+			}
+			cur.Start = start
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+
+	out := chs[:0]
+	for _, c := range chs {
+		if c.Start >= 0 {
+			out = append(out, c)
+		}
+	}
+	if len(out) == 0 {
+		return nil, nil
+	}
+	return finish(out, 0), nil
+}
+
+func cueTime(s string) (time.Duration, bool) {
+	f := strings.Split(s, ":")
+	if len(f) != 3 {
+		return 0, false
+	}
+	var n [3]int
+	for i, v := range f {
+		x, err := strconv.Atoi(v)
+		if err != nil || x < 0 {
+			return 0, false
+		}
+		n[i] = x
+	}
+	frames := (n[0]*60+n[1])*75 + n[2]
+	return time.Duration(frames) * time.Second / 75, true
+}
+
+func unquote(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// latin1 converts lines of older (Windows) cue sheets that are not UTF-8.
+func latin1(s string) string {
+	if utf8.ValidString(s) {
+		return s
+	}
+	r := make([]rune, len(s))
+	for i := 0; i < len(s); i++ {
+		r[i] = rune(s[i])
+	}
+	return string(r)
+}
@@ -0,0 +1,230 @@
+package chapters
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// Chapter tags are a few KB, cover art a few MB; larger tags are refused
+// instead of allocating up to the 256 MB a syncsafe size allows.
+const maxTagSize = 16 << 20
+
+// ParseID3 reads CHAP frames of an ID3v2.3/2.4 tag at the start of r.
+// The order comes from the top-level CTOC frame if there is one,
+// otherwise from the start times.
+func ParseID3(r io.Reader) ([]Chapter, error) {
+	var hdr [10]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		return nil, nil
+	}
+	if string(hdr[:3]) != "ID3" {
+		return nil, nil
+	}
+	version, flags := hdr[3], hdr[5]
+	if version != 3 && version != 4 {
+		return nil, nil
+	}
+
+	size := syncsafe(hdr[6:10])
+	if size > maxTagSize {
+		return nil, ErrInvalid
+	}
+	tag := make([]byte, size)
+	if _, err := io.ReadFull(r, tag); err != nil {
+		return nil, ErrInvalid
+	}
+	if version == 3 && flags&0x80 != 0 {
+		tag = unsync(tag)
+	}
+	if flags&0x40 != 0 {
+		tag = skipExtHeader(tag, version)
+	}
+
+	chaps := map[string]Chapter{}
+	var order, ids []string
+	for _, fr := range frames(tag, version) {
+		switch fr.id {
+		case "CHAP":
+			id, c, ok := parseCHAP(fr.data, version)
+			if ok {
+				chaps[id] = c
+				ids = append(ids, id)
+			}
+		case "CTOC":
+			if top, children := parseCTOC(fr.data); top {
+				order = children
+			}
+		}
+	}
+	if len(chaps) == 0 {
+		return nil, nil
+	}
+
+	var out []Chapter
+	if len(order) > 0 {
+		for _, id := range order {
+			if c, ok := chaps[id]; ok {
+				out = append(out, c)
+			}
+		}
+	} else {
+		for _, id := range ids {
+			out = append(out, chaps[id])
+		}
+	}
+	return finish(out, 0), nil
+}
+
+type frame struct {
+	id   string
+	data []byte
+}
+
+func frames(b []byte, version byte) []frame {
+	var out []frame
+	for len(b) >= 10 && b[0] != 0 {
+		id := string(b[:4])
+		// compare before converting: int(size) overflows on 32 bit
+		size := binary.BigEndian.Uint32(b[4:8])
+		if version == 4 {
+			size = uint32(syncsafe(b[4:8]))
+		}
+		flags := b[9]
+		if uint64(size) > uint64(len(b)-10) {
+			break
+		}
+		data := b[10 : 10+int(size)]
+		if version == 4 && flags&0x01 != 0 {
+			// data length indicator: the size before unsynchronisation
+			if len(data) < 4 {
+				b = b[10+int(size):]
+				continue
+			}
+			data = data[4:]
+		}
+		if version == 4 && flags&0x02 != 0 {
+			data = unsync(data)
+		}
+		out = append(out, frame{id: id, data: data})
+		b = b[10+int(size):]
+	}
+	return out
+}
+
+// CHAP: element id \0, start ms, end ms, start/end byte offset, sub-frames
+func parseCHAP(b []byte, version byte) (string, Chapter, bool) {
+	id, rest, ok := cutNull(b)
+	if !ok || len(rest) < 16 {
+		return "", Chapter{}, false
+	}
+	c := Chapter{
+		Start: time.Duration(binary.BigEndian.Uint32(rest[0:4])) * time.Millisecond,
+		End:   time.Duration(binary.BigEndian.Uint32(rest[4:8])) * time.Millisecond,
+	}
+	for _, sub := range frames(rest[16:], version) {
+		if sub.id == "TIT2" {
+			c.Title = decodeText(sub.data)
+		}
+	}
+	return id, c, true
+}
+
+// CTOC: element id \0, flags, entry count, child ids \0, sub-frames
+func parseCTOC(b []byte) (bool, []string) {
+	_, rest, ok := cutNull(b)
+	if !ok || len(rest) < 2 {
+		return false, nil
+	}
+	top := rest[0]&0x02 != 0
+	n := int(rest[1])
+	rest = rest[2:]
+
+	children := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		var child string
+		child, rest, ok = cutNull(rest)
+		if !ok {
+			break
+		}
+		children = append(children, child)
+	}
+	return top, children
+}
+
+// decodeText decodes a text frame: encoding byte, then the text.
+func decodeText(b []byte) string {
+	if len(b) == 0 {
+		return ""
+	}
+	enc, b := b[0], b[1:]
+	var s string
+	switch enc {
+	case 1, 2: // UTF-16 with BOM / UTF-16BE
+		s = decodeUTF16(b, enc == 2)
+	case 3:
+		s = string(b)
+	default: // ISO-8859-1
+		r := make([]rune, len(b))
+		for i, c := range b {
+			r[i] = rune(c)
+		}
+		s = string(r)
+	}
+	return strings.TrimRight(s, "\x00")
+}
+
+func decodeUTF16(b []byte, bigEndian bool) string {
+	if len(b) >= 2 {
+		switch {
+		case b[0] == 0xFE && b[1] == 0xFF:
+			bigEndian, b = true, b[2:]
+		case b[0] == 0xFF && b[1] == 0xFE:
+			bigEndian, b = false, b[2:]
+		}
+	}
+	u := make([]uint16, 0, len(b)/2)
+	for i := 0; i+1 < len(b); i += 2 {
+		if bigEndian {
+			u = append(u, binary.BigEndian.Uint16(b[i:]))
+		} else {
+			u = append(u, binary.LittleEndian.Uint16(b[i:]))
+		}
+	}
+	return string(utf16.Decode(u))
+}
+
+func skipExtHeader(tag []byte, version byte) []byte {
+	if len(tag) < 4 {
+		return tag
+	}
+	// v2.3: size excludes the size field, v2.4: syncsafe and includes it
+	n := uint64(binary.BigEndian.Uint32(tag[:4])) + 4
+	if version == 4 {
+		n = uint64(syncsafe(tag[:4]))
+	}
+	if n > uint64(len(tag)) {
+		return nil
+	}
+	return tag[int(n):]
+}
+
+func syncsafe(b []byte) int {
+	return int(b[0]&0x7f)<<21 | int(b[1]&0x7f)<<14 | int(b[2]&0x7f)<<7 | int(b[3]&0x7f)
+}
+
+// unsync undoes the unsynchronisation scheme (FF 00 -> FF).
+func unsync(b []byte) []byte {
+	return bytes.ReplaceAll(b, []byte{0xFF, 0x00}, []byte{0xFF})
+}
+
+func cutNull(b []byte) (string, []byte, bool) {
+	i := bytes.IndexByte(b, 0)
+	if i < 0 {
+		return "", nil, false
+	}
+	return string(b[:i]), b[i+1:], true
+}
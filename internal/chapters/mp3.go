@@ -0,0 +1,125 @@
+package chapters
+
+import (
+	"encoding/binary"
+	"io"
+	"time"
+)
+
+// MPEG audio bitrates in kbit/s by version and bitrate index, layer III only
+var (
+	mp3Bitrates1 = [16]int{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 0}
+	mp3Bitrates2 = [16]int{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160, 0}
+	mp3Rates     = [4]int{44100, 48000, 32000, 0}
+)
+
+// the first frame is searched within this many bytes after the tag
+const mp3SyncWindow = 64 << 10
+
+// mp3Duration returns the playing time of an MP3 file from its first
+// frame: exact if it carries a Xing/Info or VBRI header, from the bitrate
+// otherwise. 0 means unknown.
+func mp3Duration(r io.ReadSeeker) time.Duration {
+	size, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return 0
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return 0
+	}
+
+	var offset int64
+	var hdr [10]byte
+	if _, err := io.ReadFull(r, hdr[:]); err == nil && string(hdr[:3]) == "ID3" {
+		offset = 10 + int64(syncsafe(hdr[6:10]))
+		if hdr[5]&0x10 != 0 {
+			offset += 10 // footer
+		}
+	}
+	if _, err := r.Seek(offset, io.SeekStart); err != nil {
+		return 0
+	}
+	buf := make([]byte, mp3SyncWindow)
+	n, _ := io.ReadFull(r, buf)
+	buf = buf[:n]
+
+	for i := 0; i+4 <= len(buf); i++ {
+		h, ok := parseMP3Header(buf[i:])
+		if !ok {
+			continue
+		}
+		if frames := vbrFrames(buf[i:], h); frames > 0 {
+			samples := time.Duration(frames) * time.Duration(h.samples)
+			return samples * time.Second / time.Duration(h.rate)
+		}
+		audio := size - offset - int64(i)
+		return time.Duration(audio*8) * time.Second / time.Duration(h.kbps*1000)
+	}
+	return 0
+}
+
+type mp3Header struct {
+	mpeg1   bool
+	mono    bool
+	kbps    int
+	rate    int // Hz
+	samples int // per frame
+}
+
+func parseMP3Header(b []byte) (mp3Header, bool) {
+	if b[0] != 0xff || b[1]&0xe0 != 0xe0 {
+		return mp3Header{}, false
+	}
+	version := b[1] >> 3 & 3 // 0 = MPEG 2.5, 2 = MPEG 2, 3 = MPEG 1
+	layer := b[1] >> 1 & 3   // 1 = layer III
+	if version == 1 || layer != 1 {
+		return mp3Header{}, false
+	}
+	h := mp3Header{
+		mpeg1:   version == 3,
+		mono:    b[3]>>6 == 3,
+		rate:    mp3Rates[b[2]>>2&3],
+		samples: 576,
+	}
+	if h.mpeg1 {
+		h.kbps = mp3Bitrates1[b[2]>>4]
+		h.samples = 1152
+	} else {
+		h.kbps = mp3Bitrates2[b[2]>>4]
+		h.rate /= 2
+		if version == 0 {
+			h.rate /= 2
+		}
+	}
+	if h.kbps == 0 || h.rate == 0 {
+		return mp3Header{}, false
+	}
+	return h, true
+}
+
+// vbrFrames reads the frame count of a Xing/Info or VBRI header in the
+// first frame f.
+func vbrFrames(f []byte, h mp3Header) uint32 {
+	// Xing follows the side information
+	side := 17
+	switch {
+	case h.mpeg1 && !h.mono:
+		side = 32
+	case !h.mpeg1 && h.mono:
+		side = 9
+	}
+	if len(f) >= 4+side+12 {
+		x := f[4+side:]
+		if string(x[:4]) == "Xing" || string(x[:4]) == "Info" {
+			if binary.BigEndian.Uint32(x[4:8])&1 != 0 {
+				return binary.BigEndian.Uint32(x[8:12])
+			}
+			return 0
+		}
+	}
+	// VBRI sits at a fixed offset: id, version, delay, quality, bytes, frames
+	if len(f) >= 36+18 && string(f[36:40]) == "VBRI" {
+		return binary.BigEndian.Uint32(f[50:54])
+	}
+	return 0
+}
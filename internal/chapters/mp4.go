@@ -0,0 +1,381 @@
+package chapters
+
+import (
+	"encoding/binary"
+	"io"
+	"time"
+	"unicode/utf8"
+)
+
+// moov boxes of audiobooks are small; anything larger is not a file for us.
+const maxMoovSize = 64 << 20
+
+// ParseMP4 reads chapters of an MP4/M4B file. QuickTime chapter tracks
+// (iTunes, most m4b tools) win over the Nero chpl box.
+func ParseMP4(r io.ReadSeeker) ([]Chapter, error) {
+	moov, err := findBox(r, "moov")
+	if err != nil || moov == nil {
+		return nil, err
+	}
+
+	var total time.Duration
+	if mvhd := child(moov, "mvhd"); mvhd != nil {
+		total = mvhdDuration(mvhd)
+	}
+
+	var traks []*trak
+	for _, b := range children(moov) {
+		if b.typ == "trak" {
+			if t := parseTrak(b.data); t != nil {
+				traks = append(traks, t)
+			}
+		}
+	}
+	if chs := quickTimeChapters(r, traks); len(chs) > 0 {
+		return finish(chs, total), nil
+	}
+
+	if udta := child(moov, "udta"); udta != nil {
+		if chpl := child(udta, "chpl"); chpl != nil {
+			if chs := parseChpl(chpl); len(chs) > 0 {
+				return finish(chs, total), nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+type box struct {
+	typ  string
+	data []byte // payload
+}
+
+// findBox scans top-level boxes for typ and reads its payload.
+func findBox(r io.ReadSeeker, typ string) ([]byte, error) {
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	for {
+		var hdr [8]byte
+		if _, err := io.ReadFull(r, hdr[:]); err != nil {
+			return nil, nil // no such box
+		}
+		size := int64(binary.BigEndian.Uint32(hdr[:4]))
+		name := string(hdr[4:8])
+		hlen := int64(8)
+
+		switch size {
+		case 0: // box extends to the end of the file
+			if name != typ {
+				return nil, nil
+			}
+			return io.ReadAll(io.LimitReader(r, maxMoovSize))
+		case 1:
+			var ext [8]byte
+			if _, err := io.ReadFull(r, ext[:]); err != nil {
+				return nil, ErrInvalid
+			}
+			size = int64(binary.BigEndian.Uint64(ext[:]))
+			hlen = 16
+		}
+		if size < hlen {
+			return nil, ErrInvalid
+		}
+
+		if name == typ {
+			if size-hlen > maxMoovSize {
+				return nil, ErrInvalid
+			}
+			data := make([]byte, size-hlen)
+			if _, err := io.ReadFull(r, data); err != nil {
+				return nil, ErrInvalid
+			}
+			return data, nil
+		}
+		if _, err := r.Seek(size-hlen, io.SeekCurrent); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func children(b []byte) []box {
+	var out []box
+	for len(b) >= 8 {
+		// sizes stay uint64 until checked: int overflows on 32 bit
+		size := uint64(binary.BigEndian.Uint32(b[:4]))
+		typ := string(b[4:8])
+		hlen := uint64(8)
+		if size == 1 && len(b) >= 16 {
+			size = binary.BigEndian.Uint64(b[8:16])
+			hlen = 16
+		} else if size == 0 {
+			size = uint64(len(b))
+		}
+		if size < hlen || size > uint64(len(b)) {
+			break
+		}
+		out = append(out, box{typ: typ, data: b[hlen:size]})
+		b = b[size:]
+	}
+	return out
+}
+
+func child(b []byte, typ string) []byte {
+	for _, c := range children(b) {
+		if c.typ == typ {
+			return c.data
+		}
+	}
+	return nil
+}
+
+// path walks nested boxes, e.g. path(trak, "mdia", "minf", "stbl").
+func path(b []byte, types ...string) []byte {
+	for _, t := range types {
+		if b = child(b, t); b == nil {
+			return nil
+		}
+	}
+	return b
+}
+
+func mvhdDuration(b []byte) time.Duration {
+	// version 0: ctime(4) mtime(4) timescale(4) duration(4)
+	// version 1: ctime(8) mtime(8) timescale(4) duration(8)
+	if len(b) < 20 {
+		return 0
+	}
+	if b[0] == 1 {
+		if len(b) < 32 {
+			return 0
+		}
+		return ticks(binary.BigEndian.Uint64(b[24:32]), binary.BigEndian.Uint32(b[20:24]))
+	}
+	return ticks(uint64(binary.BigEndian.Uint32(b[16:20])), binary.BigEndian.Uint32(b[12:16]))
+}
+
+func ticks(n uint64, timescale uint32) time.Duration {
+	if timescale == 0 {
+		return 0
+	}
+	return time.Duration(n) * time.Second / time.Duration(timescale)
+}
+
+// Nero chapters: version, flags, [reserved], count, {start (100ns), len, title}
+func parseChpl(b []byte) []Chapter {
+	if len(b) < 5 {
+		return nil
+	}
+	off := 4
+	if b[0] == 1 {
+		off += 4
+	}
+	if off >= len(b) {
+		return nil
+	}
+	n := int(b[off])
+	off++
+
+	chs := make([]Chapter, 0, n)
+	for i := 0; i < n && off+9 <= len(b); i++ {
+		start := binary.BigEndian.Uint64(b[off:])
+		l := int(b[off+8])
+		off += 9
+		if off+l > len(b) {
+			break
+		}
+		chs = append(chs, Chapter{
+			Title: string(b[off : off+l]),
+			Start: time.Duration(start) * 100,
+		})
+		off += l
+	}
+	return chs
+}
+
+type trak struct {
+	id        uint32
+	chapRefs  []uint32 // tref/chap
+	timescale uint32
+	stbl      []byte
+}
+
+func parseTrak(b []byte) *trak {
+	t := &trak{}
+	if tkhd := child(b, "tkhd"); len(tkhd) >= 24 {
+		if tkhd[0] == 1 {
+			t.id = binary.BigEndian.Uint32(tkhd[20:24])
+		} else {
+			t.id = binary.BigEndian.Uint32(tkhd[12:16])
+		}
+	}
+	if chap := path(b, "tref", "chap"); chap != nil {
+		for i := 0; i+4 <= len(chap); i += 4 {
+			t.chapRefs = append(t.chapRefs, binary.BigEndian.Uint32(chap[i:]))
+		}
+	}
+	if mdhd := path(b, "mdia", "mdhd"); len(mdhd) >= 24 {
+		if mdhd[0] == 1 {
+			t.timescale = binary.BigEndian.Uint32(mdhd[20:24])
+		} else {
+			t.timescale = binary.BigEndian.Uint32(mdhd[12:16])
+		}
+	}
+	t.stbl = path(b, "mdia", "minf", "stbl")
+	return t
+}
+
+// quickTimeChapters reads the text track referenced by tref/chap: one
+// sample per chapter, the sample time is the chapter start.
+func quickTimeChapters(r io.ReadSeeker, traks []*trak) []Chapter {
+	var ref uint32
+	for _, t := range traks {
+		if len(t.chapRefs) > 0 {
+			ref = t.chapRefs[0]
+			break
+		}
+	}
+	if ref == 0 {
+		return nil
+	}
+	var text *trak
+	for _, t := range traks {
+		if t.id == ref {
+			text = t
+		}
+	}
+	if text == nil || text.stbl == nil {
+		return nil
+	}
+
+	starts := sampleTimes(child(text.stbl, "stts"), text.timescale)
+	sizes := sampleSizes(child(text.stbl, "stsz"))
+	offsets := sampleOffsets(text.stbl, sizes)
+
+	n := len(starts)
+	if len(offsets) < n {
+		n = len(offsets)
+	}
+	chs := make([]Chapter, 0, n)
+	for i := 0; i < n; i++ {
+		title := readTextSample(r, offsets[i], sizes[i])
+		chs = append(chs, Chapter{Title: title, Start: starts[i]})
+	}
+	return chs
+}
+
+func sampleTimes(stts []byte, timescale uint32) []time.Duration {
+	if len(stts) < 8 {
+		return nil
+	}
+	n := int(binary.BigEndian.Uint32(stts[4:8]))
+	var out []time.Duration
+	var t uint64
+	for i := 0; i < n && 8+i*8+8 <= len(stts); i++ {
+		e := stts[8+i*8:]
+		count := binary.BigEndian.Uint32(e[0:4])
+		delta := binary.BigEndian.Uint32(e[4:8])
+		for j := uint32(0); j < count && len(out) < 10000; j++ {
+			out = append(out, ticks(t, timescale))
+			t += uint64(delta)
+		}
+	}
+	return out
+}
+
+func sampleSizes(stsz []byte) []uint32 {
+	if len(stsz) < 12 {
+		return nil
+	}
+	fixed := binary.BigEndian.Uint32(stsz[4:8])
+	count := binary.BigEndian.Uint32(stsz[8:12])
+	if count > 10000 {
+		count = 10000
+	}
+	n := int(count)
+	out := make([]uint32, 0, n)
+	for i := 0; i < n; i++ {
+		if fixed != 0 {
+			out = append(out, fixed)
+			continue
+		}
+		if 12+i*4+4 > len(stsz) {
+			break
+		}
+		out = append(out, binary.BigEndian.Uint32(stsz[12+i*4:]))
+	}
+	return out
+}
+
+// sampleOffsets combines the chunk offsets (stco/co64) with the
+// sample-to-chunk table (stsc) into one file offset per sample.
+func sampleOffsets(stbl []byte, sizes []uint32) []int64 {
+	var chunks []int64
+	if stco := child(stbl, "stco"); len(stco) >= 8 {
+		n := int(binary.BigEndian.Uint32(stco[4:8]))
+		for i := 0; i < n && 8+i*4+4 <= len(stco); i++ {
+			chunks = append(chunks, int64(binary.BigEndian.Uint32(stco[8+i*4:])))
+		}
+	} else if co64 := child(stbl, "co64"); len(co64) >= 8 {
+		n := int(binary.BigEndian.Uint32(co64[4:8]))
+		for i := 0; i < n && 8+i*8+8 <= len(co64); i++ {
+			chunks = append(chunks, int64(binary.BigEndian.Uint64(co64[8+i*8:])))
+		}
+	}
+
+	type run struct{ first, perChunk uint32 }
+	var runs []run
+	if stsc := child(stbl, "stsc"); len(stsc) >= 8 {
+		n := int(binary.BigEndian.Uint32(stsc[4:8]))
+		for i := 0; i < n && 8+i*12+12 <= len(stsc); i++ {
+			e := stsc[8+i*12:]
+			runs = append(runs, run{binary.BigEndian.Uint32(e[0:4]), binary.BigEndian.Uint32(e[4:8])})
+		}
+	}
+	if len(runs) == 0 {
+		runs = []run{{1, 1}}
+	}
+
+	var out []int64
+	sample := 0
+	for ci, off := range chunks {
+		per := runs[0].perChunk
+		for _, r := range runs {
+			if uint32(ci+1) >= r.first {
+				per = r.perChunk
+			}
+		}
+		for j := uint32(0); j < per && sample < len(sizes); j++ {
+			out = append(out, off)
+			off += int64(sizes[sample])
+			sample++
+		}
+	}
+	return out
+}
+
+// readTextSample reads a QuickTime text sample: 16 bit length + text.
+func readTextSample(r io.ReadSeeker, off int64, size uint32) string {
+	if size < 2 || size > 4096 {
+		return ""
+	}
+	buf := make([]byte, size)
+	if _, err := r.Seek(off, io.SeekStart); err != nil {
+		return ""
+	}
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return ""
+	}
+	l := int(binary.BigEndian.Uint16(buf[:2]))
+	if 2+l > len(buf) {
+		l = len(buf) - 2
+	}
+	text := buf[2 : 2+l]
+	if len(text) >= 2 && (text[0] == 0xFE && text[1] == 0xFF || text[0] == 0xFF && text[1] == 0xFE) {
+		return decodeUTF16(text, true)
+	}
+	if !utf8.Valid(text) {
+		return ""
+	}
+	return string(text)
+}
@@ -0,0 +1,194 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+
+	"mupibox/internal/button"
+	"mupibox/internal/lirc"
+	"mupibox/internal/mixer"
+	"mupibox/internal/volume"
+)
+
+// Config holds box settings from config/mupibox.json.
+// Missing file or missing fields fall back to Default().
+type Config struct {
+	Sync      Sync      `json:"sync"`
+	Retention Retention `json:"retention"`
+	Status    Status    `json:"status"`
+	Admin     Admin     `json:"admin"`
+	Wifi      Wifi      `json:"wifi"`
+	Power     Power     `json:"power"`
+	RFID      RFID      `json:"rfid"`
+	Buttons   Buttons   `json:"buttons"`
+	Encoder   Encoder   `json:"encoder"`
+	LIRC      LIRC      `json:"lirc"`
+	Mixer     Mixer     `json:"mixer"`
+
+	// volume limits until parents change them via the admin API
+	Volume volume.Settings `json:"volume"`
+}
+
+// Mixer selects where the volume goes.
+type Mixer struct {
+	Type      string  `json:"type,omitempty"`    // alsa, leer = Software-Lautstärke des Players
+	Card      string  `json:"card,omitempty"`    // amixer -c
+	Control   string  `json:"control,omitempty"` // z.B. PCM (Standard), Master, Digital
+	MinDB     float64 `json:"min_db,omitempty"`  // Lautstärke 1 entspricht knapp darüber
+	StateFile string  `json:"state_file,omitempty"`
+}
+
+// LIRC maps IR remote keys from lircd to player commands.
+type LIRC struct {
+	Socket      string                  `json:"socket,omitempty"` // z.B. /var/run/lirc/lircd, leer = aus
+	Remote      string                  `json:"remote,omitempty"` // nur diese Fernbedienung
+	RepeatDelay int                     `json:"repeat_delay,omitempty"`
+	Keys        map[string]lirc.Binding `json:"keys,omitempty"`
+}
+
+// Encoder configures a KY-040 rotary encoder. It uses the source and chip
+// of Buttons.
+type Encoder struct {
+	Enabled bool `json:"enabled,omitempty"`
+	LineA   int  `json:"line_a"`            // CLK
+	LineB   int  `json:"line_b"`            // DT
+	LineSW  int  `json:"line_sw,omitempty"` // Taster, 0 = keiner
+
+	StepsPerDetent int  `json:"steps_per_detent,omitempty"`
+	Reverse        bool `json:"reverse,omitempty"`
+
+	VolumeStep  int    `json:"volume_step,omitempty"`
+	SkipSeconds int    `json:"skip_seconds,omitempty"`
+	Mode        string `json:"mode,omitempty"`        // volume oder skip
+	ModeSwitch  bool   `json:"mode_switch,omitempty"` // langer Druck wechselt den Modus
+}
+
+// Buttons maps GPIO buttons to player commands (see player.Command).
+type Buttons struct {
+	Source string `json:"source,omitempty"` // chardev oder sysfs
+	Chip   string `json:"chip,omitempty"`   // für chardev
+
+	DebounceMs int `json:"debounce_ms,omitempty"`
+	LongMs     int `json:"long_ms,omitempty"`
+	DoubleMs   int `json:"double_ms,omitempty"`
+	RepeatMs   int `json:"repeat_ms,omitempty"`
+
+	Buttons []button.Binding `json:"buttons,omitempty"`
+}
+
+// RFID configures USB card readers that act as keyboards.
+type RFID struct {
+	Devices []string `json:"devices,omitempty"` // Pfade oder Globs, z.B. /dev/input/by-id/*-event-kbd
+	Grab    bool     `json:"grab,omitempty"`    // exklusiv lesen
+}
+
+// Admin protects the parent API (/api/admin/...).
+type Admin struct {
+	PIN string `json:"pin,omitempty"` // leer = Eltern-API deaktiviert
+}
+
+// Wifi configures network management via wpa_supplicant.
+type Wifi struct {
+	CtrlPath string `json:"ctrl_path,omitempty"`
+}
+
+// Sync pulls resume state from another box.
+type Sync struct {
+	PeerURL     string `json:"peer_url,omitempty"` // z.B. http://mupibox-kinderzimmer.local:8080, leer = aus
+	IntervalSec int    `json:"interval_sec,omitempty"`
+}
+
+// Retention drops old resume entries automatically (0 = no limit).
+type Retention struct {
+	MaxAgeDays   int  `json:"max_age_days,omitempty"`
+	MaxEntries   int  `json:"max_entries,omitempty"`
+	PruneOrphans bool `json:"prune_orphans,omitempty"` // entries of items no longer in the catalog
+}
+
+// Status configures the hardware status providers.
+type Status struct {
+	Root          string `json:"root,omitempty"` // filesystem root for sysfs/procfs, "/" on the box
+	IntervalSec   int    `json:"interval_sec,omitempty"`
+	WifiInterface string `json:"wifi_interface,omitempty"` // leer = automatisch
+}
+
+// Power configures low-battery handling (percent, 0 = stage disabled).
+type Power struct {
+	WarnPercent     int      `json:"warn_percent,omitempty"`
+	WarnMaxVolume   int      `json:"warn_max_volume,omitempty"`
+	SavePercent     int      `json:"save_percent,omitempty"`
+	CriticalPercent int      `json:"critical_percent,omitempty"`
+	ShutdownCommand []string `json:"shutdown_command,omitempty"`
+
+	// idle power-off after playback was paused/stopped (0 = aus); off by
+	// default, so the mock doesn't power off a developer's machine
+	IdleMinutes    int      `json:"idle_minutes,omitempty"`
+	IdleWarningSec int      `json:"idle_warning_sec,omitempty"`
+	IdleCommand    []string `json:"idle_command,omitempty"`
+}
+
+func Default() Config {
+	return Config{
+		Sync: Sync{
+			IntervalSec: 300,
+		},
+		Retention: Retention{
+			MaxAgeDays: 180,
+			MaxEntries: 200,
+		},
+		Status: Status{
+			Root:        "/",
+			IntervalSec: 10,
+		},
+		Wifi: Wifi{
+			CtrlPath: "/var/run/wpa_supplicant/wlan0",
+		},
+		Power: Power{
+			WarnPercent:     15,
+			WarnMaxVolume:   40,
+			SavePercent:     8,
+			CriticalPercent: 4,
+			ShutdownCommand: []string{"sudo", "systemctl", "poweroff"},
+			IdleWarningSec:  60,
+			IdleCommand:     []string{"sudo", "systemctl", "poweroff"},
+		},
+		RFID: RFID{
+			Grab: true,
+		},
+		Buttons: Buttons{
+			Source: "chardev",
+			Chip:   "/dev/gpiochip0",
+		},
+		Encoder: Encoder{
+			StepsPerDetent: 4,
+			VolumeStep:     2,
+			SkipSeconds:    10,
+			Mode:           "volume",
+		},
+		LIRC: LIRC{
+			RepeatDelay: 2,
+		},
+		Mixer: Mixer{
+			Control:   mixer.DefaultControl,
+			MinDB:     -50,
+			StateFile: "data/asound.state",
+		},
+	}
+}
+
+func Load(path string) (*Config, error) {
+	c := Default()
+
+	raw, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &c, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
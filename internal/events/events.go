@@ -0,0 +1,94 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+type Event struct {
+	Type string `json:"type"` // z.B. battery, wifi
+	Time string `json:"time"` // RFC3339
+	Data any    `json:"data,omitempty"`
+}
+
+// Bus fans events out to subscribers. Publishing never blocks; slow
+// subscribers miss events instead of stalling the box.
+type Bus struct {
+	mu   sync.Mutex
+	subs map[chan Event]struct{}
+}
+
+func NewBus() *Bus {
+	return &Bus{subs: map[chan Event]struct{}{}}
+}
+
+func (b *Bus) Publish(typ string, data any) {
+	ev := Event{
+		Type: typ,
+		Time: time.Now().UTC().Format(time.RFC3339),
+		Data: data,
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch := range b.subs {
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+}
+
+// Subscribe returns an event channel and a func to unsubscribe.
+func (b *Bus) Subscribe() (<-chan Event, func()) {
+	ch := make(chan Event, 16)
+
+	b.mu.Lock()
+	b.subs[ch] = struct{}{}
+	b.mu.Unlock()
+
+	return ch, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if _, ok := b.subs[ch]; ok {
+			delete(b.subs, ch)
+			close(ch)
+		}
+	}
+}
+
+// Handler streams events as server-sent events (GET /api/events).
+func (b *Bus) Handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		flusher.Flush()
+
+		ch, unsubscribe := b.Subscribe()
+		defer unsubscribe()
+
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case ev := <-ch:
+				raw, _ := json.Marshal(ev)
+				fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev.Type, raw)
+				flusher.Flush()
+			}
+		}
+	}
+}
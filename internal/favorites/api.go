@@ -0,0 +1,132 @@
+package favorites
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+type API struct {
+	// Store returns the favorites of the active profile.
+	Store func() *Store
+}
+
+func NewAPI(store func() *Store) *API {
+	return &API{Store: store}
+}
+
+func (a *API) Register(mux *http.ServeMux) {
+	// List (GET) + add (POST)
+	mux.HandleFunc("/api/favorites", a.handleFavorites)
+
+	// Heart button in the UI
+	mux.HandleFunc("/api/favorites/toggle", a.postOnly(a.handleToggle))
+
+	// Custom order
+	mux.HandleFunc("/api/favorites/order", a.handleOrder)
+
+	// Remove: DELETE /api/favorites/{kind}:{id}
+	mux.HandleFunc("/api/favorites/", a.handleFavorite)
+}
+
+func (a *API) handleFavorites(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, a.Store().List())
+	case http.MethodPost:
+		f, ok := decodeFavorite(w, r)
+		if !ok {
+			return
+		}
+		if err := a.Store().Add(f); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		writeOK(w)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (a *API) handleToggle(w http.ResponseWriter, r *http.Request) {
+	f, ok := decodeFavorite(w, r)
+	if !ok {
+		return
+	}
+	fav, err := a.Store().Toggle(f)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, map[string]any{"key": f.Key(), "favorite": fav})
+}
+
+func (a *API) handleOrder(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut && r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var body struct {
+		Keys []string `json:"keys"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	if err := a.Store().Reorder(body.Keys); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, a.Store().List())
+}
+
+func (a *API) handleFavorite(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	key := strings.TrimPrefix(r.URL.Path, "/api/favorites/")
+	removed, err := a.Store().Remove(key)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !removed {
+		http.NotFound(w, r)
+		return
+	}
+	writeOK(w)
+}
+
+func decodeFavorite(w http.ResponseWriter, r *http.Request) (Favorite, bool) {
+	var f Favorite
+	if err := json.NewDecoder(r.Body).Decode(&f); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return f, false
+	}
+	if !f.valid() {
+		http.Error(w, ErrInvalid.Error(), http.StatusBadRequest)
+		return f, false
+	}
+	return f, true
+}
+
+func (a *API) postOnly(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		next(w, r)
+	}
+}
+
+func writeOK(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{"ok": true})
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
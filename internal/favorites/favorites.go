@@ -0,0 +1,190 @@
+package favorites
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+	"time"
+)
+
+type Kind string
+
+const (
+	KindItem    Kind = "item"    // catalog item (artist, playlist, podcast)
+	KindAlbum   Kind = "album"   // album/folge of a catalog item
+	KindEpisode Kind = "episode" // podcast episode
+)
+
+var ErrInvalid = errors.New("invalid favorite")
+
+type Favorite struct {
+	Kind   Kind   `json:"kind"`
+	ID     string `json:"id"`                // item, album or episode id
+	ItemID string `json:"item_id,omitempty"` // parent catalog item for album/episode
+
+	AddedAt string `json:"added_at,omitempty"` // RFC3339
+}
+
+// Key identifies a favorite, e.g. "album:benjamin_bluemchen_12".
+func (f Favorite) Key() string {
+	return string(f.Kind) + ":" + f.ID
+}
+
+func (f Favorite) valid() bool {
+	switch f.Kind {
+	case KindItem:
+		return f.ID != ""
+	case KindAlbum, KindEpisode:
+		return f.ID != "" && f.ItemID != ""
+	default:
+		return false
+	}
+}
+
+// Store keeps the ordered favorites of one profile.
+type Store struct {
+	path string
+	mu   sync.Mutex
+	data []Favorite // user order
+
+	disabled error // set by Disabled, every change fails with it
+}
+
+// Disabled returns an empty store that refuses every change with err,
+// in place of a store that can't be opened.
+func Disabled(err error) *Store {
+	return &Store{data: []Favorite{}, disabled: err}
+}
+
+func NewStore(path string) (*Store, error) {
+	s := &Store{
+		path: path,
+		data: []Favorite{},
+	}
+
+	raw, err := os.ReadFile(path)
+	if err == nil {
+		_ = json.Unmarshal(raw, &s.data)
+	}
+
+	if os.IsNotExist(err) {
+		_ = os.WriteFile(path, []byte("[]"), 0644)
+	}
+
+	return s, nil
+}
+
+func (s *Store) List() []Favorite {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Favorite{}, s.data...)
+}
+
+func (s *Store) Has(key string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.indexLocked(key) >= 0
+}
+
+// Add appends f at the end. Adding an existing favorite is a no-op.
+func (s *Store) Add(f Favorite) error {
+	if !f.valid() {
+		return ErrInvalid
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.disabled != nil {
+		return s.disabled
+	}
+	if s.indexLocked(f.Key()) >= 0 {
+		return nil
+	}
+	return s.addLocked(f)
+}
+
+// Remove deletes a favorite; it reports whether it existed.
+func (s *Store) Remove(key string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.disabled != nil {
+		return false, s.disabled
+	}
+	i := s.indexLocked(key)
+	if i < 0 {
+		return false, nil
+	}
+	return true, s.removeLocked(i)
+}
+
+// Toggle adds or removes f and returns whether it is a favorite afterwards.
+func (s *Store) Toggle(f Favorite) (bool, error) {
+	if !f.valid() {
+		return false, ErrInvalid
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.disabled != nil {
+		return false, s.disabled
+	}
+	if i := s.indexLocked(f.Key()); i >= 0 {
+		return false, s.removeLocked(i)
+	}
+	return true, s.addLocked(f)
+}
+
+// Reorder moves the given keys to the front in that order.
+// Unknown keys are ignored, unlisted favorites keep their relative order.
+func (s *Store) Reorder(keys []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.disabled != nil {
+		return s.disabled
+	}
+	out := make([]Favorite, 0, len(s.data))
+	used := map[string]bool{}
+	for _, k := range keys {
+		if i := s.indexLocked(k); i >= 0 && !used[k] {
+			out = append(out, s.data[i])
+			used[k] = true
+		}
+	}
+	for _, f := range s.data {
+		if !used[f.Key()] {
+			out = append(out, f)
+		}
+	}
+	s.data = out
+	return s.persist()
+}
+
+func (s *Store) addLocked(f Favorite) error {
+	f.AddedAt = time.Now().UTC().Format(time.RFC3339)
+	s.data = append(s.data, f)
+	return s.persist()
+}
+
+func (s *Store) removeLocked(i int) error {
+	s.data = append(s.data[:i], s.data[i+1:]...)
+	return s.persist()
+}
+
+func (s *Store) indexLocked(key string) int {
+	for i, f := range s.data {
+		if f.Key() == key {
+			return i
+		}
+	}
+	return -1
+}
+
+func (s *Store) persist() error {
+	raw, _ := json.MarshalIndent(s.data, "", "  ")
+	return os.WriteFile(s.path, raw, 0644)
+}
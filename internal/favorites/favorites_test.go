@@ -0,0 +1,55 @@
+package favorites
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestToggleConcurrent(t *testing.T) {
+	s, err := NewStore(filepath.Join(t.TempDir(), "favorites.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := Favorite{Kind: KindItem, ID: "benjamin_bluemchen"}
+
+	const n = 50 // even: ends where it started
+	var (
+		wg    sync.WaitGroup
+		mu    sync.Mutex
+		added int
+	)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			fav, err := s.Toggle(f)
+			if err != nil {
+				t.Error(err)
+			}
+			if fav {
+				mu.Lock()
+				added++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if added != n/2 {
+		t.Errorf("%d toggles reported added, want %d", added, n/2)
+	}
+	if s.Has(f.Key()) {
+		t.Error("still a favorite after an even number of toggles")
+	}
+}
+
+func TestToggleInvalid(t *testing.T) {
+	s, err := NewStore(filepath.Join(t.TempDir(), "favorites.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Toggle(Favorite{Kind: KindAlbum, ID: "x"}); err != ErrInvalid {
+		t.Fatalf("err = %v, want ErrInvalid", err)
+	}
+}
@@ -0,0 +1,153 @@
+//go:build linux
+
+package gpio
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+	"sync"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// GPIO character device, v1 ABI (linux/gpio.h)
+const (
+	gpioGetLineeventIoctl = 0xc030b404 // _IOWR(0xB4, 0x04, struct gpioevent_request)
+
+	handleRequestInput = 1 << 0
+	eventRequestBoth   = 0x3
+	eventRisingEdge    = 0x1
+
+	eventDataSize = 16 // struct gpioevent_data: u64 timestamp, u32 id (+ padding)
+
+	clockMonotonic = 1
+)
+
+type gpioeventRequest struct {
+	LineOffset    uint32
+	HandleFlags   uint32
+	EventFlags    uint32
+	ConsumerLabel [32]byte
+	Fd            int32
+}
+
+// Chip reads edges from /dev/gpiochipN, one line event fd per line.
+type Chip struct {
+	edges chan Edge
+	files []*os.File
+	once  sync.Once
+}
+
+// OpenChip requests edge events for lines on a GPIO chip, e.g.
+// /dev/gpiochip0 with the BCM numbers on a Raspberry Pi.
+func OpenChip(path string, lines []int) (*Chip, error) {
+	chip, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer chip.Close()
+
+	c := &Chip{edges: make(chan Edge, 64)}
+	for _, line := range lines {
+		req := gpioeventRequest{
+			LineOffset:  uint32(line),
+			HandleFlags: handleRequestInput,
+			EventFlags:  eventRequestBoth,
+		}
+		copy(req.ConsumerLabel[:], "mupibox")
+
+		_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, chip.Fd(), gpioGetLineeventIoctl, uintptr(unsafe.Pointer(&req)))
+		if errno != 0 {
+			c.Close()
+			return nil, fmt.Errorf("gpio: line %d on %s: %w", line, path, errno)
+		}
+
+		// non-blocking, so Close wakes up the reader
+		_ = syscall.SetNonblock(int(req.Fd), true)
+		f := os.NewFile(uintptr(req.Fd), fmt.Sprintf("%s:%d", path, line))
+		c.files = append(c.files, f)
+		go c.read(f, line)
+	}
+	return c, nil
+}
+
+func (c *Chip) Edges() <-chan Edge { return c.edges }
+
+func (c *Chip) Close() error {
+	c.once.Do(func() {
+		for _, f := range c.files {
+			f.Close()
+		}
+	})
+	return nil
+}
+
+func (c *Chip) read(f *os.File, line int) {
+	buf := make([]byte, eventDataSize*16)
+	for {
+		n, err := f.Read(buf)
+		if err != nil {
+			return
+		}
+		// reads are batched: the kernel timestamps keep the real gaps
+		// between edges, which debouncing and press timing rely on
+		now := time.Now()
+		mono, err := monotonic()
+		if err != nil {
+			mono = -1
+		}
+		for _, e := range parseEvents(buf[:n], line, now, mono) {
+			c.send(e)
+		}
+	}
+}
+
+// parseEvents decodes struct gpioevent_data records. now and mono are
+// read together and map the kernel timestamps to wall-clock time.
+func parseEvents(buf []byte, line int, now time.Time, mono time.Duration) []Edge {
+	var out []Edge
+	for off := 0; off+eventDataSize <= len(buf); off += eventDataSize {
+		ts := binary.LittleEndian.Uint64(buf[off:])
+		id := binary.LittleEndian.Uint32(buf[off+8:])
+		out = append(out, Edge{Line: line, Rising: id == eventRisingEdge, Time: eventTime(ts, now, mono)})
+	}
+	return out
+}
+
+// eventTime converts a kernel timestamp (ns). Since Linux 5.7 it is
+// CLOCK_MONOTONIC, older kernels use CLOCK_REALTIME; monotonic stamps are
+// at most the uptime, realtime ones are decades larger. mono < 0 = unknown.
+func eventTime(ts uint64, now time.Time, mono time.Duration) time.Time {
+	const day = 24 * time.Hour
+	if ts > math.MaxInt64 {
+		return now
+	}
+	d := time.Duration(ts)
+	if mono >= 0 && d <= mono+day {
+		return now.Add(d - mono)
+	}
+	if t := time.Unix(0, int64(ts)); t.Sub(now) < day && now.Sub(t) < day {
+		return t
+	}
+	return now
+}
+
+func monotonic() (time.Duration, error) {
+	var ts syscall.Timespec
+	_, _, errno := syscall.Syscall(syscall.SYS_CLOCK_GETTIME, clockMonotonic, uintptr(unsafe.Pointer(&ts)), 0)
+	if errno != 0 {
+		return 0, errno
+	}
+	return time.Duration(ts.Nano()), nil
+}
+
+// send never blocks: a stuck consumer loses edges, not the reader.
+func (c *Chip) send(e Edge) {
+	select {
+	case c.edges <- e:
+	default:
+	}
+}
@@ -0,0 +1,68 @@
+//go:build linux
+
+package gpio
+
+import (
+	"encoding/binary"
+	"testing"
+	"time"
+)
+
+func gpioEvent(ts uint64, rising bool) []byte {
+	b := make([]byte, eventDataSize)
+	binary.LittleEndian.PutUint64(b, ts)
+	id := uint32(2) // falling
+	if rising {
+		id = eventRisingEdge
+	}
+	binary.LittleEndian.PutUint32(b[8:], id)
+	return b
+}
+
+func TestParseEventsMonotonic(t *testing.T) {
+	now := time.Date(2026, 1, 1, 7, 0, 0, 0, time.UTC)
+	mono := 5 * time.Hour
+
+	// one read with a press and a release 120ms later, read 10ms after
+	var buf []byte
+	buf = append(buf, gpioEvent(uint64(mono-130*time.Millisecond), false)...)
+	buf = append(buf, gpioEvent(uint64(mono-10*time.Millisecond), true)...)
+	buf = append(buf, 0xff, 0xff) // partial record is ignored
+
+	edges := parseEvents(buf, 17, now, mono)
+	if len(edges) != 2 {
+		t.Fatalf("%d edges, want 2", len(edges))
+	}
+	if edges[0].Line != 17 || edges[0].Rising || !edges[1].Rising {
+		t.Fatalf("edges = %+v", edges)
+	}
+	if got := edges[1].Time.Sub(edges[0].Time); got != 120*time.Millisecond {
+		t.Errorf("gap = %s, want 120ms", got)
+	}
+	if want := now.Add(-10 * time.Millisecond); !edges[1].Time.Equal(want) {
+		t.Errorf("time = %s, want %s", edges[1].Time, want)
+	}
+}
+
+func TestEventTime(t *testing.T) {
+	now := time.Date(2026, 1, 1, 7, 0, 0, 0, time.UTC)
+	mono := 48 * time.Hour
+
+	tests := []struct {
+		name string
+		ts   uint64
+		mono time.Duration
+		want time.Time
+	}{
+		{"monotonic", uint64(mono - time.Second), mono, now.Add(-time.Second)},
+		{"realtime (old kernels)", uint64(now.Add(-time.Second).UnixNano()), mono, now.Add(-time.Second)},
+		{"realtime, clock unknown", uint64(now.Add(-time.Second).UnixNano()), -1, now.Add(-time.Second)},
+		{"garbage", 1 << 63, mono, now},
+		{"far from both clocks", uint64(now.Add(-72 * time.Hour).UnixNano()), mono, now},
+	}
+	for _, tt := range tests {
+		if got := eventTime(tt.ts, now, tt.mono); !got.Equal(tt.want) {
+			t.Errorf("%s: %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
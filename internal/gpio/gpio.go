@@ -0,0 +1,43 @@
+package gpio
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+var ErrUnsupported = errors.New("gpio: only supported on linux")
+
+// Edge is a level change of an input line.
+type Edge struct {
+	Line   int       // offset on the chip / sysfs gpio number
+	Rising bool      // true = low -> high
+	Time   time.Time // when the change was seen
+}
+
+// Source delivers edges of a set of input lines. Tests and other inputs
+// (e.g. a rotary encoder on the same chip) only need to implement this.
+type Source interface {
+	Edges() <-chan Edge
+	Close() error
+}
+
+// Open opens lines via "chardev" (default, chip e.g. /dev/gpiochip0) or
+// "sysfs".
+func Open(kind, chip string, lines []int) (Source, error) {
+	switch kind {
+	case "", "chardev":
+		c, err := OpenChip(chip, lines)
+		if err != nil {
+			return nil, err
+		}
+		return c, nil
+	case "sysfs":
+		s, err := OpenSysfs("", lines)
+		if err != nil {
+			return nil, err
+		}
+		return s, nil
+	}
+	return nil, fmt.Errorf("gpio: unknown source %q", kind)
+}
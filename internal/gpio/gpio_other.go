@@ -0,0 +1,17 @@
+//go:build !linux
+
+package gpio
+
+type Chip struct{}
+
+func OpenChip(path string, lines []int) (*Chip, error) { return nil, ErrUnsupported }
+
+func (c *Chip) Edges() <-chan Edge { return nil }
+func (c *Chip) Close() error       { return nil }
+
+type Sysfs struct{}
+
+func OpenSysfs(root string, lines []int) (*Sysfs, error) { return nil, ErrUnsupported }
+
+func (s *Sysfs) Edges() <-chan Edge { return nil }
+func (s *Sysfs) Close() error       { return nil }
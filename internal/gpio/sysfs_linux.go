@@ -0,0 +1,154 @@
+//go:build linux
+
+package gpio
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Sysfs reads edges via the legacy /sys/class/gpio interface, for
+// kernels or images without usable character devices.
+type Sysfs struct {
+	Root string // /sys/class/gpio
+
+	edges chan Edge
+	epfd  int
+	files map[int]*os.File // by fd
+	lines map[int]int      // fd -> line
+	last  map[int]bool     // line -> level
+	done  chan struct{}
+	once  sync.Once
+}
+
+func OpenSysfs(root string, lines []int) (*Sysfs, error) {
+	if root == "" {
+		root = "/sys/class/gpio"
+	}
+	epfd, err := syscall.EpollCreate1(syscall.EPOLL_CLOEXEC)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Sysfs{
+		Root:  root,
+		edges: make(chan Edge, 64),
+		epfd:  epfd,
+		files: map[int]*os.File{},
+		lines: map[int]int{},
+		last:  map[int]bool{},
+		done:  make(chan struct{}),
+	}
+	for _, line := range lines {
+		if err := s.open(line); err != nil {
+			// the loop isn't running yet, so Close wouldn't release anything
+			s.release()
+			return nil, fmt.Errorf("gpio: sysfs line %d: %w", line, err)
+		}
+	}
+	go s.loop()
+	return s, nil
+}
+
+func (s *Sysfs) open(line int) error {
+	dir := filepath.Join(s.Root, "gpio"+strconv.Itoa(line))
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		if err := os.WriteFile(filepath.Join(s.Root, "export"), []byte(strconv.Itoa(line)), 0200); err != nil {
+			return err
+		}
+		// udev needs a moment to fix permissions of the new files
+		time.Sleep(100 * time.Millisecond)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "direction"), []byte("in"), 0644); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, "edge"), []byte("both"), 0644); err != nil {
+		return err
+	}
+
+	f, err := os.Open(filepath.Join(dir, "value"))
+	if err != nil {
+		return err
+	}
+	fd := int(f.Fd())
+	ev := syscall.EpollEvent{Events: syscall.EPOLLPRI | syscall.EPOLLERR, Fd: int32(fd)}
+	if err := syscall.EpollCtl(s.epfd, syscall.EPOLL_CTL_ADD, fd, &ev); err != nil {
+		f.Close()
+		return err
+	}
+	s.files[fd] = f
+	s.lines[fd] = line
+	s.last[line], _ = readLevel(f)
+	return nil
+}
+
+func (s *Sysfs) Edges() <-chan Edge { return s.edges }
+
+func (s *Sysfs) Close() error {
+	s.once.Do(func() {
+		close(s.done)
+	})
+	return nil
+}
+
+// release closes the value files and the epoll fd.
+func (s *Sysfs) release() {
+	for _, f := range s.files {
+		f.Close()
+	}
+	syscall.Close(s.epfd)
+}
+
+func (s *Sysfs) loop() {
+	defer s.release()
+
+	events := make([]syscall.EpollEvent, 8)
+	for {
+		select {
+		case <-s.done:
+			return
+		default:
+		}
+
+		n, err := syscall.EpollWait(s.epfd, events, 500)
+		if err != nil && err != syscall.EINTR {
+			return
+		}
+		if n < 0 {
+			n = 0
+		}
+		now := time.Now()
+		for _, ev := range events[:n] {
+			fd := int(ev.Fd)
+			level, err := readLevel(s.files[fd])
+			if err != nil {
+				continue
+			}
+			line := s.lines[fd]
+			// sysfs reports the current level only; skip repeats
+			if level == s.last[line] {
+				continue
+			}
+			s.last[line] = level
+			select {
+			case s.edges <- Edge{Line: line, Rising: level, Time: now}:
+			default:
+			}
+		}
+	}
+}
+
+func readLevel(f *os.File) (bool, error) {
+	buf := make([]byte, 2)
+	// the value file is re-read from the start after every event
+	n, err := f.ReadAt(buf, 0)
+	if n == 0 {
+		return false, err
+	}
+	return buf[0] == '1', nil
+}
@@ -0,0 +1,47 @@
+//go:build linux
+
+package gpio
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func openFDs(t *testing.T) int {
+	t.Helper()
+	fds, err := os.ReadDir("/proc/self/fd")
+	if err != nil {
+		t.Skip(err)
+	}
+	return len(fds)
+}
+
+func TestOpenSysfsErrorClosesFiles(t *testing.T) {
+	root := t.TempDir()
+
+	// line 17 opens: its value file is a pipe, which epoll accepts
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+	dir := filepath.Join(root, "gpio17")
+	if err := os.Mkdir(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("/proc/self/fd/"+strconv.Itoa(int(r.Fd())), filepath.Join(dir, "value")); err != nil {
+		t.Fatal(err)
+	}
+
+	// line 27 doesn't: the export "succeeds" but no directory appears
+	before := openFDs(t)
+	if _, err := OpenSysfs(root, []int{17, 27}); err == nil {
+		t.Fatal("expected an error for line 27")
+	}
+	if after := openFDs(t); after != before {
+		t.Fatalf("%d fds open after the failed OpenSysfs, want %d", after, before)
+	}
+}
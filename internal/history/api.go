@@ -0,0 +1,89 @@
+package history
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+type API struct {
+	Store *Store
+}
+
+func NewAPI(s *Store) *API {
+	return &API{Store: s}
+}
+
+func (a *API) Register(mux *http.ServeMux) {
+	// Totals per day / item / profile
+	mux.HandleFunc("/api/stats", a.handleStats)
+
+	// Raw timeline, newest first
+	mux.HandleFunc("/api/stats/history", a.handleHistory)
+}
+
+// GET /api/stats?from=2026-01-01&to=2026-01-31&profile=&item=
+func (a *API) handleStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	writeJSON(w, a.Store.Stats(filterFromQuery(r)))
+}
+
+// GET /api/stats/history?offset=0&limit=50&from=&to=&profile=&item=
+func (a *API) handleHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	offset, ok := intQuery(w, r, "offset", 0)
+	if !ok {
+		return
+	}
+	limit, ok := intQuery(w, r, "limit", 50)
+	if !ok {
+		return
+	}
+
+	sessions, total := a.Store.List(filterFromQuery(r), offset, limit)
+	if sessions == nil {
+		sessions = []Session{}
+	}
+
+	writeJSON(w, map[string]any{
+		"total":    total,
+		"offset":   offset,
+		"limit":    limit,
+		"sessions": sessions,
+	})
+}
+
+func filterFromQuery(r *http.Request) Filter {
+	q := r.URL.Query()
+	return Filter{
+		From:    q.Get("from"),
+		To:      q.Get("to"),
+		ItemID:  q.Get("item"),
+		Profile: q.Get("profile"),
+	}
+}
+
+func intQuery(w http.ResponseWriter, r *http.Request, key string, def int) (int, bool) {
+	val := r.URL.Query().Get(key)
+	if val == "" {
+		return def, true
+	}
+	n, err := strconv.Atoi(val)
+	if err != nil || n < 0 {
+		http.Error(w, "invalid int for "+key, http.StatusBadRequest)
+		return 0, false
+	}
+	return n, true
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,44 @@
+package history
+
+// Source describes which input started a playback session.
+type Source string
+
+const (
+	SourceUI   Source = "ui"
+	SourceCard Source = "card"
+)
+
+type Session struct {
+	ItemID  string `json:"item_id,omitempty"`
+	AlbumID string `json:"album_id,omitempty"`
+	Profile string `json:"profile,omitempty"`
+	Source  Source `json:"source"`
+
+	StartedAt string `json:"started_at"` // RFC3339
+	EndedAt   string `json:"ended_at"`   // RFC3339
+
+	// seconds actually spent in playing state
+	PlayedSec int `json:"played_sec"`
+}
+
+type Total struct {
+	Key       string `json:"key"`
+	PlayedSec int    `json:"played_sec"`
+	Sessions  int    `json:"sessions"`
+}
+
+type Stats struct {
+	PlayedSec int     `json:"played_sec"`
+	Sessions  int     `json:"sessions"`
+	Days      []Total `json:"days"`     // key: YYYY-MM-DD (local time)
+	Items     []Total `json:"items"`    // key: item id
+	Profiles  []Total `json:"profiles"` // key: profile id
+}
+
+// Filter restricts sessions for Stats and List. Empty fields match everything.
+type Filter struct {
+	From    string // YYYY-MM-DD, inclusive
+	To      string // YYYY-MM-DD, inclusive
+	ItemID  string
+	Profile string
+}
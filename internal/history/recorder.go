@@ -0,0 +1,137 @@
+package history
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"mupibox/internal/player"
+)
+
+// pendingTTL is how long an Attribute call waits for its session to
+// start. A card that did not start playback must not claim a later one.
+const pendingTTL = 5 * time.Second
+
+// Recorder samples the player once per second and turns continuous
+// playing periods into sessions. A session ends when playback pauses or
+// stops, or when the loaded item/album changes.
+type Recorder struct {
+	p     player.Player
+	store *Store
+	now   func() time.Time
+
+	mu        sync.Mutex
+	profile   func() string // optional
+	pending   Source        // source for the next session, consumed on start
+	pendingAt time.Time
+	cur       *Session
+
+	ticker *time.Ticker
+	done   chan struct{}
+}
+
+func NewRecorder(p player.Player, store *Store) *Recorder {
+	r := &Recorder{
+		p:      p,
+		store:  store,
+		now:    time.Now,
+		ticker: time.NewTicker(1 * time.Second),
+		done:   make(chan struct{}),
+	}
+	go r.loop()
+	return r
+}
+
+// Attribute marks the input that is about to start playback. It applies
+// to a session starting within pendingTTL; without a call, sessions are
+// attributed to the UI.
+func (r *Recorder) Attribute(src Source) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.pending = src
+	r.pendingAt = r.now()
+}
+
+// SetProfile sets the func that names the profile new sessions belong to.
+func (r *Recorder) SetProfile(fn func() string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.profile = fn
+}
+
+// Close stops sampling and stores the running session, if any.
+func (r *Recorder) Close() {
+	close(r.done)
+	r.ticker.Stop()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.finishLocked(r.now())
+}
+
+func (r *Recorder) loop() {
+	for {
+		select {
+		case <-r.done:
+			return
+		case now := <-r.ticker.C:
+			r.sample(r.p.Status(), now)
+		}
+	}
+}
+
+func (r *Recorder) sample(st player.PlayerStatus, now time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.pending != "" && now.Sub(r.pendingAt) > pendingTTL {
+		r.pending = ""
+	}
+
+	if st.State != player.StatePlaying {
+		r.finishLocked(now)
+		return
+	}
+
+	if r.cur != nil && (r.cur.ItemID != st.ItemID || r.cur.AlbumID != st.AlbumID) {
+		r.finishLocked(now)
+	}
+
+	if r.cur == nil {
+		src := r.pending
+		if src == "" {
+			src = SourceUI
+		}
+		r.pending = ""
+
+		var profile string
+		if r.profile != nil {
+			profile = r.profile()
+		}
+
+		r.cur = &Session{
+			ItemID:    st.ItemID,
+			AlbumID:   st.AlbumID,
+			Profile:   profile,
+			Source:    src,
+			StartedAt: now.UTC().Format(time.RFC3339),
+		}
+	}
+	r.cur.PlayedSec++
+}
+
+func (r *Recorder) finishLocked(now time.Time) {
+	if r.cur == nil {
+		return
+	}
+	sess := *r.cur
+	r.cur = nil
+
+	if sess.PlayedSec <= 0 {
+		return
+	}
+	sess.EndedAt = now.UTC().Format(time.RFC3339)
+	if err := r.store.Add(sess); err != nil {
+		log.Println("history:", err)
+	}
+}
@@ -0,0 +1,142 @@
+package history
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"mupibox/internal/player"
+)
+
+var start = time.Date(2026, 3, 14, 18, 0, 0, 0, time.UTC)
+
+func newTestStore(t *testing.T) *Store {
+	t.Helper()
+	s, err := NewStore(filepath.Join(t.TempDir(), "history.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// newTestRecorder returns a recorder without ticker; tests feed it
+// samples. clock is what Attribute and Close see as now.
+func newTestRecorder(t *testing.T) (*Recorder, *time.Time) {
+	clock := start
+	r := &Recorder{
+		store:  newTestStore(t),
+		now:    func() time.Time { return clock },
+		ticker: time.NewTicker(time.Hour),
+		done:   make(chan struct{}),
+	}
+	return r, &clock
+}
+
+func playing(item, album string) player.PlayerStatus {
+	return player.PlayerStatus{State: player.StatePlaying, ItemID: item, AlbumID: album}
+}
+
+// run feeds one sample per second from *clock on and advances it.
+func run(r *Recorder, clock *time.Time, st player.PlayerStatus, sec int) {
+	for i := 0; i < sec; i++ {
+		*clock = clock.Add(time.Second)
+		r.sample(st, *clock)
+	}
+}
+
+func sessions(r *Recorder) []Session {
+	list, _ := r.store.List(Filter{}, 0, 0)
+	// oldest first reads easier in tests
+	for i, j := 0, len(list)-1; i < j; i, j = i+1, j-1 {
+		list[i], list[j] = list[j], list[i]
+	}
+	return list
+}
+
+func TestRecorderSessions(t *testing.T) {
+	r, clock := newTestRecorder(t)
+	r.SetProfile(func() string { return "lena" })
+	paused := player.PlayerStatus{State: player.StatePaused, ItemID: "bibi", AlbumID: "bibi_1"}
+
+	run(r, clock, paused, 3)                     // nothing playing yet
+	run(r, clock, playing("bibi", "bibi_1"), 10) // session 1
+	run(r, clock, paused, 5)                     // ends it
+	run(r, clock, playing("bibi", "bibi_1"), 4)  // session 2 ...
+	run(r, clock, playing("bibi", "bibi_2"), 6)  // ... ends with the next album
+	run(r, clock, player.PlayerStatus{}, 1)      // stopped
+	run(r, clock, playing("tkkg", "tkkg_1"), 2)  // running at Close
+	r.Close()
+
+	want := []Session{
+		{ItemID: "bibi", AlbumID: "bibi_1", Profile: "lena", Source: SourceUI, StartedAt: "2026-03-14T18:00:04Z", EndedAt: "2026-03-14T18:00:14Z", PlayedSec: 10},
+		{ItemID: "bibi", AlbumID: "bibi_1", Profile: "lena", Source: SourceUI, StartedAt: "2026-03-14T18:00:19Z", EndedAt: "2026-03-14T18:00:23Z", PlayedSec: 4},
+		{ItemID: "bibi", AlbumID: "bibi_2", Profile: "lena", Source: SourceUI, StartedAt: "2026-03-14T18:00:23Z", EndedAt: "2026-03-14T18:00:29Z", PlayedSec: 6},
+		{ItemID: "tkkg", AlbumID: "tkkg_1", Profile: "lena", Source: SourceUI, StartedAt: "2026-03-14T18:00:30Z", EndedAt: "2026-03-14T18:00:31Z", PlayedSec: 2},
+	}
+	if got := sessions(r); !reflect.DeepEqual(got, want) {
+		t.Fatalf("sessions =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestRecorderSource(t *testing.T) {
+	r, clock := newTestRecorder(t)
+	paused := player.PlayerStatus{State: player.StatePaused}
+
+	// a card starts playback: its session and only that one
+	r.Attribute(SourceCard)
+	run(r, clock, playing("bibi", "bibi_1"), 3)
+	run(r, clock, paused, 1)
+	run(r, clock, playing("bibi", "bibi_1"), 3)
+	run(r, clock, paused, 1)
+
+	// a card that started nothing (e.g. it toggled pause) does not claim
+	// a later start from the UI
+	r.Attribute(SourceCard)
+	run(r, clock, paused, 10)
+	run(r, clock, playing("bibi", "bibi_1"), 3)
+	run(r, clock, paused, 1)
+
+	// the player needs a moment to start: still the card's session
+	r.Attribute(SourceCard)
+	run(r, clock, paused, 2)
+	run(r, clock, playing("tkkg", "tkkg_1"), 3)
+	run(r, clock, paused, 1)
+
+	var got []Source
+	for _, s := range sessions(r) {
+		got = append(got, s.Source)
+	}
+	if want := []Source{SourceCard, SourceUI, SourceUI, SourceCard}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("sources = %v, want %v", got, want)
+	}
+}
+
+func TestStoreRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.json")
+	s, err := NewStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	in := []Session{
+		{ItemID: "bibi", AlbumID: "bibi_1", Source: SourceCard, StartedAt: "2026-03-13T18:00:00Z", EndedAt: "2026-03-13T18:20:00Z", PlayedSec: 1200},
+		{ItemID: "tkkg", Profile: "lena", Source: SourceUI, StartedAt: "2026-03-14T08:00:00Z", EndedAt: "2026-03-14T08:05:00Z", PlayedSec: 300},
+	}
+	for _, sess := range in {
+		if err := s.Add(sess); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	loaded, err := NewStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, total := loaded.List(Filter{}, 0, 0)
+	if want := []Session{in[1], in[0]}; total != 2 || !reflect.DeepEqual(got, want) {
+		t.Fatalf("List = %+v (%d), want %+v", got, total, want)
+	}
+	if st := loaded.Stats(Filter{Profile: "default"}); st.PlayedSec != 1200 || st.Sessions != 1 {
+		t.Fatalf("stats of the default profile = %+v", st)
+	}
+}
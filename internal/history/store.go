@@ -0,0 +1,168 @@
+package history
+
+import (
+	"encoding/json"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"mupibox/internal/atomicfile"
+)
+
+// maxSessions caps the history file; oldest sessions are dropped first.
+const maxSessions = 5000
+
+const dayLayout = "2006-01-02"
+
+type Store struct {
+	path string
+	mu   sync.Mutex
+	data []Session // oldest first
+}
+
+func NewStore(path string) (*Store, error) {
+	s := &Store{
+		path: path,
+		data: []Session{},
+	}
+
+	raw, err := os.ReadFile(path)
+	if err == nil {
+		_ = json.Unmarshal(raw, &s.data)
+	}
+
+	if os.IsNotExist(err) {
+		_ = atomicfile.Write(path, []byte("[]"))
+	}
+
+	return s, nil
+}
+
+func (s *Store) Add(sess Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.data = append(s.data, sess)
+	if len(s.data) > maxSessions {
+		s.data = append([]Session(nil), s.data[len(s.data)-maxSessions:]...)
+	}
+	return s.persist()
+}
+
+// List returns matching sessions newest first, plus the total number of matches.
+func (s *Store) List(f Filter, offset, limit int) ([]Session, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var matched []Session
+	for i := len(s.data) - 1; i >= 0; i-- {
+		if f.match(s.data[i]) {
+			matched = append(matched, s.data[i])
+		}
+	}
+
+	total := len(matched)
+	if offset < 0 {
+		offset = 0
+	}
+	if offset > total {
+		offset = total
+	}
+	matched = matched[offset:]
+	if limit > 0 && len(matched) > limit {
+		matched = matched[:limit]
+	}
+	return matched, total
+}
+
+func (s *Store) Stats(f Filter) Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	days := map[string]*Total{}
+	items := map[string]*Total{}
+	profiles := map[string]*Total{}
+
+	var out Stats
+	for _, sess := range s.data {
+		if !f.match(sess) {
+			continue
+		}
+		out.PlayedSec += sess.PlayedSec
+		out.Sessions++
+
+		addTotal(days, sessionDay(sess), sess.PlayedSec)
+		addTotal(items, sess.ItemID, sess.PlayedSec)
+		addTotal(profiles, profileKey(sess.Profile), sess.PlayedSec)
+	}
+
+	out.Days = sortedTotals(days, func(a, b Total) bool { return a.Key < b.Key })
+	out.Items = sortedTotals(items, byPlayed)
+	out.Profiles = sortedTotals(profiles, byPlayed)
+	return out
+}
+
+func (f Filter) match(sess Session) bool {
+	if f.ItemID != "" && sess.ItemID != f.ItemID {
+		return false
+	}
+	if f.Profile != "" && profileKey(sess.Profile) != f.Profile {
+		return false
+	}
+	day := sessionDay(sess)
+	if f.From != "" && day < f.From {
+		return false
+	}
+	if f.To != "" && day > f.To {
+		return false
+	}
+	return true
+}
+
+// sessionDay is the local calendar day a session started on.
+func sessionDay(sess Session) string {
+	t, err := time.Parse(time.RFC3339, sess.StartedAt)
+	if err != nil {
+		return ""
+	}
+	return t.Local().Format(dayLayout)
+}
+
+func profileKey(p string) string {
+	if p == "" {
+		return "default"
+	}
+	return p
+}
+
+func addTotal(m map[string]*Total, key string, sec int) {
+	t, ok := m[key]
+	if !ok {
+		t = &Total{Key: key}
+		m[key] = t
+	}
+	t.PlayedSec += sec
+	t.Sessions++
+}
+
+func byPlayed(a, b Total) bool {
+	if a.PlayedSec != b.PlayedSec {
+		return a.PlayedSec > b.PlayedSec
+	}
+	return a.Key < b.Key
+}
+
+func sortedTotals(m map[string]*Total, less func(a, b Total) bool) []Total {
+	out := make([]Total, 0, len(m))
+	for _, t := range m {
+		out = append(out, *t)
+	}
+	sort.Slice(out, func(i, j int) bool { return less(out[i], out[j]) })
+	return out
+}
+
+func (s *Store) persist() error {
+	raw, _ := json.MarshalIndent(s.data, "", "  ")
+	return atomicfile.Write(s.path, raw)
+}
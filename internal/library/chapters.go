@@ -0,0 +1,39 @@
+package library
+
+import (
+	"log"
+	"os"
+
+	"mupibox/internal/catalog"
+	"mupibox/internal/chapters"
+	"mupibox/internal/player"
+)
+
+// applyChapters turns a single-file audiobook with chapters into virtual
+// tracks. Items without a local file or without chapters stay as they are.
+func applyChapters(it *catalog.Item, m *player.Media) {
+	if it == nil {
+		return
+	}
+	for _, src := range it.Sources {
+		if src.Type != "local" || src.Path == "" {
+			continue
+		}
+		fi, err := os.Stat(src.Path)
+		if err != nil || !fi.Mode().IsRegular() {
+			continue
+		}
+		chs, err := chapters.Load(src.Path)
+		if err != nil {
+			log.Printf("chapters %s: %v", src.Path, err)
+			continue
+		}
+		if len(chs) == 0 {
+			continue
+		}
+		m.Chapters = chs
+		m.TrackCount = len(chs)
+		m.Mode = player.ModeAudiobookSingle
+		return
+	}
+}
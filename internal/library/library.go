@@ -0,0 +1,222 @@
+package library
+
+import (
+	"errors"
+	"os"
+
+	"mupibox/internal/catalog"
+	"mupibox/internal/player"
+	"mupibox/internal/state"
+)
+
+const placeholderCover = "/covers/placeholder.png"
+
+// demoTrackCount is used until real backends report track lists.
+const demoTrackCount = 12
+
+var ErrNotFound = errors.New("not found")
+
+// Library turns catalog items, albums and resume entries into player.Media.
+type Library struct {
+	Catalog   *catalog.Catalog
+	State     func() *state.Store // resume state of the active profile
+	StaticDir string              // where cover paths are served from
+
+	// DefaultSpeed is used for items without a remembered speed (optional).
+	DefaultSpeed func() float64
+}
+
+func New(cat *catalog.Catalog, st func() *state.Store, staticDir string) *Library {
+	return &Library{Catalog: cat, State: st, StaticDir: staticDir}
+}
+
+func (l *Library) FindItem(id string) *catalog.Item {
+	for _, c := range l.Catalog.Categories {
+		for _, it := range c.Items {
+			if it.ID == id {
+				tmp := it
+				return &tmp
+			}
+		}
+	}
+	return nil
+}
+
+// Cover returns the first existing cover of an item or the placeholder.
+func (l *Library) Cover(it *catalog.Item) string {
+	if it == nil {
+		return placeholderCover
+	}
+	for _, src := range it.Sources {
+		if src.CoverPath != "" {
+			if _, err := os.Stat(l.StaticDir + src.CoverPath); err == nil {
+				return src.CoverPath
+			}
+		}
+	}
+	return placeholderCover
+}
+
+// ItemMedia loads a catalog item. Items with resume continue with their
+// most recent album unless play_behavior says start_at=beginning.
+func (l *Library) ItemMedia(itemID string) (player.Media, error) {
+	it := l.FindItem(itemID)
+	if it == nil {
+		return player.Media{}, ErrNotFound
+	}
+
+	if resumes(it) {
+		for _, e := range l.State().ListRecent(0) {
+			if e.State.ItemID == it.ID {
+				return l.ContinueMedia(e.Key)
+			}
+		}
+	}
+
+	m := player.Media{
+		ItemID:     it.ID,
+		Series:     it.DisplayName,
+		Title:      it.DisplayName,
+		Cover:      l.Cover(it),
+		Mode:       modeFor(it),
+		TrackCount: demoTrackCount,
+		Track:      1,
+		Speed:      l.speed(it.ID),
+	}
+	applyChapters(it, &m)
+	applyBehavior(it, &m)
+	return m, nil
+}
+
+// AlbumMedia loads an album of a catalog item, at its resume position if any.
+func (l *Library) AlbumMedia(itemID, albumID string) (player.Media, error) {
+	it := l.FindItem(itemID)
+	if it == nil || albumID == "" {
+		return player.Media{}, ErrNotFound
+	}
+
+	m := player.Media{
+		ItemID:     it.ID,
+		AlbumID:    albumID,
+		Series:     it.DisplayName,
+		Title:      albumID,
+		Cover:      l.Cover(it),
+		Mode:       modeFor(it),
+		TrackCount: demoTrackCount,
+		Track:      1,
+		Speed:      l.speed(albumID),
+	}
+	applyChapters(it, &m)
+	applyBehavior(it, &m)
+	if resumes(it) {
+		if st, ok := l.State().Get(albumID); ok {
+			m.Track = st.TrackIndex + 1
+			m.Position = st.PositionSec
+			applyShuffle(st, &m)
+		}
+	}
+	return m, nil
+}
+
+// ContinueMedia loads a "Weiter abspielen" entry by its key.
+func (l *Library) ContinueMedia(key string) (player.Media, error) {
+	st, ok := l.State().Get(key)
+	if !ok {
+		return player.Media{}, ErrNotFound
+	}
+
+	it := l.FindItem(st.ItemID)
+	m := player.Media{
+		ItemID:     st.ItemID,
+		AlbumID:    st.AlbumID,
+		Series:     st.ItemID,
+		Title:      key,
+		Cover:      l.Cover(it),
+		Mode:       player.ModeAudiobookChapters,
+		TrackCount: demoTrackCount,
+		Track:      st.TrackIndex + 1,
+		Position:   st.PositionSec,
+		Speed:      l.speed(key),
+	}
+	if m.AlbumID != "" {
+		m.Title = m.AlbumID
+	}
+	if it != nil {
+		m.Series = it.DisplayName
+		m.Mode = modeFor(it)
+	}
+	applyChapters(it, &m)
+	applyBehavior(it, &m)
+	applyShuffle(st, &m)
+	return m, nil
+}
+
+// QueueEntries returns the tracks of an item or album for the play queue.
+func (l *Library) QueueEntries(itemID, albumID string) ([]player.QueueEntry, error) {
+	var m player.Media
+	var err error
+	if albumID != "" {
+		m, err = l.AlbumMedia(itemID, albumID)
+	} else {
+		m, err = l.ItemMedia(itemID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return m.Entries(), nil
+}
+
+// Resumes reports whether an item continues where it stopped.
+func (l *Library) Resumes(itemID string) bool {
+	it := l.FindItem(itemID)
+	return it != nil && resumes(it)
+}
+
+// speed returns the remembered speed of a resume key, else the default.
+func (l *Library) speed(key string) float64 {
+	if st, ok := l.State().Get(key); ok && st.Speed > 0 {
+		return st.Speed
+	}
+	if l.DefaultSpeed != nil {
+		return l.DefaultSpeed()
+	}
+	return 0
+}
+
+// applyShuffle continues a resume entry in its saved shuffle order.
+func applyShuffle(st state.ResumeState, m *player.Media) {
+	if st.ShuffleSeed == 0 {
+		return
+	}
+	m.Shuffle = st.Shuffle
+	m.ShuffleSeed = st.ShuffleSeed
+}
+
+// applyBehavior sets shuffle and repeat from the item's play_behavior.
+func applyBehavior(it *catalog.Item, m *player.Media) {
+	if it == nil || it.PlayBehavior == nil {
+		return
+	}
+	m.Shuffle = it.PlayBehavior.Shuffle
+	if it.PlayBehavior.Repeat {
+		m.Repeat = player.RepeatAll
+	}
+}
+
+func resumes(it *catalog.Item) bool {
+	if it.PlayBehavior != nil && it.PlayBehavior.StartAt == "beginning" {
+		return false
+	}
+	return it.Resume
+}
+
+func modeFor(it *catalog.Item) player.Mode {
+	switch {
+	case it.Type == "podcast":
+		return player.ModeAudiobookSingle
+	case it.Resume:
+		return player.ModeAudiobookChapters
+	default:
+		return player.ModeMusic
+	}
+}
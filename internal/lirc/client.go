@@ -0,0 +1,94 @@
+package lirc
+
+import (
+	"log"
+	"net"
+	"sync"
+	"time"
+)
+
+// Client listens on the lircd socket (usually /var/run/lirc/lircd) and
+// reconnects when lircd restarts.
+type Client struct {
+	Path  string
+	Retry time.Duration
+
+	// OnKey is called for every received key event.
+	OnKey func(Key)
+
+	mu   sync.Mutex
+	conn net.Conn
+	done chan struct{}
+}
+
+func NewClient(path string, onKey func(Key)) *Client {
+	return &Client{Path: path, Retry: 5 * time.Second, OnKey: onKey}
+}
+
+func (c *Client) Start() {
+	c.done = make(chan struct{})
+	go c.loop()
+}
+
+func (c *Client) Close() {
+	close(c.done)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.conn != nil {
+		c.conn.Close()
+	}
+}
+
+func (c *Client) loop() {
+	logged := false
+	for {
+		conn, err := net.Dial("unix", c.Path)
+		if err != nil {
+			// lircd not (yet) running: log once, keep trying
+			if !logged {
+				log.Println("lirc:", err)
+				logged = true
+			}
+		} else {
+			logged = false
+			log.Println("lirc: connected to", c.Path)
+			c.serve(conn)
+		}
+
+		select {
+		case <-c.done:
+			return
+		case <-time.After(c.Retry):
+		}
+	}
+}
+
+func (c *Client) serve(conn net.Conn) {
+	c.mu.Lock()
+	c.conn = conn
+	select {
+	case <-c.done:
+		// Close ran while we were dialing
+		conn.Close()
+	default:
+	}
+	c.mu.Unlock()
+
+	err := Scan(conn, func(k Key) {
+		if c.OnKey != nil {
+			c.OnKey(k)
+		}
+	})
+	conn.Close()
+
+	c.mu.Lock()
+	c.conn = nil
+	c.mu.Unlock()
+
+	select {
+	case <-c.done:
+	default:
+		log.Printf("lirc: %s: %v", c.Path, err)
+	}
+}
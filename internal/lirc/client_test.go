@@ -0,0 +1,142 @@
+package lirc
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// fakeLircd listens on a unix socket and sends each client one script,
+// then hangs up like a restarting lircd.
+func fakeLircd(t *testing.T, scripts ...string) string {
+	t.Helper()
+	dir, err := os.MkdirTemp("", "lirc") // t.TempDir may exceed the socket path limit
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	path := filepath.Join(dir, "lircd")
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	go func() {
+		for _, script := range scripts {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			conn.Write([]byte(script))
+			conn.Close()
+		}
+	}()
+	return path
+}
+
+func TestClient(t *testing.T) {
+	path := fakeLircd(t,
+		"000000037ff07bef 00 KEY_VOLUMEUP Samsung_BN59\n"+
+			"BEGIN\nLIST\nSUCCESS\nDATA\n1\nSamsung_BN59\nEND\n"+
+			"000000037ff07bef 01 KEY_VOLUMEUP Samsung_BN59\n",
+		"garbage\n0000000000000010 00 KEY_PLAY Hama\n",
+	)
+
+	keys := make(chan Key, 10)
+	c := NewClient(path, func(k Key) { keys <- k })
+	c.Retry = 10 * time.Millisecond
+	c.Start()
+	defer c.Close()
+
+	want := []Key{
+		{Code: "000000037ff07bef", Repeat: 0, Name: "KEY_VOLUMEUP", Remote: "Samsung_BN59"},
+		{Code: "000000037ff07bef", Repeat: 1, Name: "KEY_VOLUMEUP", Remote: "Samsung_BN59"},
+		{Code: "0000000000000010", Repeat: 0, Name: "KEY_PLAY", Remote: "Hama"}, // after reconnecting
+	}
+	var got []Key
+	for len(got) < len(want) {
+		select {
+		case k := <-keys:
+			got = append(got, k)
+		case <-time.After(2 * time.Second):
+			t.Fatalf("keys = %+v, want %+v", got, want)
+		}
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("keys = %+v, want %+v", got, want)
+	}
+}
+
+func TestClientCloseWhileConnected(t *testing.T) {
+	dir, err := os.MkdirTemp("", "lirc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	l, err := net.Listen("unix", filepath.Join(dir, "lircd"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	c := NewClient(filepath.Join(dir, "lircd"), nil)
+	c.Start()
+	conn, err := l.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	// Close hangs up the idle connection
+	c.Close()
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, err = conn.Read(make([]byte, 1))
+	if ne, ok := err.(net.Error); err == nil || ok && ne.Timeout() {
+		t.Fatal("connection still open after Close")
+	}
+}
+
+func TestKeymap(t *testing.T) {
+	m := Keymap{
+		Keys: map[string]Binding{
+			"KEY_PLAY":        {Command: "toggle"},
+			"KEY_VOLUMEUP":    {Command: "volume_up", Repeat: true},
+			"KEY_FASTFORWARD": {Command: "skip_forward", Repeat: true},
+		},
+		Remote:      "Hama",
+		RepeatDelay: 2,
+	}
+	tests := []struct {
+		key  Key
+		want string
+	}{
+		{Key{Name: "KEY_PLAY", Remote: "Hama"}, "toggle"},
+		{Key{Name: "KEY_PLAY", Remote: "Hama", Repeat: 3}, ""}, // no repeat binding
+		{Key{Name: "KEY_PLAY", Remote: "Samsung"}, ""},
+		{Key{Name: "KEY_VOLUMEUP", Remote: "Hama", Repeat: 2}, ""}, // within the delay
+		{Key{Name: "KEY_VOLUMEUP", Remote: "Hama", Repeat: 3}, "volume_up"},
+		{Key{Name: "KEY_MENU", Remote: "Hama"}, ""},
+	}
+	for _, tt := range tests {
+		if got := m.Command(tt.key); got != tt.want {
+			t.Errorf("Command(%+v) = %q, want %q", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestParseLine(t *testing.T) {
+	k, err := ParseLine("000000037ff07bef 0a KEY_VOLUMEUP Samsung_BN59")
+	if err != nil || k.Repeat != 10 || k.Name != "KEY_VOLUMEUP" {
+		t.Fatalf("ParseLine = %+v, %v", k, err)
+	}
+	for _, line := range []string{"", "SIGHUP", "0000 zz KEY_PLAY Hama", "0000 00 KEY_PLAY"} {
+		if _, err := ParseLine(line); err != ErrInvalidLine {
+			t.Errorf("ParseLine(%q) err = %v", line, err)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package lirc
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidLine = errors.New("lirc: invalid line")
+
+// Key is one decoded key press as broadcast by lircd.
+type Key struct {
+	Code   string // raw scan code (hex)
+	Repeat int    // 0 = new press, >0 = key held
+	Name   string // z.B. KEY_PLAY
+	Remote string // remote name from lircd.conf
+}
+
+// ParseLine parses "<code> <repeat> <key> <remote>", e.g.
+// "000000037ff07bef 00 KEY_VOLUMEUP Samsung_BN59".
+func ParseLine(line string) (Key, error) {
+	f := strings.Fields(line)
+	if len(f) != 4 {
+		return Key{}, ErrInvalidLine
+	}
+	repeat, err := strconv.ParseInt(f[1], 16, 32)
+	if err != nil {
+		return Key{}, ErrInvalidLine
+	}
+	return Key{Code: f[0], Repeat: int(repeat), Name: f[2], Remote: f[3]}, nil
+}
+
+// Scan reads key events from a lircd connection until r fails. Command
+// replies (BEGIN ... END blocks) are skipped.
+func Scan(r io.Reader, fn func(Key)) error {
+	sc := bufio.NewScanner(r)
+	inReply := false
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		switch {
+		case line == "BEGIN":
+			inReply = true
+			continue
+		case line == "END":
+			inReply = false
+			continue
+		case inReply || line == "":
+			continue
+		}
+
+		k, err := ParseLine(line)
+		if err != nil {
+			continue
+		}
+		fn(k)
+	}
+	if err := sc.Err(); err != nil {
+		return err
+	}
+	return io.EOF
+}
+
+// Binding maps a key to a command (see player.Command).
+type Binding struct {
+	Command string `json:"command"`
+	Repeat  bool   `json:"repeat,omitempty"` // holding the key repeats the command
+}
+
+// Keymap maps lircd key names to bindings.
+type Keymap struct {
+	Keys        map[string]Binding
+	Remote      string // only keys of this remote, empty = any
+	RepeatDelay int    // repeats to skip before a held key repeats
+}
+
+// Command returns the command for k, or "" if it should be ignored.
+func (m Keymap) Command(k Key) string {
+	if m.Remote != "" && k.Remote != m.Remote {
+		return ""
+	}
+	b, ok := m.Keys[k.Name]
+	if !ok {
+		return ""
+	}
+	if k.Repeat > 0 && (!b.Repeat || k.Repeat <= m.RepeatDelay) {
+		return ""
+	}
+	return b.Command
+}
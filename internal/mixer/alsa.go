@@ -0,0 +1,170 @@
+package mixer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var ErrNoLevel = errors.New("mixer: no level in amixer output")
+
+// DefaultControl is the playback control of the Raspberry Pi's onboard
+// audio; DACs usually name theirs Digital or Master.
+const DefaultControl = "PCM"
+
+var (
+	reDB = regexp.MustCompile(`\[(-?[0-9.]+)dB\]`)
+	reOn = regexp.MustCompile(`\[(on|off)\]`)
+)
+
+// ALSA drives a simple mixer control with amixer. With a StateFile the
+// mixer state is stored with alsactl after changes and restored at boot.
+type ALSA struct {
+	Card      string  // e.g. 0 or "sndrpihifiberry"
+	Control   string  // e.g. PCM, Master, Digital
+	MinDB     float64 // level 1 maps to just above this, e.g. -50
+	StateFile string  // alsactl state file, empty = don't store/restore
+	Run       Runner
+
+	// StoreDelay collects quick changes (volume buttons) into one store.
+	StoreDelay time.Duration
+
+	mu    sync.Mutex
+	store *time.Timer
+}
+
+func NewALSA(card, control string, minDB float64, stateFile string) *ALSA {
+	if control == "" {
+		control = DefaultControl
+	}
+	if minDB >= 0 {
+		minDB = -50
+	}
+	return &ALSA{
+		Card:       card,
+		Control:    control,
+		MinDB:      minDB,
+		StateFile:  stateFile,
+		Run:        ExecRunner,
+		StoreDelay: 3 * time.Second,
+	}
+}
+
+func (a *ALSA) Volume() (int, bool, error) {
+	out, err := a.amixer("sget", a.Control)
+	if err != nil {
+		return 0, false, err
+	}
+	return parseSget(out, a.MinDB)
+}
+
+func (a *ALSA) SetVolume(level int) error {
+	var err error
+	if level <= 0 {
+		// below MinDB there is no sensible dB value: use the raw minimum
+		_, err = a.amixer("-q", "sset", a.Control, "0%")
+	} else {
+		db := LevelToDB(level, a.MinDB)
+		_, err = a.amixer("-q", "sset", a.Control, "--", strconv.FormatFloat(db, 'f', 2, 64)+"dB")
+	}
+	if err == nil {
+		a.scheduleStore()
+	}
+	return err
+}
+
+func (a *ALSA) SetMute(muted bool) error {
+	sw := "unmute"
+	if muted {
+		sw = "mute"
+	}
+	_, err := a.amixer("-q", "sset", a.Control, sw)
+	if err == nil {
+		a.scheduleStore()
+	}
+	return err
+}
+
+// Restore loads the stored mixer state, e.g. at boot. A missing state
+// file is not an error. A configured startup volume is set afterwards and
+// replaces the restored level; mute and other controls stay restored.
+func (a *ALSA) Restore() error {
+	if a.StateFile == "" {
+		return nil
+	}
+	if _, err := os.Stat(a.StateFile); os.IsNotExist(err) {
+		return nil
+	}
+	_, err := a.run("alsactl", a.alsactlArgs("restore")...)
+	return err
+}
+
+// Store writes the mixer state now.
+func (a *ALSA) Store() error {
+	if a.StateFile == "" {
+		return nil
+	}
+	_, err := a.run("alsactl", a.alsactlArgs("store")...)
+	return err
+}
+
+func (a *ALSA) scheduleStore() {
+	if a.StateFile == "" {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.store != nil {
+		a.store.Stop()
+	}
+	a.store = time.AfterFunc(a.StoreDelay, func() {
+		_ = a.Store()
+	})
+}
+
+func (a *ALSA) alsactlArgs(cmd string) []string {
+	args := []string{"-f", a.StateFile, cmd}
+	if a.Card != "" {
+		args = append(args, a.Card)
+	}
+	return args
+}
+
+func (a *ALSA) amixer(args ...string) ([]byte, error) {
+	if a.Card != "" {
+		args = append([]string{"-c", a.Card}, args...)
+	}
+	return a.run("amixer", args...)
+}
+
+func (a *ALSA) run(name string, args ...string) ([]byte, error) {
+	out, err := a.Run(name, args...)
+	if err != nil {
+		return out, fmt.Errorf("mixer: %s: %w", name, err)
+	}
+	return out, nil
+}
+
+// parseSget reads the first channel of "amixer sget", e.g.
+//
+//	Mono: Playback -1234 [78%] [-12.34dB] [on]
+func parseSget(out []byte, minDB float64) (int, bool, error) {
+	m := reDB.FindSubmatch(out)
+	if m == nil {
+		return 0, false, ErrNoLevel
+	}
+	db, err := strconv.ParseFloat(string(m[1]), 64)
+	if err != nil {
+		return 0, false, ErrNoLevel
+	}
+	muted := false
+	if on := reOn.FindSubmatch(out); on != nil {
+		muted = string(on[1]) == "off"
+	}
+	return DBToLevel(db, minDB), muted, nil
+}
@@ -0,0 +1,44 @@
+package mixer
+
+import (
+	"math"
+	"os/exec"
+)
+
+// Mixer controls the system volume, independent of the player backend.
+type Mixer interface {
+	Volume() (level int, muted bool, err error)
+	SetVolume(level int) error // 0..100, perceptual
+	SetMute(muted bool) error
+}
+
+// Runner executes a command and returns its output. It is injectable so
+// the issued commands can be checked without sound hardware.
+type Runner func(name string, args ...string) ([]byte, error)
+
+func ExecRunner(name string, args ...string) ([]byte, error) {
+	return exec.Command(name, args...).Output()
+}
+
+// LevelToDB maps a 0..100 level onto a logarithmic (perceptual) curve
+// between minDB and 0 dB. Level 0 is silence and returns -Inf.
+func LevelToDB(level int, minDB float64) float64 {
+	if level <= 0 {
+		return math.Inf(-1)
+	}
+	if level >= 100 {
+		return 0
+	}
+	return minDB * (1 - float64(level)/100)
+}
+
+// DBToLevel is the inverse of LevelToDB.
+func DBToLevel(db, minDB float64) int {
+	if minDB >= 0 || db <= minDB {
+		return 0
+	}
+	if db >= 0 {
+		return 100
+	}
+	return int(math.Round(100 * (1 - db/minDB)))
+}
@@ -0,0 +1,186 @@
+package mixer
+
+import (
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"mupibox/internal/player"
+)
+
+// fakeRunner records commands and answers amixer sget with out.
+type fakeRunner struct {
+	mu   sync.Mutex
+	cmds []string
+	out  string
+	err  error
+}
+
+func (f *fakeRunner) run(name string, args ...string) ([]byte, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.cmds = append(f.cmds, strings.Join(append([]string{name}, args...), " "))
+	if f.err != nil {
+		return nil, f.err
+	}
+	return []byte(f.out), nil
+}
+
+func (f *fakeRunner) commands() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.cmds...)
+}
+
+func newTestALSA(card, stateFile string) (*ALSA, *fakeRunner) {
+	f := &fakeRunner{}
+	a := NewALSA(card, "", -50, stateFile)
+	a.Run = f.run
+	a.StoreDelay = time.Hour
+	return a, f
+}
+
+func TestALSACommands(t *testing.T) {
+	tests := []struct {
+		name string
+		card string
+		do   func(a *ALSA) error
+		want string
+	}{
+		{"volume", "0", func(a *ALSA) error { return a.SetVolume(50) }, "amixer -c 0 -q sset PCM -- -25.00dB"},
+		{"full volume", "", func(a *ALSA) error { return a.SetVolume(100) }, "amixer -q sset PCM -- 0.00dB"},
+		{"silence", "0", func(a *ALSA) error { return a.SetVolume(0) }, "amixer -c 0 -q sset PCM 0%"},
+		{"mute", "1", func(a *ALSA) error { return a.SetMute(true) }, "amixer -c 1 -q sset PCM mute"},
+		{"unmute", "1", func(a *ALSA) error { return a.SetMute(false) }, "amixer -c 1 -q sset PCM unmute"},
+		{"get", "0", func(a *ALSA) error { _, _, err := a.Volume(); return err }, "amixer -c 0 sget PCM"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, f := newTestALSA(tt.card, "")
+			f.out = "  Mono: Playback 100 [50%] [-25.00dB] [on]\n"
+			if err := tt.do(a); err != nil {
+				t.Fatal(err)
+			}
+			if got := f.commands(); !reflect.DeepEqual(got, []string{tt.want}) {
+				t.Fatalf("commands = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestALSAVolume(t *testing.T) {
+	tests := []struct {
+		out       string
+		level     int
+		muted     bool
+		wantError error
+	}{
+		{"Simple mixer control 'PCM',0\n  Mono: Playback -1250 [80%] [-12.50dB] [on]\n", 75, false, nil},
+		{"  Front Left: Playback 0 [100%] [0.00dB] [off]\n  Front Right: Playback 0 [100%] [0.00dB] [off]\n", 100, true, nil},
+		{"  Mono: Playback -9999 [0%] [-99.99dB] [on]\n", 0, false, nil},
+		{"  Mono: Playback 31 [100%]\n", 0, false, ErrNoLevel},
+	}
+	for _, tt := range tests {
+		a, f := newTestALSA("0", "")
+		f.out = tt.out
+		level, muted, err := a.Volume()
+		if err != tt.wantError || level != tt.level || muted != tt.muted {
+			t.Errorf("Volume(%q) = %d, %v, %v; want %d, %v, %v", tt.out, level, muted, err, tt.level, tt.muted, tt.wantError)
+		}
+	}
+}
+
+func TestALSAStoreAndRestore(t *testing.T) {
+	stateFile := filepath.Join(t.TempDir(), "asound.state")
+
+	a, f := newTestALSA("0", stateFile)
+	if err := a.Restore(); err != nil {
+		t.Fatal(err)
+	}
+	if got := f.commands(); len(got) != 0 {
+		t.Fatalf("restore without a state file ran %q", got)
+	}
+
+	// quick changes are stored once
+	a.StoreDelay = 20 * time.Millisecond
+	for _, level := range []int{40, 45, 50} {
+		if err := a.SetVolume(level); err != nil {
+			t.Fatal(err)
+		}
+	}
+	time.Sleep(100 * time.Millisecond)
+	var stores []string
+	for _, c := range f.commands() {
+		if strings.HasPrefix(c, "alsactl") {
+			stores = append(stores, c)
+		}
+	}
+	if want := []string{"alsactl -f " + stateFile + " store 0"}; !reflect.DeepEqual(stores, want) {
+		t.Fatalf("stores = %q, want %q", stores, want)
+	}
+
+	if err := os.WriteFile(stateFile, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	b, f := newTestALSA("0", stateFile)
+	if err := b.Restore(); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"alsactl -f " + stateFile + " restore 0"}; !reflect.DeepEqual(f.commands(), want) {
+		t.Fatalf("restore = %q, want %q", f.commands(), want)
+	}
+}
+
+func TestALSAError(t *testing.T) {
+	a, f := newTestALSA("0", "")
+	f.err = errors.New("exit status 1")
+	if err := a.SetVolume(30); err == nil || !strings.Contains(err.Error(), "amixer") {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+func TestLevelDB(t *testing.T) {
+	if db := LevelToDB(0, -50); !math.IsInf(db, -1) {
+		t.Errorf("level 0 = %v dB, want -Inf", db)
+	}
+	for level := 0; level <= 100; level++ {
+		if got := DBToLevel(LevelToDB(level, -50), -50); got != level {
+			t.Errorf("level %d -> %d", level, got)
+		}
+	}
+}
+
+func TestPlayer(t *testing.T) {
+	a, f := newTestALSA("0", "")
+	f.out = "  Mono: Playback -1250 [80%] [-12.50dB] [off]\n"
+	mp := player.NewMemoryPlayer()
+	mp.Close()
+
+	p := NewPlayer(mp, a)
+	if st := p.Status(); st.Volume != 75 || !st.Muted {
+		t.Fatalf("status = %d muted %v, want the mixer level 75, muted", st.Volume, st.Muted)
+	}
+
+	f.cmds = nil
+	p.SetVolume(120)
+	want := []string{"amixer -c 0 -q sset PCM -- 0.00dB", "amixer -c 0 -q sset PCM unmute"}
+	if got := f.commands(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("commands = %q, want %q", got, want)
+	}
+	if st := p.Status(); st.Volume != 100 || st.Muted {
+		t.Fatalf("status = %d muted %v", st.Volume, st.Muted)
+	}
+
+	// a failing mixer keeps the old level
+	f.err = errors.New("exit status 1")
+	p.SetVolume(10)
+	if st := p.Status(); st.Volume != 100 {
+		t.Fatalf("volume = %d after a failed change", st.Volume)
+	}
+}
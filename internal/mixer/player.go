@@ -0,0 +1,92 @@
+package mixer
+
+import (
+	"log"
+	"sync"
+
+	"mupibox/internal/player"
+)
+
+// Player wraps a player backend so volume and mute go to a Mixer
+// instead of the backend's software volume.
+type Player struct {
+	player.Player
+	Mixer Mixer
+
+	mu     sync.Mutex
+	volume int
+	muted  bool
+}
+
+// NewPlayer takes over the current mixer level, so a restored level
+// shows up in the player status.
+func NewPlayer(p player.Player, m Mixer) *Player {
+	mp := &Player{Player: p, Mixer: m}
+
+	level, muted, err := m.Volume()
+	if err != nil {
+		log.Println("mixer:", err)
+		st := p.Status()
+		level, muted = st.Volume, st.Muted
+	}
+	mp.volume, mp.muted = level, muted
+	return mp
+}
+
+func (p *Player) Status() player.PlayerStatus {
+	st := p.Player.Status()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	st.Volume = p.volume
+	st.Muted = p.muted
+	return st
+}
+
+func (p *Player) SetVolume(level int) {
+	if level < 0 {
+		level = 0
+	}
+	if level > 100 {
+		level = 100
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err := p.Mixer.SetVolume(level); err != nil {
+		log.Println(err)
+		return
+	}
+	p.volume = level
+	// like the player backends: setting a volume unmutes
+	if level > 0 && p.muted {
+		p.setMuteLocked(false)
+	}
+}
+
+func (p *Player) Mute() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.setMuteLocked(true)
+}
+
+func (p *Player) Unmute() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.setMuteLocked(false)
+}
+
+func (p *Player) ToggleMute() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.setMuteLocked(!p.muted)
+}
+
+func (p *Player) setMuteLocked(muted bool) {
+	if err := p.Mixer.SetMute(muted); err != nil {
+		log.Println(err)
+		return
+	}
+	p.muted = muted
+}
@@ -0,0 +1,103 @@
+package player
+
+import "fmt"
+
+// Command names shared by cards, buttons and remotes.
+const (
+	CmdPlay        = "play"
+	CmdPause       = "pause"
+	CmdToggle      = "toggle"
+	CmdNext        = "next"
+	CmdPrev        = "prev"
+	CmdSkipForward = "skip_forward"
+	CmdSkipBack    = "skip_back"
+	CmdVolumeUp    = "volume_up"
+	CmdVolumeDown  = "volume_down"
+	CmdMute        = "mute"
+	CmdUnmute      = "unmute"
+	CmdToggleMute  = "toggle_mute"
+	CmdSpeedUp     = "speed_up"
+	CmdSpeedDown   = "speed_down"
+	CmdSpeedReset  = "speed_reset"
+	CmdShuffle     = "toggle_shuffle"
+	CmdRepeat      = "cycle_repeat" // off -> all -> one -> off
+)
+
+// Commands lists all command names, e.g. to validate card actions.
+var Commands = []string{
+	CmdPlay, CmdPause, CmdToggle, CmdNext, CmdPrev,
+	CmdSkipForward, CmdSkipBack,
+	CmdVolumeUp, CmdVolumeDown, CmdMute, CmdUnmute, CmdToggleMute,
+	CmdSpeedUp, CmdSpeedDown, CmdSpeedReset,
+	CmdShuffle, CmdRepeat,
+}
+
+// IsCommand reports whether name is a known command.
+func IsCommand(name string) bool {
+	for _, c := range Commands {
+		if c == name {
+			return true
+		}
+	}
+	return false
+}
+
+const (
+	skipStep   = 10 // seconds
+	volumeStep = 5
+	speedStep  = 0.25
+)
+
+// Command runs a named command on p.
+func Command(p Player, name string) error {
+	switch name {
+	case CmdPlay:
+		p.Play()
+	case CmdPause:
+		p.Pause()
+	case CmdToggle:
+		p.Toggle()
+	case CmdNext:
+		p.Next()
+	case CmdPrev:
+		p.Prev()
+	case CmdSkipForward:
+		p.Skip(skipStep)
+	case CmdSkipBack:
+		p.Skip(-skipStep)
+	case CmdVolumeUp:
+		p.SetVolume(p.Status().Volume + volumeStep)
+	case CmdVolumeDown:
+		p.SetVolume(p.Status().Volume - volumeStep)
+	case CmdMute:
+		p.Mute()
+	case CmdUnmute:
+		p.Unmute()
+	case CmdToggleMute:
+		p.ToggleMute()
+	case CmdSpeedUp:
+		p.SetSpeed(p.Status().Speed + speedStep)
+	case CmdSpeedDown:
+		p.SetSpeed(p.Status().Speed - speedStep)
+	case CmdSpeedReset:
+		p.SetSpeed(1)
+	case CmdShuffle:
+		p.SetShuffle(!p.Status().Shuffle)
+	case CmdRepeat:
+		p.SetRepeat(nextRepeat(p.Status().Repeat))
+	default:
+		return fmt.Errorf("unknown player command %q", name)
+	}
+	return nil
+}
+
+func nextRepeat(m RepeatMode) RepeatMode {
+	switch m {
+	case RepeatAll:
+		return RepeatOne
+	case RepeatOne:
+		return RepeatOff
+	default:
+		return RepeatAll
+	}
+}
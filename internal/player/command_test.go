@@ -0,0 +1,23 @@
+package player
+
+import "testing"
+
+func TestCommands(t *testing.T) {
+	p := newTestPlayer(t, Media{ItemID: "bibi", TrackCount: 3})
+	for _, name := range Commands {
+		if !IsCommand(name) {
+			t.Errorf("IsCommand(%q) = false", name)
+		}
+		if err := Command(p, name); err != nil {
+			t.Errorf("Command(%q): %v", name, err)
+		}
+	}
+	for _, name := range []string{"", "Pause", "speed", "shuffle"} {
+		if IsCommand(name) {
+			t.Errorf("IsCommand(%q) = true", name)
+		}
+		if err := Command(p, name); err == nil {
+			t.Errorf("Command(%q): no error", name)
+		}
+	}
+}
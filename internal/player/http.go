@@ -4,10 +4,22 @@ import (
 	"encoding/json"
 	"net/http"
 	"strconv"
+	"strings"
 )
 
 type API struct {
 	P Player
+
+	// Sleep enables /api/player/sleep (optional).
+	Sleep *SleepTimer
+
+	// OnSpeed is called after the speed was changed via the API, e.g. to
+	// remember it for the item (optional).
+	OnSpeed func(st PlayerStatus)
+
+	// Entries resolves a catalog item or album for enqueueing (optional,
+	// without it POST /api/player/queue is not available).
+	Entries func(itemID, albumID string) ([]QueueEntry, error)
 }
 
 func NewAPI(p Player) *API {
@@ -31,6 +43,17 @@ func (a *API) Register(mux *http.ServeMux) {
 	// Time control
 	mux.HandleFunc("/api/player/seek", a.postOnly(a.handleSeek))
 	mux.HandleFunc("/api/player/skip", a.postOnly(a.handleSkip))
+	mux.HandleFunc("/api/player/speed", a.postOnly(a.handleSpeed))
+
+	// Play behavior
+	mux.HandleFunc("/api/player/shuffle", a.postOnly(a.handleShuffle))
+	mux.HandleFunc("/api/player/repeat", a.postOnly(a.handleRepeat))
+
+	// Queue: list (GET), enqueue (POST), clear (DELETE)
+	mux.HandleFunc("/api/player/queue", a.handleQueue)
+	mux.HandleFunc("/api/player/queue/move", a.postOnly(a.handleQueueMove))
+	// DELETE /api/player/queue/{nr}
+	mux.HandleFunc("/api/player/queue/", a.handleQueueEntry)
 
 	// Volume
 	mux.HandleFunc("/api/player/volume", a.postOnly(a.handleVolume))
@@ -38,7 +61,11 @@ func (a *API) Register(mux *http.ServeMux) {
 	mux.HandleFunc("/api/player/unmute", a.postOnly(a.handleUnmute))
 	mux.HandleFunc("/api/player/mute/toggle", a.postOnly(a.handleToggleMute))
 
-	mux.HandleFunc("/api/collections", a.handleCollections)
+	// Sleep timer: start (POST ?minutes=N or ?mode=track|album), state (GET), cancel (DELETE)
+	if a.Sleep != nil {
+		mux.HandleFunc("/api/player/sleep", a.handleSleep)
+		mux.HandleFunc("/api/player/sleep/extend", a.postOnly(a.handleSleepExtend))
+	}
 }
 
 func (a *API) handleStatus(w http.ResponseWriter, r *http.Request) {
@@ -97,6 +124,130 @@ func (a *API) handleSkip(w http.ResponseWriter, r *http.Request) {
 	writeOK(w)
 }
 
+func (a *API) handleSpeed(w http.ResponseWriter, r *http.Request) {
+	val := r.URL.Query().Get("value")
+	if val == "" {
+		http.Error(w, "missing query param: value", http.StatusBadRequest)
+		return
+	}
+	speed, err := strconv.ParseFloat(val, 64)
+	if err != nil || speed <= 0 {
+		http.Error(w, "invalid speed", http.StatusBadRequest)
+		return
+	}
+	a.P.SetSpeed(speed)
+
+	st := a.P.Status()
+	if a.OnSpeed != nil {
+		a.OnSpeed(st)
+	}
+	writeJSON(w, map[string]any{"speed": st.Speed})
+}
+
+// handleShuffle sets shuffle with ?on=true|false, without it toggles.
+func (a *API) handleShuffle(w http.ResponseWriter, r *http.Request) {
+	on := !a.P.Status().Shuffle
+	if val := r.URL.Query().Get("on"); val != "" {
+		b, err := strconv.ParseBool(val)
+		if err != nil {
+			http.Error(w, "invalid bool for on", http.StatusBadRequest)
+			return
+		}
+		on = b
+	}
+	a.P.SetShuffle(on)
+	writeJSON(w, map[string]any{"shuffle": a.P.Status().Shuffle})
+}
+
+// handleRepeat sets ?mode=off|all|one, without it cycles the modes.
+func (a *API) handleRepeat(w http.ResponseWriter, r *http.Request) {
+	mode := nextRepeat(a.P.Status().Repeat)
+	if val := r.URL.Query().Get("mode"); val != "" {
+		mode = RepeatMode(val)
+		if !mode.Valid() {
+			http.Error(w, "invalid repeat mode", http.StatusBadRequest)
+			return
+		}
+	}
+	a.P.SetRepeat(mode)
+	writeJSON(w, map[string]any{"repeat": a.P.Status().Repeat})
+}
+
+type enqueueRequest struct {
+	ItemID  string `json:"item_id"`
+	AlbumID string `json:"album_id,omitempty"`
+	Next    bool   `json:"next,omitempty"` // after the current track, else at the end
+}
+
+func (a *API) handleQueue(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		a.writeQueue(w)
+	case http.MethodPost:
+		if a.Entries == nil {
+			http.Error(w, "not supported", http.StatusNotImplemented)
+			return
+		}
+		var req enqueueRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.ItemID == "" {
+			http.Error(w, "invalid json", http.StatusBadRequest)
+			return
+		}
+		entries, err := a.Entries(req.ItemID, req.AlbumID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		a.P.Enqueue(entries, req.Next)
+		a.writeQueue(w)
+	case http.MethodDelete:
+		a.P.ClearQueue()
+		a.writeQueue(w)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (a *API) handleQueueMove(w http.ResponseWriter, r *http.Request) {
+	from, ok := mustIntQuery(w, r, "from")
+	if !ok {
+		return
+	}
+	to, ok := mustIntQuery(w, r, "to")
+	if !ok {
+		return
+	}
+	if err := a.P.MoveQueue(from, to); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	a.writeQueue(w)
+}
+
+func (a *API) handleQueueEntry(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	nr, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/api/player/queue/"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	switch err := a.P.RemoveQueue(nr); err {
+	case nil:
+		a.writeQueue(w)
+	case ErrQueueCurrent:
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	}
+}
+
+func (a *API) writeQueue(w http.ResponseWriter) {
+	writeJSON(w, map[string]any{"track": a.P.Status().Track, "queue": a.P.Queue()})
+}
+
 func (a *API) handleVolume(w http.ResponseWriter, r *http.Request) {
 	level, ok := mustIntQuery(w, r, "level")
 	if !ok {
@@ -119,6 +270,61 @@ func (a *API) handleToggleMute(w http.ResponseWriter, r *http.Request) {
 	writeOK(w)
 }
 
+func (a *API) handleSleep(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		a.writeSleep(w)
+	case http.MethodPost:
+		var err error
+		if mode := SleepMode(r.URL.Query().Get("mode")); mode != "" && mode != SleepTimed {
+			err = a.Sleep.StartMode(mode)
+		} else {
+			minutes, ok := mustIntQuery(w, r, "minutes")
+			if !ok {
+				return
+			}
+			err = a.Sleep.Start(minutes)
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		a.writeSleep(w)
+	case http.MethodDelete:
+		if err := a.Sleep.Cancel(); err != nil {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		writeOK(w)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (a *API) handleSleepExtend(w http.ResponseWriter, r *http.Request) {
+	minutes, ok := mustIntQuery(w, r, "minutes")
+	if !ok {
+		return
+	}
+	switch err := a.Sleep.Extend(minutes); err {
+	case nil:
+		a.writeSleep(w)
+	case ErrSleepInactive:
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	}
+}
+
+func (a *API) writeSleep(w http.ResponseWriter) {
+	info, ok := a.Sleep.Info()
+	if !ok {
+		writeJSON(w, map[string]any{"active": false})
+		return
+	}
+	writeJSON(w, map[string]any{"active": true, "sleep": info})
+}
+
 func (a *API) postOnly(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
@@ -152,55 +358,3 @@ func writeJSON(w http.ResponseWriter, v any) {
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(v)
 }
-
-func (a *API) handleCollections(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
-	writeJSON(w, []map[string]any{
-		{
-			"id":    "favorites",
-			"title": "Favoriten",
-			"items": []map[string]any{
-				{
-					"key":      "local:album:benjamin_bluemchen_12",
-					"cover":    "/covers/placeholder.png",
-					"series":   "Benjamin Blümchen",
-					"title":    "Folge 12 – Der Zoo brennt",
-					"progress": 0.42,
-					"duration": 3000,
-					"badges":   []string{"resume"},
-					"playable": true,
-				},
-				{
-					"key":      "local:album:benjamin_bluemchen_18",
-					"cover":    "/covers/placeholder.png",
-					"series":   "Benjamin Blümchen",
-					"title":    "Folge 18 – als Pilot",
-					"progress": nil,
-					"duration": nil,
-					"badges":   []string{"album"},
-					"playable": true,
-				},
-			},
-		},
-		{
-			"id":    "goodnight",
-			"title": "Gute Nacht",
-			"items": []map[string]any{
-				{
-					"key":      "spotify:playlist:goodnight",
-					"cover":    "/covers/placeholder.png",
-					"series":   "Spotify",
-					"title":    "Schlaflieder",
-					"progress": nil,
-					"duration": nil,
-					"badges":   []string{"shuffle"},
-					"playable": true,
-				},
-			},
-		},
-	})
-}
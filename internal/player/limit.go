@@ -0,0 +1,27 @@
+package player
+
+// Limited wraps a Player and caps SetVolume at the level returned by Max.
+// A Max of 0 (or below) means no limit.
+type Limited struct {
+	Player
+	Max func() int
+}
+
+func NewLimited(p Player, max func() int) *Limited {
+	return &Limited{Player: p, Max: max}
+}
+
+func (l *Limited) SetVolume(level int) {
+	if max := l.Max(); max > 0 && level > max {
+		level = max
+	}
+	l.Player.SetVolume(level)
+}
+
+// Enforce lowers the current volume if it is above the limit, e.g. after
+// the limit itself went down.
+func (l *Limited) Enforce() {
+	if max := l.Max(); max > 0 && l.Player.Status().Volume > max {
+		l.Player.SetVolume(max)
+	}
+}
@@ -1,16 +1,27 @@
 package player
 
 import (
+	"math"
 	"sync"
 	"time"
 )
 
+// speed range of the memory player
+const (
+	MinSpeed = 0.5
+	MaxSpeed = 2.0
+)
+
 type MemoryPlayer struct {
 	mu sync.Mutex
 	st PlayerStatus
 
-	// trackDurations is optional; if empty we use st.Duration for all tracks.
-	trackDurations []int
+	// played fraction of a second at speeds != 1
+	frac float64
+
+	// play queue in play order (shuffled if st.Shuffle); st.Track is the
+	// 1-based position of the current entry
+	queue []QueueEntry
 
 	ticker *time.Ticker
 	done   chan struct{}
@@ -33,21 +44,35 @@ func NewMemoryPlayer() *MemoryPlayer {
 
 			Cover: "/covers/placeholder.png",
 
+			ItemID:  "benjamin_bluemchen",
+			AlbumID: "benjamin_bluemchen_12",
+
 			Volume: 40,
 			Muted:  false,
 
+			Speed:  1,
+			Repeat: RepeatOff,
+
 			CanSeek:      true,
 			CanSkipTrack: true,
 			CanSkipTime:  true,
 		},
-		trackDurations: make([]int, 20),
-		done:           make(chan struct{}),
+		done: make(chan struct{}),
 	}
 
-	// Demo: vary durations a bit
-	for i := 0; i < len(p.trackDurations); i++ {
-		p.trackDurations[i] = 300 + (i * 10) // 5:00, 5:10, ...
-	}
+	// Demo: 20 tracks, durations 5:00, 5:10, ...
+	p.queue = Media{
+		ItemID:     p.st.ItemID,
+		AlbumID:    p.st.AlbumID,
+		Series:     p.st.Series,
+		Title:      p.st.Title,
+		Cover:      p.st.Cover,
+		Mode:       p.st.Mode,
+		TrackCount: 20,
+	}.Entries()
+	p.st.ShuffleSeed = newShuffleSeed()
+	p.renumberLocked()
+	p.syncLocked()
 
 	p.ticker = time.NewTicker(1 * time.Second)
 	go p.loop()
@@ -66,15 +91,32 @@ func (p *MemoryPlayer) loop() {
 		case <-p.done:
 			return
 		case <-p.ticker.C:
-			p.mu.Lock()
-			if p.st.State == StatePlaying {
-				p.st.Position++
-				if p.st.Position >= p.currentDurationLocked() {
-					// auto-next
-					p.nextLocked()
-				}
-			}
-			p.mu.Unlock()
+			p.tick()
+		}
+	}
+}
+
+// tick advances playback by one second of wall-clock time.
+func (p *MemoryPlayer) tick() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.st.State != StatePlaying {
+		return
+	}
+	p.frac += p.st.Speed
+	step := int(p.frac)
+	p.frac -= float64(step)
+
+	p.st.Position += step
+	if p.st.Position >= p.currentDurationLocked() {
+		if p.st.Repeat == RepeatOne {
+			p.st.Position = 0
+		} else {
+			// auto-next; the next entry may be other content that plays
+			// at its own speed from the first tick on
+			p.nextLocked()
+			p.syncLocked()
 		}
 	}
 }
@@ -83,11 +125,56 @@ func (p *MemoryPlayer) Status() PlayerStatus {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
-	// ensure Duration matches current track
-	p.st.Duration = p.currentDurationLocked()
+	// ensure metadata and Duration match the current track
+	p.syncLocked()
 	return p.st
 }
 
+func (p *MemoryPlayer) Load(m Media) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.queue = m.Entries()
+	p.renumberLocked()
+	if m.Track < 1 || m.Track > len(p.queue) {
+		m.Track = 1
+	}
+	if m.Position < 0 {
+		m.Position = 0
+	}
+	if !m.Repeat.Valid() {
+		m.Repeat = RepeatOff
+	}
+
+	p.st.State = StatePaused
+	p.st.Track = m.Track // the album track, same as the queue position until shuffled
+
+	p.st.Shuffle = m.Shuffle
+	p.st.Repeat = m.Repeat
+	p.st.ShuffleSeed = m.ShuffleSeed
+	if p.st.ShuffleSeed == 0 {
+		p.st.ShuffleSeed = newShuffleSeed()
+	}
+	if m.Shuffle {
+		p.shuffleLocked()
+		if m.Track == 1 && m.Position == 0 {
+			// fresh start: begin with the first track of the order
+			p.st.Track = 1
+		} else {
+			p.st.Track = p.positionOfLocked(m.Track)
+		}
+	}
+	p.syncLocked()
+
+	p.st.Position = m.Position
+	if p.st.Position > p.st.Duration {
+		p.st.Position = 0
+	}
+
+	p.st.Speed = clampSpeed(m.Speed)
+	p.frac = 0
+}
+
 func (p *MemoryPlayer) Play() {
 	p.mu.Lock()
 	defer p.mu.Unlock()
@@ -140,6 +227,9 @@ func (p *MemoryPlayer) Prev() {
 	p.st.Track--
 	if p.st.Track < 1 {
 		p.st.Track = 1
+		if p.st.Repeat == RepeatAll {
+			p.st.Track = p.st.TrackCount
+		}
 	}
 	p.st.Position = 0
 	p.st.Duration = p.currentDurationLocked()
@@ -201,6 +291,52 @@ func (p *MemoryPlayer) Skip(seconds int) {
 	p.st.Duration = d
 }
 
+// SetSpeed changes the speed of the current album, including its entries
+// later in the queue.
+func (p *MemoryPlayer) SetSpeed(speed float64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.st.Speed = clampSpeed(speed)
+	for i, e := range p.queue {
+		if e.ItemID == p.st.ItemID && e.AlbumID == p.st.AlbumID {
+			p.queue[i].speed = p.st.Speed
+		}
+	}
+}
+
+// SetShuffle reorders the queue and keeps the current track; playback
+// continues at its new place. Turning shuffle on picks a new order.
+func (p *MemoryPlayer) SetShuffle(on bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if on == p.st.Shuffle || len(p.queue) == 0 {
+		p.st.Shuffle = on
+		return
+	}
+	cur := p.queue[p.st.Track-1].seq
+	p.st.Shuffle = on
+	if on {
+		p.st.ShuffleSeed = newShuffleSeed()
+		p.shuffleLocked()
+	} else {
+		p.unshuffleLocked()
+	}
+	for i, e := range p.queue {
+		if e.seq == cur {
+			p.st.Track = i + 1
+		}
+	}
+}
+
+func (p *MemoryPlayer) SetRepeat(mode RepeatMode) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if mode.Valid() {
+		p.st.Repeat = mode
+	}
+}
+
 func (p *MemoryPlayer) SetVolume(level int) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
@@ -244,11 +380,14 @@ func (p *MemoryPlayer) nextLocked() {
 	}
 	p.st.Track++
 	if p.st.Track > p.st.TrackCount {
-		// stop at end
-		p.st.Track = p.st.TrackCount
-		p.st.State = StatePaused
-		p.st.Position = p.currentDurationLocked()
-		return
+		if p.st.Repeat != RepeatAll {
+			// stop at end
+			p.st.Track = p.st.TrackCount
+			p.st.State = StatePaused
+			p.st.Position = p.currentDurationLocked()
+			return
+		}
+		p.st.Track = 1
 	}
 	p.st.Position = 0
 	p.st.Duration = p.currentDurationLocked()
@@ -265,11 +404,77 @@ func (p *MemoryPlayer) currentDurationLocked() int {
 	if idx < 0 {
 		idx = 0
 	}
-	if idx >= len(p.trackDurations) || p.trackDurations[idx] <= 0 {
+	if idx >= len(p.queue) || p.queue[idx].Duration <= 0 {
 		if p.st.Duration <= 0 {
 			return 0
 		}
 		return p.st.Duration
 	}
-	return p.trackDurations[idx]
+	return p.queue[idx].Duration
+}
+
+// syncLocked copies the metadata of the current queue entry into st.
+func (p *MemoryPlayer) syncLocked() {
+	p.st.TrackCount = len(p.queue)
+	if p.st.Track > p.st.TrackCount {
+		p.st.Track = p.st.TrackCount
+	}
+	if p.st.Track < 1 || len(p.queue) == 0 {
+		p.st.TracksLeft = 0
+		p.st.AlbumTracksLeft = 0
+		return
+	}
+	e := p.queue[p.st.Track-1]
+	if e.ItemID != p.st.ItemID || e.AlbumID != p.st.AlbumID {
+		// entered other content: it plays at its own speed
+		p.st.Speed = clampSpeed(e.speed)
+		p.frac = 0
+	}
+	p.st.ItemID = e.ItemID
+	p.st.AlbumID = e.AlbumID
+	p.st.Series = e.Series
+	p.st.Title = e.Album
+	p.st.Cover = e.Cover
+	p.st.Mode = e.Mode
+	p.st.AlbumTrack = e.Track
+	p.st.AlbumTrackCount = e.albumTracks
+	p.st.Chapter = ""
+	if e.Chapter {
+		p.st.Chapter = e.Title
+	}
+	p.st.Duration = p.currentDurationLocked()
+	p.st.TracksLeft = p.st.TrackCount - p.st.Track
+
+	p.st.AlbumTracksLeft = 0
+	for _, next := range p.queue[p.st.Track:] {
+		if next.ItemID != e.ItemID || next.AlbumID != e.AlbumID {
+			break
+		}
+		p.st.AlbumTracksLeft++
+	}
+}
+
+// positionOfLocked returns the queue position of album track nr.
+func (p *MemoryPlayer) positionOfLocked(nr int) int {
+	for i, e := range p.queue {
+		if e.Track == nr {
+			return i + 1
+		}
+	}
+	return 1
+}
+
+// clampSpeed limits speed to the supported range, rounded to 0.05 steps;
+// 0 means normal speed.
+func clampSpeed(speed float64) float64 {
+	if speed == 0 {
+		return 1
+	}
+	if speed < MinSpeed {
+		speed = MinSpeed
+	}
+	if speed > MaxSpeed {
+		speed = MaxSpeed
+	}
+	return math.Round(speed*20) / 20
 }
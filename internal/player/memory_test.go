@@ -0,0 +1,346 @@
+package player
+
+import (
+	"reflect"
+	"testing"
+)
+
+// newTestPlayer returns a memory player without its ticker.
+func newTestPlayer(t *testing.T, m Media) *MemoryPlayer {
+	t.Helper()
+	p := NewMemoryPlayer()
+	p.Close()
+	p.Load(m)
+	return p
+}
+
+func order(p Player) []int {
+	var out []int
+	for _, e := range p.Queue() {
+		out = append(out, e.Track)
+	}
+	return out
+}
+
+func TestShuffleSeed(t *testing.T) {
+	m := Media{ItemID: "bibi", AlbumID: "bibi_1", TrackCount: 20, Shuffle: true}
+
+	a := newTestPlayer(t, m)
+	b := newTestPlayer(t, m)
+	if reflect.DeepEqual(order(a), order(b)) {
+		t.Fatal("two fresh starts played in the same order")
+	}
+	if a.Status().AlbumTrack != order(a)[0] {
+		t.Fatal("fresh start does not begin with the first track of the order")
+	}
+
+	// resume with the saved seed: same order, same track
+	a.SetTrack(7)
+	a.Seek(42)
+	st := a.Status()
+	resumed := newTestPlayer(t, Media{
+		ItemID:      "bibi",
+		AlbumID:     "bibi_1",
+		TrackCount:  20,
+		Track:       st.AlbumTrack,
+		Position:    st.Position,
+		Shuffle:     true,
+		ShuffleSeed: st.ShuffleSeed,
+	})
+	if !reflect.DeepEqual(order(resumed), order(a)) {
+		t.Fatalf("resumed order %v, want %v", order(resumed), order(a))
+	}
+	if got := resumed.Status(); got.Track != 7 || got.AlbumTrack != st.AlbumTrack || got.Position != 42 {
+		t.Fatalf("resumed at %d (album track %d) @%d, want 7 (%d) @42", got.Track, got.AlbumTrack, got.Position, st.AlbumTrack)
+	}
+
+	// turning shuffle on again picks a new order
+	seed := a.Status().ShuffleSeed
+	a.SetShuffle(false)
+	a.SetShuffle(true)
+	if a.Status().ShuffleSeed == seed {
+		t.Fatal("SetShuffle(true) kept the old seed")
+	}
+}
+
+func TestEnqueuedEntriesKeepTheirSpeed(t *testing.T) {
+	p := newTestPlayer(t, Media{ItemID: "bibi", AlbumID: "bibi_1", TrackCount: 2, Speed: 1.5})
+	p.Enqueue(Media{ItemID: "tkkg", AlbumID: "tkkg_1", TrackCount: 3}.Entries(), false)
+
+	st := p.Status()
+	if st.Speed != 1.5 || st.AlbumTrackCount != 2 || st.TrackCount != 5 {
+		t.Fatalf("speed %.2f, album tracks %d, queue %d; want 1.50, 2, 5", st.Speed, st.AlbumTrackCount, st.TrackCount)
+	}
+
+	p.SetTrack(3)
+	if st := p.Status(); st.AlbumID != "tkkg_1" || st.Speed != 1 || st.AlbumTrackCount != 3 {
+		t.Fatalf("in tkkg_1: speed %.2f, album tracks %d; want 1.00, 3", st.Speed, st.AlbumTrackCount)
+	}
+
+	// a speed set while in an album sticks to it
+	p.SetSpeed(0.75)
+	p.SetTrack(1)
+	if st := p.Status(); st.Speed != 1.5 {
+		t.Fatalf("back in bibi_1: speed %.2f, want 1.50", st.Speed)
+	}
+	p.SetTrack(5)
+	if st := p.Status(); st.Speed != 0.75 {
+		t.Fatalf("back in tkkg_1: speed %.2f, want 0.75", st.Speed)
+	}
+}
+
+func TestNavigation(t *testing.T) {
+	tests := []struct {
+		name      string
+		repeat    RepeatMode
+		start     int
+		pos       int
+		do        func(p *MemoryPlayer)
+		wantTrack int
+		wantPos   int
+		wantState PlaybackState
+	}{
+		{"next", RepeatOff, 2, 50, (*MemoryPlayer).Next, 3, 0, StatePlaying},
+		{"next at the end stops", RepeatOff, 4, 50, (*MemoryPlayer).Next, 4, 330, StatePaused},
+		{"next at the end with repeat all", RepeatAll, 4, 50, (*MemoryPlayer).Next, 1, 0, StatePlaying},
+		{"prev restarts the track", RepeatOff, 3, 10, (*MemoryPlayer).Prev, 3, 0, StatePlaying},
+		{"prev", RepeatOff, 3, 2, (*MemoryPlayer).Prev, 2, 0, StatePlaying},
+		{"prev at the start", RepeatOff, 1, 0, (*MemoryPlayer).Prev, 1, 0, StatePlaying},
+		{"prev at the start with repeat all", RepeatAll, 1, 0, (*MemoryPlayer).Prev, 4, 0, StatePlaying},
+		{"set track", RepeatOff, 1, 50, func(p *MemoryPlayer) { p.SetTrack(3) }, 3, 0, StatePlaying},
+		{"set track below range", RepeatOff, 3, 50, func(p *MemoryPlayer) { p.SetTrack(0) }, 1, 0, StatePlaying},
+		{"set track above range", RepeatOff, 1, 50, func(p *MemoryPlayer) { p.SetTrack(9) }, 4, 0, StatePlaying},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newTestPlayer(t, Media{ItemID: "bibi", TrackCount: 4, Track: tt.start, Position: tt.pos, Repeat: tt.repeat})
+			p.Play()
+			tt.do(p)
+
+			st := p.Status()
+			if st.Track != tt.wantTrack || st.Position != tt.wantPos || st.State != tt.wantState {
+				t.Fatalf("track %d @%d %s, want %d @%d %s", st.Track, st.Position, st.State, tt.wantTrack, tt.wantPos, tt.wantState)
+			}
+			if want := 300 + (tt.wantTrack-1)*10; st.Duration != want {
+				t.Fatalf("duration %d, want %d", st.Duration, want)
+			}
+		})
+	}
+}
+
+func TestTickAdvancesBySpeed(t *testing.T) {
+	tests := []struct {
+		speed float64
+		ticks int
+		want  int
+	}{
+		{1, 10, 10},
+		{1.5, 1, 1},
+		{1.5, 2, 3},
+		{1.5, 10, 15},
+		{0.5, 3, 1},
+		{0.5, 10, 5},
+		{2, 10, 20},
+	}
+	for _, tt := range tests {
+		p := newTestPlayer(t, Media{ItemID: "bibi", TrackCount: 2, Speed: tt.speed})
+		p.Play()
+		for i := 0; i < tt.ticks; i++ {
+			p.tick()
+		}
+		if got := p.Status().Position; got != tt.want {
+			t.Errorf("speed %.1f, %d ticks: position %d, want %d", tt.speed, tt.ticks, got, tt.want)
+		}
+	}
+
+	// paused players don't move
+	p := newTestPlayer(t, Media{ItemID: "bibi", TrackCount: 2})
+	p.tick()
+	if got := p.Status().Position; got != 0 {
+		t.Errorf("paused: position %d, want 0", got)
+	}
+}
+
+func TestTickAtTrackEnd(t *testing.T) {
+	tests := []struct {
+		name      string
+		repeat    RepeatMode
+		track     int
+		wantTrack int
+		wantState PlaybackState
+	}{
+		{"auto next", RepeatOff, 1, 2, StatePlaying},
+		{"repeat one", RepeatOne, 1, 1, StatePlaying},
+		{"end of queue", RepeatOff, 2, 2, StatePaused},
+		{"repeat all", RepeatAll, 2, 1, StatePlaying},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newTestPlayer(t, Media{ItemID: "bibi", TrackCount: 2, Track: tt.track, Repeat: tt.repeat})
+			p.Play()
+			seekToEnd(p, 1)
+			p.tick()
+
+			st := p.Status()
+			if st.Track != tt.wantTrack || st.State != tt.wantState {
+				t.Fatalf("track %d %s, want %d %s", st.Track, st.State, tt.wantTrack, tt.wantState)
+			}
+			if st.State == StatePlaying && st.Position != 0 {
+				t.Fatalf("position %d, want 0", st.Position)
+			}
+		})
+	}
+}
+
+func TestTickIntoOtherContent(t *testing.T) {
+	p := newTestPlayer(t, Media{ItemID: "bibi", AlbumID: "bibi_1", TrackCount: 1, Speed: 2})
+	p.Enqueue(Media{ItemID: "tkkg", AlbumID: "tkkg_1", TrackCount: 1, Speed: 0.5}.Entries(), false)
+	p.Play()
+	seekToEnd(p, 1)
+
+	// no Status call in between: the ticker alone must pick up the speed
+	p.tick()
+	p.tick()
+	p.tick()
+	p.tick()
+	if st := p.Status(); st.AlbumID != "tkkg_1" || st.Speed != 0.5 || st.Position != 1 {
+		t.Fatalf("status = %s speed %.1f @%d, want tkkg_1 speed 0.5 @1", st.AlbumID, st.Speed, st.Position)
+	}
+}
+
+func TestSetShuffleKeepsCurrent(t *testing.T) {
+	p := newTestPlayer(t, Media{ItemID: "bibi", TrackCount: 10, Track: 4})
+	p.Seek(33)
+
+	p.SetShuffle(true)
+	st := p.Status()
+	if st.AlbumTrack != 4 || st.Position != 33 || !st.Shuffle {
+		t.Fatalf("after shuffle: album track %d @%d, want 4 @33", st.AlbumTrack, st.Position)
+	}
+	if got := order(p)[st.Track-1]; got != 4 {
+		t.Fatalf("queue position %d holds track %d", st.Track, got)
+	}
+	if st.TracksLeft != 10-st.Track {
+		t.Fatalf("tracks left %d at position %d", st.TracksLeft, st.Track)
+	}
+
+	p.SetShuffle(false)
+	st = p.Status()
+	if want := []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}; !reflect.DeepEqual(order(p), want) {
+		t.Fatalf("unshuffled order %v", order(p))
+	}
+	if st.Track != 4 || st.AlbumTrack != 4 {
+		t.Fatalf("after unshuffle: position %d, album track %d; want 4", st.Track, st.AlbumTrack)
+	}
+}
+
+func TestQueueOperations(t *testing.T) {
+	tkkg := Media{ItemID: "tkkg", AlbumID: "tkkg_1", TrackCount: 2}.Entries()
+	albums := func(p Player) []string {
+		var out []string
+		for _, e := range p.Queue() {
+			out = append(out, e.AlbumID+"/"+string(rune('0'+e.Track)))
+		}
+		return out
+	}
+
+	p := newTestPlayer(t, Media{ItemID: "bibi", AlbumID: "bibi_1", TrackCount: 3, Track: 2})
+
+	p.Enqueue(tkkg, true)
+	if want := []string{"bibi_1/1", "bibi_1/2", "tkkg_1/1", "tkkg_1/2", "bibi_1/3"}; !reflect.DeepEqual(albums(p), want) {
+		t.Fatalf("enqueue next: %v", albums(p))
+	}
+	p.Enqueue(tkkg[:1], false)
+	if got := albums(p); got[len(got)-1] != "tkkg_1/1" || p.Status().TrackCount != 6 {
+		t.Fatalf("enqueue at the end: %v", got)
+	}
+
+	// moving entries around the current one keeps it current
+	if err := p.MoveQueue(1, 4); err != nil {
+		t.Fatal(err)
+	}
+	if st := p.Status(); st.Track != 1 || st.AlbumID != "bibi_1" || st.AlbumTrack != 2 {
+		t.Fatalf("after move: position %d %s/%d", st.Track, st.AlbumID, st.AlbumTrack)
+	}
+	if err := p.MoveQueue(1, 6); err != nil {
+		t.Fatal(err)
+	}
+	if st := p.Status(); st.Track != 6 || st.AlbumTrack != 2 {
+		t.Fatalf("moved current: position %d, album track %d", st.Track, st.AlbumTrack)
+	}
+
+	for _, tt := range [][2]int{{0, 1}, {1, 7}, {7, 1}} {
+		if err := p.MoveQueue(tt[0], tt[1]); err != ErrQueuePosition {
+			t.Errorf("MoveQueue(%d, %d) = %v, want ErrQueuePosition", tt[0], tt[1], err)
+		}
+	}
+	if err := p.RemoveQueue(6); err != ErrQueueCurrent {
+		t.Fatalf("remove current: %v", err)
+	}
+	if err := p.RemoveQueue(9); err != ErrQueuePosition {
+		t.Fatalf("remove out of range: %v", err)
+	}
+	if err := p.RemoveQueue(1); err != nil {
+		t.Fatal(err)
+	}
+	if st := p.Status(); st.Track != 5 || st.TrackCount != 5 || st.AlbumTrack != 2 {
+		t.Fatalf("after remove: position %d of %d, album track %d", st.Track, st.TrackCount, st.AlbumTrack)
+	}
+
+	p.ClearQueue()
+	if st := p.Status(); st.Track != 1 || st.TrackCount != 1 || st.AlbumID != "bibi_1" || st.AlbumTrack != 2 {
+		t.Fatalf("after clear: %+v", albums(p))
+	}
+}
+
+func TestEnqueueWhileShuffled(t *testing.T) {
+	p := newTestPlayer(t, Media{ItemID: "bibi", AlbumID: "bibi_1", TrackCount: 4, Track: 2, Position: 5, Shuffle: true})
+	p.Enqueue(Media{ItemID: "tkkg", AlbumID: "tkkg_1", TrackCount: 2}.Entries(), true)
+
+	st := p.Status()
+	next := p.Queue()[st.Track]
+	if next.AlbumID != "tkkg_1" || next.Track != 1 {
+		t.Fatalf("next entry %s/%d, want tkkg_1/1", next.AlbumID, next.Track)
+	}
+
+	// unshuffled, the enqueued album follows the current track
+	p.SetShuffle(false)
+	var got []string
+	for _, e := range p.Queue() {
+		got = append(got, e.AlbumID)
+	}
+	want := []string{"bibi_1", "bibi_1", "tkkg_1", "tkkg_1", "bibi_1", "bibi_1"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unshuffled queue %v, want %v", got, want)
+	}
+}
+
+func TestMoveWhileShuffled(t *testing.T) {
+	// seed 42 plays 6 tracks as 1 6 4 5 3 2
+	tests := []struct {
+		from, to  int
+		shuffled  []int
+		unshuffle []int // the moved track follows the same neighbour
+	}{
+		{5, 2, []int{1, 3, 6, 4, 5, 2}, []int{1, 3, 2, 4, 5, 6}},
+		{2, 6, []int{1, 4, 5, 3, 2, 6}, []int{1, 2, 6, 3, 4, 5}},
+		{4, 1, []int{5, 1, 6, 4, 3, 2}, []int{5, 1, 2, 3, 4, 6}},
+		{3, 4, []int{1, 6, 5, 4, 3, 2}, []int{1, 2, 3, 5, 4, 6}},
+	}
+	for _, tt := range tests {
+		p := newTestPlayer(t, Media{ItemID: "bibi", AlbumID: "bibi_1", TrackCount: 6, Shuffle: true, ShuffleSeed: 42})
+		if err := p.MoveQueue(tt.from, tt.to); err != nil {
+			t.Fatal(err)
+		}
+		if got := order(p); !reflect.DeepEqual(got, tt.shuffled) {
+			t.Fatalf("move %d to %d: order %v, want %v", tt.from, tt.to, got, tt.shuffled)
+		}
+		p.SetShuffle(false)
+		if got := order(p); !reflect.DeepEqual(got, tt.unshuffle) {
+			t.Errorf("move %d to %d: unshuffled %v, want %v", tt.from, tt.to, got, tt.unshuffle)
+		}
+		if st := p.Status(); st.AlbumTrack != 1 {
+			t.Errorf("move %d to %d: playing track %d after unshuffle, want 1", tt.from, tt.to, st.AlbumTrack)
+		}
+	}
+}
@@ -3,17 +3,31 @@ package player
 type Player interface {
 	Status() PlayerStatus
 
+	Load(m Media) // replace current content, stays paused
+
 	Play()
 	Pause()
 	Toggle()
 
 	Next()
 	Prev()
-	SetTrack(nr int) // 1-based
+	SetTrack(nr int) // 1-based position in the queue
 
 	Seek(pos int)      // seconds within current track
 	Skip(seconds int)  // +/- seconds
 
+	SetSpeed(speed float64) // 1.0 = normal, clamped to what the backend supports
+
+	SetShuffle(on bool)
+	SetRepeat(mode RepeatMode)
+
+	// play queue; Status().Track is the position in it
+	Queue() []QueueEntry
+	Enqueue(entries []QueueEntry, next bool) // next: after the current track
+	MoveQueue(from, to int) error            // 1-based positions
+	RemoveQueue(nr int) error
+	ClearQueue() // everything but the current track
+
 	SetVolume(level int) // 0..100
 	Mute()
 	Unmute()
@@ -0,0 +1,282 @@
+package player
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"time"
+)
+
+var (
+	ErrQueuePosition = errors.New("invalid queue position")
+	ErrQueueCurrent  = errors.New("cannot remove the current track")
+)
+
+// QueueEntry is one track of the play queue.
+type QueueEntry struct {
+	ItemID  string `json:"item_id,omitempty"`
+	AlbumID string `json:"album_id,omitempty"`
+
+	Series string `json:"series"`
+	Album  string `json:"album"` // title of the media the track belongs to
+	Title  string `json:"title"` // track or chapter title
+	Cover  string `json:"cover"`
+	Mode   Mode   `json:"mode"`
+
+	Track    int `json:"track"`    // 1-based within its album
+	Duration int `json:"duration"` // seconds, 0 = unknown
+
+	// chapter of a single-file audiobook (Title is the chapter title)
+	Chapter bool `json:"chapter,omitempty"`
+
+	albumTracks int     // tracks of the album, for its progress
+	speed       float64 // of the media, applied when the entry starts its album
+	seq         int     // position in unshuffled order
+}
+
+// Entries expands m into one queue entry per track (or chapter).
+func (m Media) Entries() []QueueEntry {
+	n := m.TrackCount
+	if len(m.Chapters) > 0 {
+		n = len(m.Chapters)
+	}
+	if n < 1 {
+		n = 1
+	}
+	mode := m.Mode
+	if mode == "" {
+		mode = ModeMusic
+	}
+
+	out := make([]QueueEntry, n)
+	for i := range out {
+		e := QueueEntry{
+			ItemID:  m.ItemID,
+			AlbumID: m.AlbumID,
+			Series:  m.Series,
+			Album:   m.Title,
+			Title:   fmt.Sprintf("Titel %d", i+1),
+			Cover:   m.Cover,
+			Mode:    mode,
+			Track:   i + 1,
+			// Demo durations like in NewMemoryPlayer
+			Duration: 300 + (i * 10),
+
+			albumTracks: n,
+			speed:       m.Speed,
+		}
+		if i < len(m.Chapters) {
+			c := m.Chapters[i]
+			e.Title = c.Title
+			e.Chapter = true
+			// a chapter without a known end is not given a demo length
+			e.Duration = int(c.Duration().Seconds())
+		}
+		out[i] = e
+	}
+	return out
+}
+
+// Queue returns the entries in play order; Status().Track indexes into it.
+func (p *MemoryPlayer) Queue() []QueueEntry {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]QueueEntry(nil), p.queue...)
+}
+
+// Enqueue adds entries after the current track (next) or at the end.
+func (p *MemoryPlayer) Enqueue(entries []QueueEntry, next bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(entries) == 0 {
+		return
+	}
+	at := len(p.queue)
+	if next && len(p.queue) > 0 {
+		at = p.st.Track
+	}
+	add := append([]QueueEntry(nil), entries...)
+
+	if p.st.Shuffle && len(p.queue) > 0 {
+		// keep the unshuffled order sensible for SetShuffle(false)
+		natural := p.naturalLocked()
+		nat := len(natural)
+		if next {
+			nat = p.queue[p.st.Track-1].seq + 1
+		}
+		for i := range add {
+			add[i].seq = nat + i
+		}
+		for i := range p.queue {
+			if p.queue[i].seq >= nat {
+				p.queue[i].seq += len(add)
+			}
+		}
+	}
+
+	q := make([]QueueEntry, 0, len(p.queue)+len(add))
+	q = append(q, p.queue[:at]...)
+	q = append(q, add...)
+	q = append(q, p.queue[at:]...)
+	p.queue = q
+
+	if !p.st.Shuffle {
+		p.renumberLocked()
+	}
+	p.syncLocked()
+}
+
+// MoveQueue moves the entry at from to position to (both 1-based). The
+// current track stays current.
+func (p *MemoryPlayer) MoveQueue(from, to int) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	n := len(p.queue)
+	if from < 1 || from > n || to < 1 || to > n {
+		return ErrQueuePosition
+	}
+	cur := p.st.Track - 1
+	e := p.queue[from-1]
+	p.queue = append(p.queue[:from-1], p.queue[from:]...)
+	p.queue = append(p.queue[:to-1], append([]QueueEntry{e}, p.queue[to-1:]...)...)
+
+	switch {
+	case cur == from-1:
+		cur = to - 1
+	case from-1 < cur && to-1 >= cur:
+		cur--
+	case from-1 > cur && to-1 <= cur:
+		cur++
+	}
+	p.st.Track = cur + 1
+
+	if p.st.Shuffle {
+		// the move outlives SetShuffle(false): the entry follows its
+		// new neighbour in the unshuffled order as well
+		p.reseqLocked(to - 1)
+	} else {
+		p.renumberLocked()
+	}
+	return nil
+}
+
+// reseqLocked moves the entry at queue index i in the unshuffled order
+// right after the entry before it (or before the one after it, at the
+// front).
+func (p *MemoryPlayer) reseqLocked(i int) {
+	if len(p.queue) < 2 {
+		return
+	}
+	old := p.queue[i].seq
+	var seq int
+	if i > 0 {
+		seq = p.queue[i-1].seq
+		if seq < old {
+			seq++
+		}
+	} else {
+		seq = p.queue[1].seq
+		if seq > old {
+			seq--
+		}
+	}
+	for j := range p.queue {
+		switch s := p.queue[j].seq; {
+		case seq < old && s >= seq && s < old:
+			p.queue[j].seq++
+		case seq > old && s > old && s <= seq:
+			p.queue[j].seq--
+		}
+	}
+	p.queue[i].seq = seq
+}
+
+// RemoveQueue removes the entry at nr (1-based). The current track can't
+// be removed, skip it with Next instead.
+func (p *MemoryPlayer) RemoveQueue(nr int) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if nr < 1 || nr > len(p.queue) {
+		return ErrQueuePosition
+	}
+	if nr == p.st.Track {
+		return ErrQueueCurrent
+	}
+	p.queue = append(p.queue[:nr-1], p.queue[nr:]...)
+	if nr < p.st.Track {
+		p.st.Track--
+	}
+	p.renumberNaturalLocked()
+	p.syncLocked()
+	return nil
+}
+
+// ClearQueue removes everything but the current track.
+func (p *MemoryPlayer) ClearQueue() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.queue) == 0 {
+		return
+	}
+	cur := p.queue[p.st.Track-1]
+	cur.seq = 0
+	p.queue = []QueueEntry{cur}
+	p.st.Track = 1
+	p.syncLocked()
+}
+
+// shuffleLocked puts the queue into shuffle order. The order only depends
+// on the seed and the queue length, so resume with the saved seed
+// continues in it after a restart.
+func (p *MemoryPlayer) shuffleLocked() {
+	natural := p.naturalLocked()
+	perm := rand.New(rand.NewSource(p.st.ShuffleSeed)).Perm(len(natural))
+	for i, j := range perm {
+		p.queue[i] = natural[j]
+	}
+}
+
+// unshuffleLocked restores the order the entries were added in.
+func (p *MemoryPlayer) unshuffleLocked() {
+	p.queue = p.naturalLocked()
+}
+
+func (p *MemoryPlayer) naturalLocked() []QueueEntry {
+	q := append([]QueueEntry(nil), p.queue...)
+	sort.SliceStable(q, func(i, j int) bool { return q[i].seq < q[j].seq })
+	return q
+}
+
+// renumberLocked makes the current order the unshuffled one.
+func (p *MemoryPlayer) renumberLocked() {
+	for i := range p.queue {
+		p.queue[i].seq = i
+	}
+}
+
+// renumberNaturalLocked closes gaps in seq after a removal.
+func (p *MemoryPlayer) renumberNaturalLocked() {
+	idx := make([]int, len(p.queue))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(a, b int) bool { return p.queue[idx[a]].seq < p.queue[idx[b]].seq })
+	for seq, i := range idx {
+		p.queue[i].seq = seq
+	}
+}
+
+// newShuffleSeed returns a random, non-zero seed. The global math/rand
+// source is not seeded on go 1.19, so it would repeat after every boot.
+func newShuffleSeed() int64 {
+	for {
+		if seed := rand.New(rand.NewSource(time.Now().UnixNano())).Int63(); seed != 0 {
+			return seed
+		}
+	}
+}
@@ -0,0 +1,337 @@
+package player
+
+import (
+	"errors"
+	"math"
+	"sync"
+	"time"
+)
+
+type SleepMode string
+
+const (
+	SleepOff   SleepMode = ""
+	SleepTimed SleepMode = "timer" // after N minutes
+	SleepTrack SleepMode = "track" // at the end of the current track
+	SleepAlbum SleepMode = "album" // at the end of the current album
+)
+
+var (
+	ErrSleepInactive = errors.New("sleep timer not active")
+	ErrSleepInvalid  = errors.New("invalid sleep timer")
+)
+
+// SleepInfo describes a running sleep timer. Remaining is -1 while it is
+// not known yet (album mode before the last track).
+type SleepInfo struct {
+	Mode      SleepMode `json:"mode"`
+	Remaining int       `json:"remaining"` // seconds
+	Fading    bool      `json:"fading"`
+}
+
+// SleepTimer wraps a Player and pauses it when the timer runs out. During
+// the last Fade the volume goes down gradually; it is restored after the
+// pause, so the next play starts at the usual level.
+type SleepTimer struct {
+	Player
+	Fade time.Duration
+
+	// OnDone is called after the timer paused playback (optional).
+	OnDone func()
+
+	mu       sync.Mutex
+	mode     SleepMode
+	deadline time.Time // timer mode
+
+	// track/album mode: the entry to finish. The queue position is not
+	// used, it changes when the queue is edited or shuffled.
+	itemID     string
+	albumID    string
+	albumTrack int
+	ending     bool // the end was within a tick or two at the last check
+
+	fading bool
+	base   int // volume before the fade
+
+	now    func() time.Time // nil = time.Now; tests set it
+	ticker *time.Ticker
+	done   chan struct{}
+}
+
+func NewSleepTimer(p Player) *SleepTimer {
+	s := &SleepTimer{
+		Player: p,
+		Fade:   time.Minute,
+		ticker: time.NewTicker(1 * time.Second),
+		done:   make(chan struct{}),
+	}
+	go s.loop()
+	return s
+}
+
+func (s *SleepTimer) Close() {
+	close(s.done)
+	s.ticker.Stop()
+}
+
+// Start pauses playback after minutes. A running timer is replaced.
+func (s *SleepTimer) Start(minutes int) error {
+	if minutes <= 0 {
+		return ErrSleepInvalid
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.resetLocked()
+	s.mode = SleepTimed
+	s.deadline = s.clock().Add(time.Duration(minutes) * time.Minute)
+	return nil
+}
+
+// StartMode pauses playback at the end of the current track or album.
+func (s *SleepTimer) StartMode(mode SleepMode) error {
+	if mode != SleepTrack && mode != SleepAlbum {
+		return ErrSleepInvalid
+	}
+	st := s.Player.Status()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.resetLocked()
+	s.mode = mode
+	s.itemID = st.ItemID
+	s.albumID = st.AlbumID
+	s.albumTrack = st.AlbumTrack
+	return nil
+}
+
+// Extend adds minutes to a running timer. Track and album timers become
+// a timer for the known remaining time plus minutes.
+func (s *SleepTimer) Extend(minutes int) error {
+	if minutes <= 0 {
+		return ErrSleepInvalid
+	}
+	st := s.Player.Status()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.mode == SleepOff {
+		return ErrSleepInactive
+	}
+	now := s.clock()
+	if s.mode != SleepTimed {
+		rem := s.remainingLocked(st, now)
+		if rem < 0 {
+			rem = 0
+		}
+		s.mode = SleepTimed
+		s.deadline = now.Add(time.Duration(rem) * time.Second)
+	}
+	s.deadline = s.deadline.Add(time.Duration(minutes) * time.Minute)
+	return nil
+}
+
+// Cancel stops the timer and undoes a running fade.
+func (s *SleepTimer) Cancel() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.mode == SleepOff {
+		return ErrSleepInactive
+	}
+	s.resetLocked()
+	return nil
+}
+
+// Info returns the running timer; ok is false if none is set.
+func (s *SleepTimer) Info() (SleepInfo, bool) {
+	st := s.Player.Status()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.infoLocked(st)
+}
+
+func (s *SleepTimer) Status() PlayerStatus {
+	st := s.Player.Status()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if info, ok := s.infoLocked(st); ok {
+		st.Sleep = &info
+		if s.fading {
+			// show the level the user chose, not the faded one
+			st.Volume = s.base
+		}
+	}
+	return st
+}
+
+func (s *SleepTimer) infoLocked(st PlayerStatus) (SleepInfo, bool) {
+	if s.mode == SleepOff {
+		return SleepInfo{}, false
+	}
+	return SleepInfo{
+		Mode:      s.mode,
+		Remaining: s.remainingLocked(st, s.clock()),
+		Fading:    s.fading,
+	}, true
+}
+
+// SetVolume during a fade changes the level the fade starts from (and
+// the level restored afterwards).
+func (s *SleepTimer) SetVolume(level int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.fading {
+		s.Player.SetVolume(level)
+		return
+	}
+	if level < 0 {
+		level = 0
+	}
+	if level > 100 {
+		level = 100
+	}
+	s.base = level
+	s.fadeLocked(s.Player.Status(), s.clock())
+}
+
+func (s *SleepTimer) loop() {
+	for {
+		select {
+		case <-s.done:
+			return
+		case now := <-s.ticker.C:
+			s.check(now)
+		}
+	}
+}
+
+func (s *SleepTimer) check(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.mode == SleepOff {
+		return
+	}
+	st := s.Player.Status()
+
+	if s.mode != SleepTimed && !s.currentLocked(st) {
+		sameAlbum := st.ItemID == s.itemID && st.AlbumID == s.albumID
+		if s.ending || (s.mode == SleepTrack && sameAlbum) {
+			// the player already moved on: stop at the start of what follows
+			s.Player.Pause()
+			s.Player.Seek(0)
+			s.finishLocked()
+		} else {
+			// other content was loaded: end of "this" track/album is gone
+			s.resetLocked()
+		}
+		return
+	}
+
+	rem := s.remainingLocked(st, now)
+	s.ending = s.mode != SleepTimed && rem >= 0 && rem <= 2
+	switch {
+	case rem == 0 || (s.mode != SleepTimed && rem == 1):
+		// track/album end: one tick early, before the player moves on
+		// (or starts over with repeat)
+		s.Player.Pause()
+		s.finishLocked()
+	case rem > 0 && st.State == StatePlaying:
+		s.fadeLocked(st, now)
+	}
+}
+
+// fadeLocked sets the volume for the current point of the fade, or ends
+// the fade if the timer was extended.
+func (s *SleepTimer) fadeLocked(st PlayerStatus, now time.Time) {
+	rem := s.remainingLocked(st, now)
+	fade := int(s.Fade.Seconds())
+
+	if rem < 0 || rem > fade || fade <= 0 {
+		if s.fading {
+			s.fading = false
+			s.Player.SetVolume(s.base)
+		}
+		return
+	}
+	if !s.fading {
+		s.fading = true
+		s.base = st.Volume
+	}
+	// round up: only silent once the timer is done
+	s.Player.SetVolume((s.base*rem + fade - 1) / fade)
+}
+
+func (s *SleepTimer) finishLocked() {
+	s.resetLocked()
+	if s.OnDone != nil {
+		go s.OnDone()
+	}
+}
+
+// resetLocked clears the timer and restores the volume of a fade.
+func (s *SleepTimer) resetLocked() {
+	if s.fading {
+		s.Player.SetVolume(s.base)
+	}
+	s.mode = SleepOff
+	s.fading = false
+	s.ending = false
+	s.deadline = time.Time{}
+}
+
+// currentLocked reports whether st still plays what the track or album
+// timer waits for.
+func (s *SleepTimer) currentLocked(st PlayerStatus) bool {
+	if st.ItemID != s.itemID || st.AlbumID != s.albumID {
+		return false
+	}
+	return s.mode != SleepTrack || st.AlbumTrack == s.albumTrack
+}
+
+func (s *SleepTimer) remainingLocked(st PlayerStatus, now time.Time) int {
+	switch s.mode {
+	case SleepTimed:
+		rem := int(s.deadline.Sub(now).Seconds() + 0.5)
+		if rem < 0 {
+			return 0
+		}
+		return rem
+	case SleepTrack:
+		if !s.currentLocked(st) {
+			return 0
+		}
+		return playTime(st)
+	case SleepAlbum:
+		if st.AlbumTracksLeft > 0 {
+			return -1
+		}
+		return playTime(st)
+	}
+	return 0
+}
+
+func (s *SleepTimer) clock() time.Time {
+	if s.now != nil {
+		return s.now()
+	}
+	return time.Now()
+}
+
+// playTime is the wall-clock time left in the current track.
+func playTime(st PlayerStatus) int {
+	left := st.Duration - st.Position
+	if left <= 0 {
+		return 0
+	}
+	if st.Speed > 0 {
+		return int(math.Ceil(float64(left) / st.Speed))
+	}
+	return left
+}
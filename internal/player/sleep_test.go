@@ -0,0 +1,273 @@
+package player
+
+import (
+	"testing"
+	"time"
+)
+
+// newSleepTest returns a playing memory player with two albums in the
+// queue and a sleep timer on top. Neither runs its ticker: the tests
+// drive the timer with check.
+func newSleepTest(t *testing.T) (*MemoryPlayer, *SleepTimer) {
+	t.Helper()
+	p := newTestPlayer(t, Media{ItemID: "bibi", AlbumID: "bibi_1", TrackCount: 3})
+	p.Enqueue(Media{ItemID: "tkkg", AlbumID: "tkkg_1", TrackCount: 2}.Entries(), false)
+	p.Play()
+	return p, &SleepTimer{Player: p}
+}
+
+func seekToEnd(p Player, left int) {
+	p.Seek(p.Status().Duration - left)
+}
+
+func TestSleepAlbumWithQueuedAlbum(t *testing.T) {
+	p, s := newSleepTest(t)
+	if err := s.StartMode(SleepAlbum); err != nil {
+		t.Fatal(err)
+	}
+
+	s.check(time.Now())
+	if info, ok := s.Info(); !ok || info.Remaining != -1 {
+		t.Fatalf("info = %+v, %v; want unknown remaining time", info, ok)
+	}
+
+	p.SetTrack(3)
+	seekToEnd(p, 30)
+	s.check(time.Now())
+	if info, _ := s.Info(); info.Remaining != 30 {
+		t.Fatalf("remaining = %d on the last album track, want 30", info.Remaining)
+	}
+
+	seekToEnd(p, 1)
+	s.check(time.Now())
+	if _, ok := s.Info(); ok {
+		t.Fatal("timer still running at the album end")
+	}
+	if st := p.Status(); st.State != StatePaused || st.AlbumID != "bibi_1" {
+		t.Fatalf("status = %s %s, want paused in bibi_1", st.State, st.AlbumID)
+	}
+}
+
+func TestSleepTrackSurvivesQueueEdits(t *testing.T) {
+	p, s := newSleepTest(t)
+	p.SetTrack(2)
+	if err := s.StartMode(SleepTrack); err != nil {
+		t.Fatal(err)
+	}
+
+	edits := []func(){
+		func() { _ = p.MoveQueue(2, 4) },
+		func() { _ = p.RemoveQueue(1) },
+		func() { p.SetShuffle(true) },
+		func() { p.SetShuffle(false) },
+	}
+	for i, edit := range edits {
+		edit()
+		s.check(time.Now())
+		if _, ok := s.Info(); !ok {
+			t.Fatalf("edit %d stopped the timer", i)
+		}
+		if p.Status().State != StatePlaying {
+			t.Fatalf("edit %d paused playback", i)
+		}
+	}
+
+	seekToEnd(p, 1)
+	s.check(time.Now())
+	st := p.Status()
+	if _, ok := s.Info(); ok || st.State != StatePaused || st.AlbumTrack != 2 {
+		t.Fatalf("status = %s track %d, want paused at the end of track 2", st.State, st.AlbumTrack)
+	}
+}
+
+func TestSleepPlayerMovedOn(t *testing.T) {
+	p, s := newSleepTest(t)
+	p.SetTrack(3)
+	if err := s.StartMode(SleepAlbum); err != nil {
+		t.Fatal(err)
+	}
+
+	// a tick was missed: the player is already in the next album
+	seekToEnd(p, 2)
+	s.check(time.Now())
+	p.Next()
+	p.Seek(3)
+	s.check(time.Now())
+
+	st := p.Status()
+	if _, ok := s.Info(); ok {
+		t.Fatal("timer still running")
+	}
+	if st.State != StatePaused || st.AlbumID != "tkkg_1" || st.Position != 0 {
+		t.Fatalf("status = %s %s @%d, want paused at the start of tkkg_1", st.State, st.AlbumID, st.Position)
+	}
+}
+
+func TestSleepOtherContentLoaded(t *testing.T) {
+	p, s := newSleepTest(t)
+	if err := s.StartMode(SleepTrack); err != nil {
+		t.Fatal(err)
+	}
+
+	p.Load(Media{ItemID: "benjamin", AlbumID: "benjamin_1", TrackCount: 4})
+	p.Play()
+	s.check(time.Now())
+
+	if _, ok := s.Info(); ok {
+		t.Fatal("timer still running for content that is gone")
+	}
+	if p.Status().State != StatePlaying {
+		t.Fatal("new content was paused")
+	}
+}
+
+// fakePlayer is the part of a Player the sleep timer uses; anything else
+// panics on the nil embedded Player.
+type fakePlayer struct {
+	Player
+	st PlayerStatus
+}
+
+func (f *fakePlayer) Status() PlayerStatus { return f.st }
+func (f *fakePlayer) Pause()               { f.st.State = StatePaused }
+func (f *fakePlayer) Seek(pos int)         { f.st.Position = pos }
+func (f *fakePlayer) SetVolume(level int)  { f.st.Volume = level }
+
+// newFadeTest returns a timer with a one minute fade that runs out two
+// minutes after t0, on a player playing at volume 50.
+func newFadeTest(t *testing.T) (*fakePlayer, *SleepTimer, time.Time) {
+	t.Helper()
+	t0 := time.Date(2025, 11, 3, 19, 0, 0, 0, time.UTC)
+	now := t0
+	p := &fakePlayer{st: PlayerStatus{State: StatePlaying, Volume: 50}}
+	s := &SleepTimer{Player: p, Fade: time.Minute, now: func() time.Time { return now }}
+	if err := s.Start(2); err != nil {
+		t.Fatal(err)
+	}
+	return p, s, t0
+}
+
+func TestSleepFade(t *testing.T) {
+	p, s, t0 := newFadeTest(t)
+
+	steps := []struct {
+		at     int // seconds after t0
+		volume int
+		fading bool
+	}{
+		{30, 50, false},
+		{60, 50, true}, // fade starts at the full level
+		{61, 50, true}, // 49.2 rounds up
+		{90, 25, true},
+		{110, 9, true},
+		{119, 1, true}, // not silent before the end
+	}
+	for _, step := range steps {
+		s.check(t0.Add(time.Duration(step.at) * time.Second))
+		if p.st.Volume != step.volume || s.fading != step.fading {
+			t.Fatalf("at %ds: volume %d fading %v, want %d %v", step.at, p.st.Volume, s.fading, step.volume, step.fading)
+		}
+	}
+	if got := s.Status().Volume; got != 50 {
+		t.Fatalf("status volume = %d during the fade, want the level before it", got)
+	}
+
+	s.check(t0.Add(120 * time.Second))
+	if p.st.State != StatePaused || p.st.Volume != 50 {
+		t.Fatalf("status = %s volume %d, want paused with the volume restored", p.st.State, p.st.Volume)
+	}
+	if _, ok := s.Info(); ok {
+		t.Fatal("timer still running")
+	}
+}
+
+func TestSleepFadeInterrupted(t *testing.T) {
+	tests := []struct {
+		name   string
+		do     func(s *SleepTimer, t0 time.Time)
+		volume int // right after do
+		after  int // volume once the timer is done or gone
+	}{
+		{
+			name:   "cancel",
+			do:     func(s *SleepTimer, t0 time.Time) { _ = s.Cancel() },
+			volume: 50,
+			after:  50,
+		},
+		{
+			name: "extend",
+			do: func(s *SleepTimer, t0 time.Time) {
+				_ = s.Extend(1)
+				s.check(t0.Add(91 * time.Second))
+			},
+			volume: 50,
+			after:  50,
+		},
+		{
+			name:   "louder",
+			do:     func(s *SleepTimer, t0 time.Time) { s.SetVolume(80) },
+			volume: 40,
+			after:  80,
+		},
+		{
+			name:   "quieter",
+			do:     func(s *SleepTimer, t0 time.Time) { s.SetVolume(10) },
+			volume: 5,
+			after:  10,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, s, t0 := newFadeTest(t)
+			s.now = func() time.Time { return t0.Add(90 * time.Second) }
+			s.check(t0.Add(90 * time.Second))
+			if p.st.Volume != 25 {
+				t.Fatalf("volume = %d half way through the fade, want 25", p.st.Volume)
+			}
+
+			tt.do(s, t0)
+			if p.st.Volume != tt.volume {
+				t.Fatalf("volume = %d, want %d", p.st.Volume, tt.volume)
+			}
+			s.check(t0.Add(180 * time.Second))
+			_ = s.Cancel()
+			if p.st.Volume != tt.after {
+				t.Fatalf("volume afterwards = %d, want %d", p.st.Volume, tt.after)
+			}
+		})
+	}
+}
+
+func TestSleepExtendTrackAndAlbum(t *testing.T) {
+	tests := []struct {
+		mode SleepMode
+		st   PlayerStatus
+		want int
+	}{
+		{SleepTrack, PlayerStatus{Duration: 300, Position: 200}, 160},
+		{SleepTrack, PlayerStatus{Duration: 300, Position: 200, Speed: 2}, 110},
+		{SleepAlbum, PlayerStatus{Duration: 300, Position: 200}, 160},
+		{SleepAlbum, PlayerStatus{Duration: 300, Position: 200, AlbumTracksLeft: 2}, 60}, // end not known yet
+	}
+	for _, tt := range tests {
+		now := time.Date(2025, 11, 3, 19, 0, 0, 0, time.UTC)
+		tt.st.State = StatePlaying
+		tt.st.ItemID, tt.st.AlbumID, tt.st.AlbumTrack = "bibi", "bibi_1", 2
+		p := &fakePlayer{st: tt.st}
+		s := &SleepTimer{Player: p, now: func() time.Time { return now }}
+		if err := s.StartMode(tt.mode); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.Extend(1); err != nil {
+			t.Fatal(err)
+		}
+
+		// the timer no longer follows the track
+		p.st.Position = 0
+		p.st.AlbumTrack = 3
+		info, ok := s.Info()
+		if !ok || info.Mode != SleepTimed || info.Remaining != tt.want {
+			t.Errorf("%s %+v: info = %+v, %v; want timer with %ds", tt.mode, tt.st, info, ok, tt.want)
+		}
+	}
+}
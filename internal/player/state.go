@@ -1,5 +1,7 @@
 package player
 
+import "mupibox/internal/chapters"
+
 type PlaybackState string
 
 const (
@@ -16,6 +18,18 @@ const (
 	ModeAudiobookChapters Mode = "audiobook_chapters"
 )
 
+type RepeatMode string
+
+const (
+	RepeatOff RepeatMode = "off"
+	RepeatAll RepeatMode = "all" // start over after the last track
+	RepeatOne RepeatMode = "one" // repeat the current track
+)
+
+func (m RepeatMode) Valid() bool {
+	return m == RepeatOff || m == RepeatAll || m == RepeatOne
+}
+
 type PlayerStatus struct {
 	State PlaybackState `json:"state"`
 	Mode  Mode          `json:"mode"`
@@ -23,18 +37,73 @@ type PlayerStatus struct {
 	Series string `json:"series"`
 	Title  string `json:"title"`
 
-	Track      int `json:"track"`       // 1-based
-	TrackCount int `json:"track_count"` // total tracks
+	Track      int `json:"track"`       // 1-based position in the queue
+	TrackCount int `json:"track_count"` // tracks in the queue
+
+	// 1-based track within the current album; differs from Track once the
+	// queue is shuffled or other content was enqueued
+	AlbumTrack      int `json:"album_track"`
+	AlbumTrackCount int `json:"album_track_count"` // tracks of the current album
+
+	// title of the current chapter of a single-file audiobook
+	Chapter string `json:"chapter,omitempty"`
 
 	Position int `json:"position"` // seconds within current track
 	Duration int `json:"duration"` // seconds of current track
 
 	Cover string `json:"cover"`
 
+	// catalog context of the loaded content (optional)
+	ItemID  string `json:"item_id,omitempty"`
+	AlbumID string `json:"album_id,omitempty"`
+
 	Volume int  `json:"volume"` // 0..100
 	Muted  bool `json:"muted"`
 
+	Speed float64 `json:"speed"` // 1.0 = normal
+
+	Shuffle    bool       `json:"shuffle"`
+	Repeat     RepeatMode `json:"repeat"`
+	TracksLeft int        `json:"tracks_left"` // after the current one, in play order
+
+	// seed of the shuffle order, saved with the resume state
+	ShuffleSeed int64 `json:"shuffle_seed,omitempty"`
+
+	// tracks of the current album that follow the current one in play
+	// order, before other content or the end of the queue
+	AlbumTracksLeft int `json:"album_tracks_left"`
+
 	CanSeek      bool `json:"can_seek"`
 	CanSkipTrack bool `json:"can_skip_track"`
 	CanSkipTime  bool `json:"can_skip_time"`
+
+	// running sleep timer (see SleepTimer)
+	Sleep *SleepInfo `json:"sleep,omitempty"`
+}
+
+// Media describes content to load into a player.
+type Media struct {
+	ItemID  string
+	AlbumID string
+
+	Series string
+	Title  string
+	Cover  string
+	Mode   Mode
+
+	TrackCount int
+	Track      int // 1-based start track
+	Position   int // seconds within start track
+
+	Speed float64 // 0 = normal
+
+	// play behavior. ShuffleSeed picks the shuffle order: resume passes
+	// the saved one to continue in the same order, 0 = a new random order.
+	Shuffle     bool
+	ShuffleSeed int64
+	Repeat      RepeatMode // "" = off
+
+	// chapters of a single-file audiobook; they replace TrackCount and
+	// are played as virtual tracks
+	Chapters []chapters.Chapter
 }
@@ -0,0 +1,185 @@
+package power
+
+import (
+	"log"
+	"sync"
+
+	"mupibox/internal/events"
+	"mupibox/internal/player"
+	"mupibox/internal/status"
+)
+
+type Stage int
+
+const (
+	StageOK Stage = iota
+	StageWarning
+	StageSave
+	StageCritical
+)
+
+func (s Stage) String() string {
+	switch s {
+	case StageWarning:
+		return "warning"
+	case StageSave:
+		return "save"
+	case StageCritical:
+		return "critical"
+	default:
+		return "ok"
+	}
+}
+
+// Thresholds in percent. A stage is entered when the level drops below it.
+type Thresholds struct {
+	WarnPercent     int // cap volume + warning event
+	WarnMaxVolume   int
+	SavePercent     int // pause + save state
+	CriticalPercent int // flush + shutdown command
+}
+
+// recoverMargin avoids flapping around a threshold.
+const recoverMargin = 3
+
+// BatteryPolicy reacts to battery readings. Stages only escalate while
+// discharging; charging or a clearly higher level resets them.
+type BatteryPolicy struct {
+	Thresholds
+	Player      player.Player
+	Bus         *events.Bus
+	Save        func() error // persist resume state and flush stores
+	Run         Runner
+	ShutdownCmd []string
+
+	mu    sync.Mutex
+	stage Stage
+}
+
+func NewBatteryPolicy(t Thresholds, p player.Player, bus *events.Bus, save func() error, shutdownCmd []string) *BatteryPolicy {
+	return &BatteryPolicy{
+		Thresholds:  t,
+		Player:      p,
+		Bus:         bus,
+		Save:        save,
+		Run:         ExecRunner,
+		ShutdownCmd: shutdownCmd,
+	}
+}
+
+func (b *BatteryPolicy) Stage() Stage {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.stage
+}
+
+// MaxVolume is the volume cap while the battery is low (0 = no cap).
+func (b *BatteryPolicy) MaxVolume() int {
+	if b.Stage() >= StageWarning {
+		return b.WarnMaxVolume
+	}
+	return 0
+}
+
+// Update feeds a battery reading; use it with status.Service.Watch.
+func (b *BatteryPolicy) Update(bat status.Battery) {
+	if !bat.Present {
+		return
+	}
+
+	b.mu.Lock()
+	prev := b.stage
+	next := b.stageFor(bat, prev)
+	b.stage = next
+	b.mu.Unlock()
+
+	if next == prev {
+		return
+	}
+	if next < prev {
+		b.publish(next, bat)
+		return
+	}
+
+	// escalate through every stage we skipped, e.g. OK -> Critical
+	for st := prev + 1; st <= next; st++ {
+		b.enter(st, bat)
+	}
+}
+
+func (b *BatteryPolicy) stageFor(bat status.Battery, prev Stage) Stage {
+	if bat.Charging {
+		return StageOK
+	}
+	p := bat.Percent
+
+	next := StageOK
+	switch {
+	case b.CriticalPercent > 0 && p < b.CriticalPercent:
+		next = StageCritical
+	case b.SavePercent > 0 && p < b.SavePercent:
+		next = StageSave
+	case b.WarnPercent > 0 && p < b.WarnPercent:
+		next = StageWarning
+	}
+
+	// fuel gauges wobble: leave a stage only with some margin
+	if next < prev && p < b.threshold(prev)+recoverMargin {
+		return prev
+	}
+	return next
+}
+
+func (b *BatteryPolicy) threshold(st Stage) int {
+	switch st {
+	case StageWarning:
+		return b.WarnPercent
+	case StageSave:
+		return b.SavePercent
+	case StageCritical:
+		return b.CriticalPercent
+	}
+	return 0
+}
+
+func (b *BatteryPolicy) enter(st Stage, bat status.Battery) {
+	switch st {
+	case StageWarning:
+		if b.WarnMaxVolume > 0 && b.Player.Status().Volume > b.WarnMaxVolume {
+			b.Player.SetVolume(b.WarnMaxVolume)
+		}
+	case StageSave:
+		b.Player.Pause()
+		b.save()
+	case StageCritical:
+		b.Player.Pause()
+		b.save()
+		b.publish(st, bat)
+		log.Printf("power: battery critical (%d%%), shutting down", bat.Percent)
+		if err := runCommand(b.Run, b.ShutdownCmd); err != nil {
+			log.Println("power: shutdown:", err)
+		}
+		return
+	}
+	b.publish(st, bat)
+}
+
+func (b *BatteryPolicy) save() {
+	if b.Save == nil {
+		return
+	}
+	if err := b.Save(); err != nil {
+		log.Println("power: save state:", err)
+	}
+}
+
+func (b *BatteryPolicy) publish(st Stage, bat status.Battery) {
+	if b.Bus == nil {
+		return
+	}
+	b.Bus.Publish("battery.low", map[string]any{
+		"stage":      st.String(),
+		"percent":    bat.Percent,
+		"max_volume": b.MaxVolume(),
+	})
+}
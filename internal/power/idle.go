@@ -0,0 +1,150 @@
+package power
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"mupibox/internal/events"
+	"mupibox/internal/player"
+)
+
+// IdleManager powers the box off after it sat paused or stopped for
+// Timeout without any activity. During the last Warning it counts down,
+// so the UI can show it; any Touch (button, API call) or playback resets it.
+type IdleManager struct {
+	Player      player.Player
+	Bus         *events.Bus
+	Save        func() error
+	Run         Runner
+	PowerOffCmd []string
+
+	Timeout time.Duration
+	Warning time.Duration
+
+	mu     sync.Mutex
+	last   time.Time
+	warned bool
+	fired  bool
+	ticker *time.Ticker
+	done   chan struct{}
+}
+
+func NewIdleManager(p player.Player, bus *events.Bus, save func() error, powerOffCmd []string, timeout, warning time.Duration) *IdleManager {
+	return &IdleManager{
+		Player:      p,
+		Bus:         bus,
+		Save:        save,
+		Run:         ExecRunner,
+		PowerOffCmd: powerOffCmd,
+		Timeout:     timeout,
+		Warning:     warning,
+		last:        time.Now(),
+	}
+}
+
+func (m *IdleManager) Start() {
+	m.ticker = time.NewTicker(1 * time.Second)
+	m.done = make(chan struct{})
+	go m.loop()
+}
+
+func (m *IdleManager) Close() {
+	close(m.done)
+	m.ticker.Stop()
+}
+
+// Touch records user activity and cancels a running countdown.
+func (m *IdleManager) Touch() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.touchLocked(time.Now())
+}
+
+func (m *IdleManager) touchLocked(now time.Time) {
+	m.last = now
+	m.fired = false
+	if m.warned {
+		m.warned = false
+		m.publish("idle.cancel", nil)
+	}
+}
+
+// ShutdownIn returns the seconds left while the countdown warning is
+// shown, otherwise 0.
+func (m *IdleManager) ShutdownIn() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.warned {
+		return 0
+	}
+	left := m.Timeout - time.Since(m.last)
+	if left < 0 {
+		return 0
+	}
+	return int(left.Seconds() + 0.5)
+}
+
+func (m *IdleManager) loop() {
+	for {
+		select {
+		case <-m.done:
+			return
+		case now := <-m.ticker.C:
+			m.check(m.Player.Status().State, now)
+		}
+	}
+}
+
+func (m *IdleManager) check(state player.PlaybackState, now time.Time) {
+	if m.Timeout <= 0 {
+		return
+	}
+
+	m.mu.Lock()
+	if state == player.StatePlaying {
+		m.touchLocked(now)
+		m.mu.Unlock()
+		return
+	}
+
+	idle := now.Sub(m.last)
+	if m.fired {
+		m.mu.Unlock()
+		return
+	}
+	if idle >= m.Timeout {
+		m.fired = true
+		m.warned = false
+		m.mu.Unlock()
+		m.powerOff()
+		return
+	}
+	if !m.warned && idle >= m.Timeout-m.Warning {
+		m.warned = true
+		m.publish("idle.warning", map[string]any{
+			"seconds": int((m.Timeout - idle).Seconds() + 0.5),
+		})
+	}
+	m.mu.Unlock()
+}
+
+func (m *IdleManager) powerOff() {
+	log.Printf("power: idle for %s, powering off", m.Timeout)
+	m.publish("idle.shutdown", nil)
+
+	if m.Save != nil {
+		if err := m.Save(); err != nil {
+			log.Println("power: save state:", err)
+		}
+	}
+	if err := runCommand(m.Run, m.PowerOffCmd); err != nil {
+		log.Println("power: power-off:", err)
+	}
+}
+
+func (m *IdleManager) publish(typ string, data any) {
+	if m.Bus != nil {
+		m.Bus.Publish(typ, data)
+	}
+}
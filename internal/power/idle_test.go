@@ -0,0 +1,78 @@
+package power
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"mupibox/internal/events"
+	"mupibox/internal/player"
+)
+
+func TestIdlePowerOff(t *testing.T) {
+	rec := &recorder{}
+	m := NewIdleManager(newTestPlayer(t), nil, rec.save, []string{"poweroff"}, 10*time.Minute, time.Minute)
+	m.Run = rec.run
+	start := m.last
+
+	m.check(player.StatePaused, start.Add(9*time.Minute+30*time.Second))
+	if m.ShutdownIn() == 0 {
+		t.Error("no countdown during the warning")
+	}
+	m.check(player.StatePlaying, start.Add(9*time.Minute+40*time.Second))
+	if m.ShutdownIn() != 0 {
+		t.Error("playback did not cancel the countdown")
+	}
+
+	restart := m.last
+	m.check(player.StatePaused, restart.Add(10*time.Minute))
+	m.check(player.StatePaused, restart.Add(11*time.Minute))
+	if want := []string{"save", "poweroff"}; !reflect.DeepEqual(rec.calls, want) {
+		t.Fatalf("calls = %q, want %q", rec.calls, want)
+	}
+}
+
+func TestIdleDisabled(t *testing.T) {
+	rec := &recorder{}
+	m := NewIdleManager(newTestPlayer(t), nil, rec.save, []string{"poweroff"}, 0, time.Minute)
+	m.Run = rec.run
+
+	m.check(player.StateStopped, m.last.Add(24*time.Hour))
+	if len(rec.calls) != 0 || m.ShutdownIn() != 0 {
+		t.Fatalf("disabled idle power-off ran %q", rec.calls)
+	}
+}
+
+func TestIdleTouch(t *testing.T) {
+	bus := events.NewBus()
+	evs, cancel := bus.Subscribe()
+	defer cancel()
+
+	rec := &recorder{}
+	m := NewIdleManager(newTestPlayer(t), bus, rec.save, []string{"idle-off"}, 10*time.Minute, time.Minute)
+	m.Run = rec.run
+	start := m.last
+
+	m.check(player.StatePaused, start.Add(9*time.Minute+15*time.Second))
+	m.mu.Lock()
+	m.touchLocked(start.Add(9*time.Minute + 20*time.Second)) // a button press
+	m.mu.Unlock()
+
+	// the timeout counts from the touch
+	m.check(player.StatePaused, start.Add(10*time.Minute))
+	if len(rec.calls) != 0 {
+		t.Fatalf("powered off after a touch: %q", rec.calls)
+	}
+	m.check(player.StatePaused, start.Add(19*time.Minute+20*time.Second))
+	if want := []string{"save", "idle-off"}; !reflect.DeepEqual(rec.calls, want) {
+		t.Fatalf("calls = %q, want %q", rec.calls, want)
+	}
+
+	var types []string
+	for len(evs) > 0 {
+		types = append(types, (<-evs).Type)
+	}
+	if want := []string{"idle.warning", "idle.cancel", "idle.shutdown"}; !reflect.DeepEqual(types, want) {
+		t.Fatalf("events = %q, want %q", types, want)
+	}
+}
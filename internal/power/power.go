@@ -0,0 +1,27 @@
+package power
+
+import (
+	"errors"
+	"os/exec"
+)
+
+// Runner executes a system command. It is injectable so policies can be
+// exercised without powering anything off.
+type Runner func(name string, args ...string) error
+
+func ExecRunner(name string, args ...string) error {
+	return exec.Command(name, args...).Run()
+}
+
+var ErrNoCommand = errors.New("no shutdown command configured")
+
+// runCommand runs cmd[0] with cmd[1:] as arguments.
+func runCommand(run Runner, cmd []string) error {
+	if len(cmd) == 0 || cmd[0] == "" {
+		return ErrNoCommand
+	}
+	if run == nil {
+		run = ExecRunner
+	}
+	return run(cmd[0], cmd[1:]...)
+}
@@ -0,0 +1,113 @@
+package power
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"mupibox/internal/player"
+	"mupibox/internal/status"
+)
+
+// recorder collects save and command calls in the order they happened.
+type recorder struct{ calls []string }
+
+func (r *recorder) save() error {
+	r.calls = append(r.calls, "save")
+	return nil
+}
+
+func (r *recorder) run(name string, args ...string) error {
+	r.calls = append(r.calls, strings.Join(append([]string{name}, args...), " "))
+	return nil
+}
+
+func newTestPlayer(t *testing.T) *player.MemoryPlayer {
+	t.Helper()
+	p := player.NewMemoryPlayer()
+	t.Cleanup(p.Close)
+	return p
+}
+
+func TestBatteryPolicy(t *testing.T) {
+	tests := []struct {
+		name      string
+		levels    []status.Battery
+		wantStage Stage
+		wantCalls []string
+		wantVol   int
+	}{
+		{
+			name:      "warning caps the volume",
+			levels:    []status.Battery{{Present: true, Percent: 15}},
+			wantStage: StageWarning,
+			wantVol:   30,
+		},
+		{
+			name:      "save stage saves once",
+			levels:    []status.Battery{{Present: true, Percent: 9}, {Present: true, Percent: 8}},
+			wantStage: StageSave,
+			wantCalls: []string{"save"},
+			wantVol:   30,
+		},
+		{
+			name:      "critical saves before shutting down",
+			levels:    []status.Battery{{Present: true, Percent: 20}, {Present: true, Percent: 3}},
+			wantStage: StageCritical,
+			wantCalls: []string{"save", "save", "sudo poweroff"},
+			wantVol:   30,
+		},
+		{
+			name:      "charging resets",
+			levels:    []status.Battery{{Present: true, Percent: 9}, {Present: true, Percent: 9, Charging: true}},
+			wantStage: StageOK,
+			wantCalls: []string{"save"},
+			wantVol:   30,
+		},
+		{
+			name:      "no battery",
+			levels:    []status.Battery{{Percent: 0}},
+			wantStage: StageOK,
+			wantVol:   50,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newTestPlayer(t)
+			p.SetVolume(50)
+			rec := &recorder{}
+			b := NewBatteryPolicy(Thresholds{
+				WarnPercent:     20,
+				WarnMaxVolume:   30,
+				SavePercent:     10,
+				CriticalPercent: 5,
+			}, p, nil, rec.save, []string{"sudo", "poweroff"})
+			b.Run = rec.run
+
+			for _, bat := range tt.levels {
+				b.Update(bat)
+			}
+			if got := b.Stage(); got != tt.wantStage {
+				t.Errorf("stage = %s, want %s", got, tt.wantStage)
+			}
+			if !reflect.DeepEqual(rec.calls, tt.wantCalls) {
+				t.Errorf("calls = %q, want %q", rec.calls, tt.wantCalls)
+			}
+			if got := p.Status().Volume; got != tt.wantVol {
+				t.Errorf("volume = %d, want %d", got, tt.wantVol)
+			}
+		})
+	}
+}
+
+func TestBatteryNoShutdownCommand(t *testing.T) {
+	rec := &recorder{}
+	b := NewBatteryPolicy(Thresholds{CriticalPercent: 5}, newTestPlayer(t), nil, rec.save, nil)
+	b.Run = rec.run
+
+	b.Update(status.Battery{Present: true, Percent: 2})
+	// skipped stages are entered too: save, then save again before power-off
+	if want := []string{"save", "save"}; !reflect.DeepEqual(rec.calls, want) {
+		t.Fatalf("calls = %q, want %q", rec.calls, want)
+	}
+}
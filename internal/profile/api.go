@@ -0,0 +1,192 @@
+package profile
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+type API struct {
+	M *Manager
+
+	// OnVolumeChange is called after a profile's volume cap changed (optional).
+	OnVolumeChange func()
+}
+
+func NewAPI(m *Manager) *API {
+	return &API{M: m}
+}
+
+func (a *API) Register(mux *http.ServeMux) {
+	// List
+	mux.HandleFunc("/api/profiles", a.handleProfiles)
+
+	// Active profile (GET / POST ?id=); switching must not loosen the
+	// volume cap, that needs the parent API
+	mux.HandleFunc("/api/profiles/active", a.handleActive)
+
+	// Single profile (GET)
+	mux.HandleFunc("/api/profiles/", a.handleProfile)
+}
+
+// RegisterAdmin adds the parent-only profile settings. mux is expected to
+// be behind admin.Require.
+func (a *API) RegisterAdmin(mux *http.ServeMux) {
+	// Create/update (POST), incl. max_volume
+	mux.HandleFunc("/api/admin/profiles", a.handleAdminProfiles)
+
+	// Switch to any profile (POST ?id=)
+	mux.HandleFunc("/api/admin/profiles/active", a.handleAdminActive)
+
+	// DELETE /api/admin/profiles/{id}
+	// PUT /api/admin/profiles/{id}/volume {"max_volume": N}
+	mux.HandleFunc("/api/admin/profiles/", a.handleAdminProfile)
+}
+
+func (a *API) handleProfiles(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	writeJSON(w, map[string]any{
+		"active":   a.M.Active().ID,
+		"profiles": a.M.List(),
+	})
+}
+
+func (a *API) handleActive(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, a.M.Active())
+	case http.MethodPost:
+		a.switchTo(w, r, false)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (a *API) handleProfile(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	p, ok := a.M.Get(strings.TrimPrefix(r.URL.Path, "/api/profiles/"))
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	writeJSON(w, p)
+}
+
+func (a *API) handleAdminProfiles(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var p Profile
+	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	if p.MaxVolume < 0 || p.MaxVolume > 100 {
+		writeError(w, ErrInvalidVolume)
+		return
+	}
+	if err := a.M.Save(p); err != nil {
+		writeError(w, err)
+		return
+	}
+	if a.OnVolumeChange != nil {
+		a.OnVolumeChange()
+	}
+	writeJSON(w, p)
+}
+
+func (a *API) handleAdminActive(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	a.switchTo(w, r, true)
+}
+
+func (a *API) switchTo(w http.ResponseWriter, r *http.Request, admin bool) {
+	id := r.URL.Query().Get("id")
+	if id == "" {
+		http.Error(w, "missing query param: id", http.StatusBadRequest)
+		return
+	}
+	switchTo := a.M.SwitchCapped
+	if admin {
+		switchTo = a.M.Switch
+	}
+	if err := switchTo(id); err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, a.M.Active())
+}
+
+func (a *API) handleAdminProfile(w http.ResponseWriter, r *http.Request) {
+	rest := strings.TrimPrefix(r.URL.Path, "/api/admin/profiles/")
+	id, sub, _ := strings.Cut(rest, "/")
+	if sub == "" && r.Method == http.MethodDelete {
+		if err := a.M.Delete(id); err != nil {
+			writeError(w, err)
+			return
+		}
+		writeOK(w)
+		return
+	}
+	if sub != "volume" {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodPut && r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var body struct {
+		MaxVolume int `json:"max_volume"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	if err := a.M.SetMaxVolume(id, body.MaxVolume); err != nil {
+		writeError(w, err)
+		return
+	}
+	p, _ := a.M.Get(id)
+	if a.OnVolumeChange != nil {
+		a.OnVolumeChange()
+	}
+	writeJSON(w, p)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, ErrInvalidID), errors.Is(err, ErrInvalidVolume):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, ErrInUse):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, ErrNeedsAdmin):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func writeOK(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{"ok": true})
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
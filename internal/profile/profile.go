@@ -0,0 +1,355 @@
+package profile
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+
+	"mupibox/internal/favorites"
+	"mupibox/internal/state"
+)
+
+// DefaultID is the profile that owns the legacy data/state.json.
+const DefaultID = "default"
+
+var validID = regexp.MustCompile(`^[a-z0-9_-]+$`)
+
+var (
+	ErrNotFound  = errors.New("profile not found")
+	ErrInvalidID = errors.New("invalid profile id")
+	ErrInUse     = errors.New("profile is active or default")
+
+	ErrInvalidVolume = errors.New("invalid volume")
+	ErrNeedsAdmin    = errors.New("switching to this profile needs the parent pin")
+)
+
+type Profile struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Avatar string `json:"avatar,omitempty"` // z.B. /covers/avatar_lena.png
+
+	// 0 = no limit
+	MaxVolume int `json:"max_volume,omitempty"`
+
+	// default playback speed, 0 = normal
+	Speed float64 `json:"speed,omitempty"`
+}
+
+// cap returns the effective volume cap of p (100 = none).
+func (p Profile) cap() int {
+	if p.MaxVolume <= 0 {
+		return 100
+	}
+	return p.MaxVolume
+}
+
+// Looser reports whether switching from cur to p raises the volume cap.
+func Looser(p, cur Profile) bool {
+	return p.cap() > cur.cap()
+}
+
+type file struct {
+	Active   string    `json:"active"`
+	Profiles []Profile `json:"profiles"`
+}
+
+// Manager keeps the profile list, the active profile and one state.Store
+// and favorites.Store per profile. The default profile keeps using the
+// legacy state file so existing resume data stays where it is.
+type Manager struct {
+	path    string // profiles.json
+	dataDir string
+
+	// OnSwitch is called after the active profile changed (optional).
+	OnSwitch func(Profile)
+
+	mu     sync.Mutex
+	data   file
+	stores map[string]*state.Store
+	favs   map[string]*favorites.Store
+}
+
+func NewManager(path, dataDir string, defaultState *state.Store) (*Manager, error) {
+	m := &Manager{
+		path:    path,
+		dataDir: dataDir,
+		stores:  map[string]*state.Store{DefaultID: defaultState},
+		favs:    map[string]*favorites.Store{},
+	}
+
+	raw, err := os.ReadFile(path)
+	if err == nil {
+		_ = json.Unmarshal(raw, &m.data)
+	}
+
+	if m.indexLocked(DefaultID) < 0 {
+		m.data.Profiles = append([]Profile{{ID: DefaultID, Name: "Standard"}}, m.data.Profiles...)
+	}
+	if m.indexLocked(m.data.Active) < 0 {
+		m.data.Active = DefaultID
+	}
+
+	if os.IsNotExist(err) {
+		_ = m.persist()
+	}
+
+	return m, nil
+}
+
+func (m *Manager) List() []Profile {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Profile(nil), m.data.Profiles...)
+}
+
+// IDs returns the ids of all profiles.
+func (m *Manager) IDs() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ids := make([]string, len(m.data.Profiles))
+	for i, p := range m.data.Profiles {
+		ids[i] = p.ID
+	}
+	return ids
+}
+
+func (m *Manager) Get(id string) (Profile, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	i := m.indexLocked(id)
+	if i < 0 {
+		return Profile{}, false
+	}
+	return m.data.Profiles[i], true
+}
+
+func (m *Manager) Active() Profile {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.data.Profiles[m.indexLocked(m.data.Active)]
+}
+
+// Switch makes id the active profile. It is meant for the parent API;
+// children switch through SwitchCapped.
+func (m *Manager) Switch(id string) error {
+	return m.switchTo(id, false)
+}
+
+// SwitchCapped is Switch without raising the volume cap: switching to a
+// looser profile returns ErrNeedsAdmin. It is used by the public API and
+// by cards.
+func (m *Manager) SwitchCapped(id string) error {
+	return m.switchTo(id, true)
+}
+
+func (m *Manager) switchTo(id string, capped bool) error {
+	m.mu.Lock()
+	i := m.indexLocked(id)
+	if i < 0 {
+		m.mu.Unlock()
+		return ErrNotFound
+	}
+	if capped && Looser(m.data.Profiles[i], m.data.Profiles[m.indexLocked(m.data.Active)]) {
+		m.mu.Unlock()
+		return ErrNeedsAdmin
+	}
+	changed := m.data.Active != id
+	m.data.Active = id
+	err := m.persist()
+	p := m.data.Profiles[i]
+	m.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+	if changed && m.OnSwitch != nil {
+		m.OnSwitch(p)
+	}
+	return nil
+}
+
+// Save creates or updates a profile.
+func (m *Manager) Save(p Profile) error {
+	if !validID.MatchString(p.ID) {
+		return ErrInvalidID
+	}
+	if p.MaxVolume < 0 {
+		p.MaxVolume = 0
+	}
+	if p.MaxVolume > 100 {
+		p.MaxVolume = 100
+	}
+	if p.Speed < 0 {
+		p.Speed = 0
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if i := m.indexLocked(p.ID); i >= 0 {
+		m.data.Profiles[i] = p
+	} else {
+		m.data.Profiles = append(m.data.Profiles, p)
+	}
+	return m.persist()
+}
+
+// SetMaxVolume changes the volume cap of a profile (0 = none). It is
+// meant for the parent API only.
+func (m *Manager) SetMaxVolume(id string, max int) error {
+	if max < 0 || max > 100 {
+		return ErrInvalidVolume
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i := m.indexLocked(id)
+	if i < 0 {
+		return ErrNotFound
+	}
+	m.data.Profiles[i].MaxVolume = max
+	return m.persist()
+}
+
+// Delete removes a profile. Its data directory is left on disk.
+func (m *Manager) Delete(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i := m.indexLocked(id)
+	if i < 0 {
+		return ErrNotFound
+	}
+	if id == DefaultID || id == m.data.Active {
+		return ErrInUse
+	}
+	m.data.Profiles = append(m.data.Profiles[:i], m.data.Profiles[i+1:]...)
+	delete(m.stores, id)
+	delete(m.favs, id)
+	return m.persist()
+}
+
+// State returns the resume store of the active profile. If it can't be
+// opened, callers get an empty store that refuses changes: another
+// profile's resume points must not be shown or overwritten.
+func (m *Manager) State() *state.Store {
+	id := m.Active().ID
+	st, err := m.StateFor(id)
+	if err != nil {
+		log.Printf("profile %s: state: %v", id, err)
+		return state.Disabled(fmt.Errorf("profile %s: state: %w", id, err))
+	}
+	return st
+}
+
+// StateFor returns the resume store of a profile, opening it on first use.
+func (m *Manager) StateFor(id string) (*state.Store, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if st, ok := m.stores[id]; ok {
+		return st, nil
+	}
+	if m.indexLocked(id) < 0 {
+		return nil, ErrNotFound
+	}
+
+	dir := m.Dir(id)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	st, err := state.NewStore(filepath.Join(dir, "state.json"))
+	if err != nil {
+		return nil, err
+	}
+	m.stores[id] = st
+	return st, nil
+}
+
+// FlushAll syncs the resume store of every profile opened so far. A
+// switch since the last save may have left changes in another profile's
+// store, so power-off paths flush all of them. It returns the first error.
+func (m *Manager) FlushAll() error {
+	m.mu.Lock()
+	stores := make(map[string]*state.Store, len(m.stores))
+	for id, st := range m.stores {
+		stores[id] = st
+	}
+	m.mu.Unlock()
+
+	var first error
+	for id, st := range stores {
+		if err := st.Flush(); err != nil {
+			log.Printf("profile %s: flush state: %v", id, err)
+			if first == nil {
+				first = err
+			}
+		}
+	}
+	return first
+}
+
+// Favorites returns the favorites of the active profile. Like State, it
+// returns an empty store that refuses changes if they can't be opened.
+func (m *Manager) Favorites() *favorites.Store {
+	id := m.Active().ID
+	f, err := m.FavoritesFor(id)
+	if err != nil {
+		log.Printf("profile %s: favorites: %v", id, err)
+		return favorites.Disabled(fmt.Errorf("profile %s: favorites: %w", id, err))
+	}
+	return f
+}
+
+// FavoritesFor returns the favorites of a profile, stored next to its
+// resume state.
+func (m *Manager) FavoritesFor(id string) (*favorites.Store, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if f, ok := m.favs[id]; ok {
+		return f, nil
+	}
+	if m.indexLocked(id) < 0 {
+		return nil, ErrNotFound
+	}
+
+	dir := m.Dir(id)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	f, err := favorites.NewStore(filepath.Join(dir, "favorites.json"))
+	if err != nil {
+		return nil, err
+	}
+	m.favs[id] = f
+	return f, nil
+}
+
+// Dir is where per-profile data of non-default profiles lives.
+func (m *Manager) Dir(id string) string {
+	if id == DefaultID {
+		return m.dataDir
+	}
+	return filepath.Join(m.dataDir, "profiles", id)
+}
+
+func (m *Manager) indexLocked(id string) int {
+	for i, p := range m.data.Profiles {
+		if p.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func (m *Manager) persist() error {
+	raw, _ := json.MarshalIndent(m.data, "", "  ")
+	return os.WriteFile(m.path, raw, 0644)
+}
@@ -0,0 +1,196 @@
+package profile
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"mupibox/internal/favorites"
+	"mupibox/internal/state"
+)
+
+func newTestManager(t *testing.T) *Manager {
+	t.Helper()
+	dir := t.TempDir()
+	st, err := state.NewStore(filepath.Join(dir, "state.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := NewManager(filepath.Join(dir, "profiles.json"), dir, st)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestSwitchNeedsAdminToRaiseCap(t *testing.T) {
+	m := newTestManager(t)
+	for _, p := range []Profile{
+		{ID: "lena", Name: "Lena", MaxVolume: 40},
+		{ID: "tom", Name: "Tom", MaxVolume: 60},
+		{ID: "baby", Name: "Baby", MaxVolume: 30},
+	} {
+		if err := m.Save(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := m.Switch("lena"); err != nil {
+		t.Fatal(err)
+	}
+
+	api := NewAPI(m)
+	mux := http.NewServeMux()
+	api.Register(mux)
+	adminMux := http.NewServeMux()
+	api.RegisterAdmin(adminMux)
+
+	post := func(h http.Handler, url string) int {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, url, nil))
+		return rec.Code
+	}
+
+	tests := []struct {
+		name  string
+		h     http.Handler
+		url   string
+		code  int
+		after string
+	}{
+		{"higher cap", mux, "/api/profiles/active?id=tom", http.StatusForbidden, "lena"},
+		{"uncapped", mux, "/api/profiles/active?id=default", http.StatusForbidden, "lena"},
+		{"lower cap", mux, "/api/profiles/active?id=baby", http.StatusOK, "baby"},
+		{"unknown", mux, "/api/profiles/active?id=nope", http.StatusNotFound, "baby"},
+		{"admin", adminMux, "/api/admin/profiles/active?id=default", http.StatusOK, "default"},
+		{"create is admin only", mux, "/api/profiles", http.StatusMethodNotAllowed, "default"},
+	}
+	for _, tt := range tests {
+		if code := post(tt.h, tt.url); code != tt.code {
+			t.Errorf("%s: status %d, want %d", tt.name, code, tt.code)
+		}
+		if got := m.Active().ID; got != tt.after {
+			t.Errorf("%s: active %q, want %q", tt.name, got, tt.after)
+		}
+	}
+}
+
+func TestAdminCreateAndDelete(t *testing.T) {
+	m := newTestManager(t)
+	adminMux := http.NewServeMux()
+	NewAPI(m).RegisterAdmin(adminMux)
+
+	rec := httptest.NewRecorder()
+	body := strings.NewReader(`{"id":"lena","name":"Lena","max_volume":50}`)
+	adminMux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/admin/profiles", body))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("create: %d %s", rec.Code, rec.Body)
+	}
+	if p, _ := m.Get("lena"); p.MaxVolume != 50 {
+		t.Fatalf("max_volume = %d, want 50", p.MaxVolume)
+	}
+
+	rec = httptest.NewRecorder()
+	adminMux.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/api/admin/profiles/lena", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("delete: %d %s", rec.Code, rec.Body)
+	}
+	if _, ok := m.Get("lena"); ok {
+		t.Fatal("profile still exists")
+	}
+}
+
+func TestStateDoesNotFallBackToDefault(t *testing.T) {
+	m := newTestManager(t)
+	def, err := m.StateFor(DefaultID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := def.Set("bibi_1", state.ResumeState{ItemID: "bibi", PositionSec: 12}); err != nil {
+		t.Fatal(err)
+	}
+	defFavs, err := m.FavoritesFor(DefaultID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := defFavs.Add(favorites.Favorite{Kind: favorites.KindItem, ID: "bibi"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.Save(Profile{ID: "lena", Name: "Lena"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Switch("lena"); err != nil {
+		t.Fatal(err)
+	}
+	// a file where the profile directory should be: StateFor fails
+	if err := os.MkdirAll(filepath.Join(m.dataDir, "profiles"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(m.Dir("lena"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := m.StateFor("lena"); err == nil {
+		t.Fatal("StateFor: expected an error")
+	}
+	st := m.State()
+	if st == def {
+		t.Fatal("State() returned the default profile's store")
+	}
+	if n := len(st.ListRecent(0)); n != 0 {
+		t.Fatalf("State() lists %d entries of another profile", n)
+	}
+	if err := st.Set("tkkg_1", state.ResumeState{ItemID: "tkkg", PositionSec: 5}); err == nil {
+		t.Fatal("Set on a store that couldn't be opened: expected an error")
+	}
+	if _, ok := def.Get("tkkg_1"); ok {
+		t.Fatal("write ended up in the default profile")
+	}
+
+	favs := m.Favorites()
+	if favs == defFavs || len(favs.List()) != 0 {
+		t.Fatalf("Favorites() = %v, want an empty store", favs.List())
+	}
+	if _, err := favs.Toggle(favorites.Favorite{Kind: favorites.KindItem, ID: "tkkg"}); err == nil {
+		t.Fatal("Toggle on favorites that couldn't be opened: expected an error")
+	}
+	if n := len(defFavs.List()); n != 1 {
+		t.Fatalf("default favorites = %d, want 1", n)
+	}
+}
+
+func TestFlushAll(t *testing.T) {
+	m := newTestManager(t)
+	if err := m.Save(Profile{ID: "lena", Name: "Lena"}); err != nil {
+		t.Fatal(err)
+	}
+	lena, err := m.StateFor("lena")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := lena.Set("bibi_1", state.ResumeState{ItemID: "bibi", PositionSec: 12}); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Switch(DefaultID); err != nil {
+		t.Fatal(err)
+	}
+
+	// lose lena's file: only a flush of every opened store brings it back
+	path := filepath.Join(m.Dir("lena"), "state.json")
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.FlushAll(); err != nil {
+		t.Fatal(err)
+	}
+	reopened, err := state.NewStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st, ok := reopened.Get("bibi_1"); !ok || st.PositionSec != 12 {
+		t.Fatalf("bibi_1 = %+v, %v", st, ok)
+	}
+}
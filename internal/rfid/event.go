@@ -0,0 +1,72 @@
+package rfid
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// EventSize is the size of struct input_event on this platform:
+// struct timeval (two longs) + type (u16) + code (u16) + value (s32).
+// 24 bytes on 64-bit, 16 bytes on 32-bit Raspberry Pi OS.
+const EventSize = 2*strconv.IntSize/8 + 8
+
+const (
+	evKey     = 0x01
+	keyPress  = 1
+	keyEnter  = 28
+	keyKPEnt  = 96
+	maxCardID = 64
+)
+
+// keyChars maps Linux key codes to the characters USB RFID readers type:
+// digits (top row and keypad) and A-F for readers that send hex ids.
+var keyChars = map[uint16]byte{
+	2: '1', 3: '2', 4: '3', 5: '4', 6: '5', 7: '6', 8: '7', 9: '8', 10: '9', 11: '0',
+	79: '1', 80: '2', 81: '3', 75: '4', 76: '5', 77: '6', 71: '7', 72: '8', 73: '9', 82: '0',
+	30: 'A', 48: 'B', 46: 'C', 32: 'D', 18: 'E', 33: 'F',
+}
+
+// Scan reads input_event structs of the given size from r and calls fn
+// with every id typed before Enter. It returns when r is exhausted
+// (io.EOF is reported as nil) or fails.
+func Scan(r io.Reader, eventSize int, fn func(id string)) error {
+	if eventSize != 16 && eventSize != 24 {
+		return errors.New("rfid: unsupported input_event size")
+	}
+
+	buf := make([]byte, eventSize)
+	var id strings.Builder
+	for {
+		if _, err := io.ReadFull(r, buf); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				return nil
+			}
+			return err
+		}
+
+		// the timeval in front is not needed
+		ev := buf[eventSize-8:]
+		typ := binary.LittleEndian.Uint16(ev[0:2])
+		code := binary.LittleEndian.Uint16(ev[2:4])
+		value := int32(binary.LittleEndian.Uint32(ev[4:8]))
+
+		if typ != evKey || value != keyPress {
+			continue
+		}
+
+		switch code {
+		case keyEnter, keyKPEnt:
+			if id.Len() > 0 {
+				fn(id.String())
+			}
+			id.Reset()
+		default:
+			if c, ok := keyChars[code]; ok && id.Len() < maxCardID {
+				id.WriteByte(c)
+			}
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package rfid
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+const (
+	evSyn = 0x00
+	evMsc = 0x04
+)
+
+// stream builds input_events like a USB reader in keyboard mode sends
+// them: per key MSC_SCAN, key down, SYN, then MSC_SCAN, key up, SYN.
+type stream struct {
+	size int
+	buf  bytes.Buffer
+	usec int64
+}
+
+func (s *stream) event(typ, code uint16, value int32) {
+	ev := make([]byte, s.size)
+	half := (s.size - 8) / 2
+	s.usec += 800
+	if half == 8 {
+		binary.LittleEndian.PutUint64(ev[0:], uint64(1760000000+s.usec/1e6))
+		binary.LittleEndian.PutUint64(ev[8:], uint64(s.usec%1e6))
+	} else {
+		binary.LittleEndian.PutUint32(ev[0:], uint32(1760000000+s.usec/1e6))
+		binary.LittleEndian.PutUint32(ev[4:], uint32(s.usec%1e6))
+	}
+	binary.LittleEndian.PutUint16(ev[s.size-8:], typ)
+	binary.LittleEndian.PutUint16(ev[s.size-6:], code)
+	binary.LittleEndian.PutUint32(ev[s.size-4:], uint32(value))
+	s.buf.Write(ev)
+}
+
+func (s *stream) key(code uint16) {
+	for _, v := range []int32{1, 0} {
+		s.event(evMsc, 4, 0x70000+int32(code)) // MSC_SCAN, HID usage
+		s.event(evKey, code, v)
+		s.event(evSyn, 0, 0)
+	}
+}
+
+var digitKeys = map[rune]uint16{'1': 2, '2': 3, '3': 4, '4': 5, '5': 6, '6': 7, '7': 8, '8': 9, '9': 10, '0': 11}
+
+func (s *stream) card(id string, enter uint16) {
+	for _, c := range id {
+		s.key(digitKeys[c])
+	}
+	s.key(enter)
+}
+
+func TestScan(t *testing.T) {
+	for _, size := range []int{16, 24} {
+		s := &stream{size: size}
+		s.card("0012345678", keyEnter)
+		s.key(keyEnter)       // empty line: ignored
+		s.key(79)             // keypad 1
+		s.key(82)             // keypad 0
+		s.event(evKey, 80, 2) // autorepeat of keypad 2: ignored
+		s.key(30)             // A
+		s.key(33)             // F
+		s.key(57)             // space: not part of an id
+		s.key(keyKPEnt)
+		for i := 0; i < maxCardID+10; i++ {
+			s.key(2)
+		}
+		s.key(keyEnter)
+		s.buf.Write(make([]byte, size/2)) // unplugged mid-event
+
+		var ids []string
+		if err := Scan(&s.buf, size, func(id string) { ids = append(ids, id) }); err != nil {
+			t.Fatalf("size %d: %v", size, err)
+		}
+		want := []string{"0012345678", "10AF", string(bytes.Repeat([]byte("1"), maxCardID))}
+		if !reflect.DeepEqual(ids, want) {
+			t.Fatalf("size %d: ids = %q, want %q", size, ids, want)
+		}
+	}
+}
+
+func TestScanErrors(t *testing.T) {
+	if err := Scan(bytes.NewReader(nil), 20, func(string) {}); err == nil {
+		t.Error("odd event size accepted")
+	}
+
+	broken := errors.New("read error")
+	r := &failingReader{err: broken}
+	if err := Scan(r, EventSize, func(string) {}); err != broken {
+		t.Errorf("err = %v, want the read error", err)
+	}
+}
+
+type failingReader struct{ err error }
+
+func (f *failingReader) Read([]byte) (int, error) { return 0, f.err }
+
+func TestReaderDebounce(t *testing.T) {
+	var ids []string
+	r := NewReader(nil, false, func(id string) { ids = append(ids, id) })
+
+	r.emit("1")
+	r.emit("1") // card still on the reader
+	r.emit("2")
+	r.emit("1")
+	r.lastAt = r.lastAt.Add(-3 * time.Second)
+	r.emit("1")
+
+	if want := []string{"1", "2", "1", "1"}; !reflect.DeepEqual(ids, want) {
+		t.Fatalf("ids = %q, want %q", ids, want)
+	}
+}
@@ -0,0 +1,21 @@
+//go:build linux
+
+package rfid
+
+import (
+	"os"
+	"syscall"
+)
+
+// EVIOCGRAB = _IOW('E', 0x90, int)
+const eviocgrab = 0x40044590
+
+// grab gives us exclusive access, so card digits do not end up as key
+// presses in other programs (e.g. the kiosk browser).
+func grab(f *os.File) error {
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), eviocgrab, 1)
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
@@ -0,0 +1,12 @@
+//go:build !linux
+
+package rfid
+
+import (
+	"errors"
+	"os"
+)
+
+func grab(f *os.File) error {
+	return errors.New("rfid: grabbing input devices is only supported on linux")
+}
@@ -0,0 +1,133 @@
+package rfid
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Reader reads USB RFID readers that act as keyboards. Devices may be
+// given as globs (e.g. /dev/input/by-id/*RFID*-event-kbd); missing devices
+// are retried so the reader can be plugged in later.
+type Reader struct {
+	Paths    []string
+	Grab     bool
+	Debounce time.Duration // same card again within this window is ignored
+
+	// OnCard is called for every scanned card id.
+	OnCard func(id string)
+
+	mu     sync.Mutex
+	lastID string
+	lastAt time.Time
+	open   map[string]*os.File // by device path
+	done   chan struct{}
+}
+
+func NewReader(paths []string, grab bool, onCard func(id string)) *Reader {
+	return &Reader{
+		Paths:    paths,
+		Grab:     grab,
+		Debounce: 2 * time.Second,
+		OnCard:   onCard,
+		open:     map[string]*os.File{},
+	}
+}
+
+func (r *Reader) Start() {
+	r.done = make(chan struct{})
+	go r.watch()
+}
+
+func (r *Reader) Close() {
+	close(r.done)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, f := range r.open {
+		f.Close()
+	}
+}
+
+// watch opens configured devices and re-checks for new ones.
+func (r *Reader) watch() {
+	t := time.NewTicker(2 * time.Second)
+	defer t.Stop()
+	for {
+		r.openDevices()
+		select {
+		case <-r.done:
+			return
+		case <-t.C:
+		}
+	}
+}
+
+func (r *Reader) openDevices() {
+	for _, pattern := range r.Paths {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			log.Println("rfid:", err)
+			continue
+		}
+		for _, path := range matches {
+			r.mu.Lock()
+			_, busy := r.open[path]
+			r.mu.Unlock()
+			if !busy {
+				r.openDevice(path)
+			}
+		}
+	}
+}
+
+func (r *Reader) openDevice(path string) {
+	f, err := os.Open(path)
+	if err != nil {
+		log.Println("rfid:", err)
+		return
+	}
+	if r.Grab {
+		if err := grab(f); err != nil {
+			log.Printf("rfid: grab %s: %v", path, err)
+		}
+	}
+
+	r.mu.Lock()
+	r.open[path] = f
+	r.mu.Unlock()
+
+	log.Println("rfid: reading", path)
+	go func() {
+		err := Scan(f, EventSize, r.emit)
+		f.Close()
+
+		r.mu.Lock()
+		delete(r.open, path)
+		r.mu.Unlock()
+
+		select {
+		case <-r.done:
+			// closed by Close
+		default:
+			// unplugged: watch() reopens it when it comes back
+			log.Printf("rfid: %s gone: %v", path, err)
+		}
+	}()
+}
+
+func (r *Reader) emit(id string) {
+	now := time.Now()
+
+	r.mu.Lock()
+	dup := id == r.lastID && now.Sub(r.lastAt) < r.Debounce
+	r.lastID, r.lastAt = id, now
+	r.mu.Unlock()
+
+	if dup || r.OnCard == nil {
+		return
+	}
+	r.OnCard(id)
+}
@@ -0,0 +1,86 @@
+package rotary
+
+import "time"
+
+// transitions[old<<2|new] is the direction of a step between two A/B
+// states (state = A<<1 | B). Invalid jumps (both lines changed) count 0.
+var transitions = [16]int8{
+	0, -1, 1, 0,
+	1, 0, 0, -1,
+	-1, 0, 0, 1,
+	0, 1, -1, 0,
+}
+
+// Decoder turns A/B edges of a quadrature encoder into detents. Contact
+// bounce produces back-and-forth steps that cancel out.
+type Decoder struct {
+	StepsPerDetent int  // KY-040: 4 (one full cycle per click)
+	Reverse        bool // swap direction
+
+	state int8 // A<<1 | B
+	acc   int
+}
+
+// NewDecoder starts at rest with both lines high (pull-ups).
+func NewDecoder(stepsPerDetent int, reverse bool) *Decoder {
+	if stepsPerDetent <= 0 {
+		stepsPerDetent = 4
+	}
+	return &Decoder{StepsPerDetent: stepsPerDetent, Reverse: reverse, state: 3}
+}
+
+// Edge feeds a level change of line A (a = true) or B. It returns +1 or
+// -1 when a detent is complete, otherwise 0.
+func (d *Decoder) Edge(a bool, level bool) int {
+	next := d.state
+	bit := int8(1)
+	if a {
+		bit = 2
+	}
+	if level {
+		next |= bit
+	} else {
+		next &^= bit
+	}
+
+	d.acc += int(transitions[d.state<<2|next])
+	d.state = next
+
+	dir := 0
+	switch {
+	case d.acc >= d.StepsPerDetent:
+		dir = 1
+	case d.acc <= -d.StepsPerDetent:
+		dir = -1
+	default:
+		return 0
+	}
+	d.acc = 0
+	if d.Reverse {
+		dir = -dir
+	}
+	return dir
+}
+
+// Accel multiplies detents on fast turns, so the full volume range does
+// not need a dozen turns.
+type Accel struct {
+	last    time.Time
+	lastDir int
+}
+
+func (a *Accel) Steps(dir int, t time.Time) int {
+	gap := t.Sub(a.last)
+	same := dir == a.lastDir
+	a.last, a.lastDir = t, dir
+
+	switch {
+	case !same:
+		return dir
+	case gap < 40*time.Millisecond:
+		return dir * 4
+	case gap < 100*time.Millisecond:
+		return dir * 2
+	}
+	return dir
+}
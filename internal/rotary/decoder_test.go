@@ -0,0 +1,87 @@
+package rotary
+
+import (
+	"testing"
+	"time"
+)
+
+// cw is one detent clockwise from rest: A falls, B falls, A rises, B
+// rises. Each edge is {line A?, level}.
+var cw = [][2]bool{{true, false}, {false, false}, {true, true}, {false, true}}
+
+// ccw is the same detent turned back: B leads.
+var ccw = [][2]bool{{false, false}, {true, false}, {false, true}, {true, true}}
+
+func feed(d *Decoder, edges ...[][2]bool) []int {
+	var dirs []int
+	for _, seq := range edges {
+		for _, e := range seq {
+			if dir := d.Edge(e[0], e[1]); dir != 0 {
+				dirs = append(dirs, dir)
+			}
+		}
+	}
+	return dirs
+}
+
+func TestDecoder(t *testing.T) {
+	// contact bounce on A at the start of a clockwise detent
+	bounce := [][2]bool{{true, false}, {true, true}, {true, false}, {true, true}}
+	// a missed edge: B seems to change together with A
+	skipped := [][2]bool{{true, false}, {true, false}, {false, false}, {true, true}, {false, true}}
+
+	tests := []struct {
+		name    string
+		steps   int
+		reverse bool
+		seqs    [][][2]bool
+		want    []int
+	}{
+		{"clockwise", 4, false, [][][2]bool{cw, cw}, []int{1, 1}},
+		{"counter-clockwise", 4, false, [][][2]bool{ccw}, []int{-1}},
+		{"reverse", 4, true, [][][2]bool{cw, ccw}, []int{-1, 1}},
+		{"bounce", 4, false, [][][2]bool{bounce, cw}, []int{1}},
+		{"repeated level", 4, false, [][][2]bool{skipped}, []int{1}},
+		{"half detent back", 4, false, [][][2]bool{cw[:2], {{false, true}, {true, true}}}, nil},
+		{"half-step encoder", 2, false, [][][2]bool{cw}, []int{1, 1}},
+		{"default steps", 0, false, [][][2]bool{cw[:3]}, nil},
+	}
+	for _, tt := range tests {
+		d := NewDecoder(tt.steps, tt.reverse)
+		got := feed(d, tt.seqs...)
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: detents = %v, want %v", tt.name, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("%s: detents = %v, want %v", tt.name, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestAccel(t *testing.T) {
+	start := time.Date(2026, 1, 1, 7, 0, 0, 0, time.UTC)
+	var a Accel
+	tests := []struct {
+		after time.Duration
+		dir   int
+		want  int
+	}{
+		{0, 1, 1},
+		{200 * time.Millisecond, 1, 1},
+		{60 * time.Millisecond, 1, 2},
+		{20 * time.Millisecond, 1, 4},
+		{20 * time.Millisecond, -1, -1}, // direction change never accelerates
+		{20 * time.Millisecond, -1, -4},
+	}
+	now := start
+	for i, tt := range tests {
+		now = now.Add(tt.after)
+		if got := a.Steps(tt.dir, now); got != tt.want {
+			t.Errorf("step %d: %d, want %d", i, got, tt.want)
+		}
+	}
+}
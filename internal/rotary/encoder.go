@@ -0,0 +1,194 @@
+package rotary
+
+import (
+	"sync"
+	"time"
+
+	"mupibox/internal/button"
+	"mupibox/internal/events"
+	"mupibox/internal/gpio"
+	"mupibox/internal/player"
+)
+
+type Mode string
+
+const (
+	ModeVolume Mode = "volume" // Drehen = Lautstärke
+	ModeSkip   Mode = "skip"   // Drehen = spulen
+)
+
+type Config struct {
+	LineA  int
+	LineB  int
+	LineSW int // push button, 0 = none
+
+	StepsPerDetent int
+	Reverse        bool
+
+	VolumeStep  int // per detent (before acceleration)
+	SkipSeconds int // per detent
+
+	Mode       Mode
+	ModeSwitch bool // long press on the push button switches volume/skip
+}
+
+// Encoder drives a player from a KY-040 style rotary encoder: turning
+// changes the volume (or skips), pushing toggles play/pause.
+type Encoder struct {
+	Config
+	Source gpio.Source
+	Player player.Player
+	Bus    *events.Bus
+
+	// OnActivity is called on every turn or push (optional).
+	OnActivity func()
+
+	mu    sync.Mutex
+	dec   *Decoder
+	accel Accel
+	push  *button.Detector
+	done  chan struct{}
+}
+
+func NewEncoder(cfg Config, src gpio.Source, p player.Player, bus *events.Bus) *Encoder {
+	if cfg.VolumeStep <= 0 {
+		cfg.VolumeStep = 2
+	}
+	if cfg.SkipSeconds <= 0 {
+		cfg.SkipSeconds = 10
+	}
+	if cfg.Mode != ModeSkip {
+		cfg.Mode = ModeVolume
+	}
+	return &Encoder{
+		Config: cfg,
+		Source: src,
+		Player: p,
+		Bus:    bus,
+		dec:    NewDecoder(cfg.StepsPerDetent, cfg.Reverse),
+		push:   button.NewDetector(button.Timing{}, false, false),
+	}
+}
+
+// Lines returns the gpio lines to open for cfg.
+func Lines(cfg Config) []int {
+	lines := []int{cfg.LineA, cfg.LineB}
+	if cfg.LineSW > 0 {
+		lines = append(lines, cfg.LineSW)
+	}
+	return lines
+}
+
+func (e *Encoder) Start() {
+	e.done = make(chan struct{})
+	go e.loop()
+}
+
+func (e *Encoder) Close() {
+	close(e.done)
+	e.Source.Close()
+}
+
+func (e *Encoder) loop() {
+	t := time.NewTicker(20 * time.Millisecond)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-e.done:
+			return
+		case ev, ok := <-e.Source.Edges():
+			if !ok {
+				return
+			}
+			e.Edge(ev)
+		case now := <-t.C:
+			e.Tick(now)
+		}
+	}
+}
+
+// Edge feeds one edge of line A, B or the push button.
+func (e *Encoder) Edge(ev gpio.Edge) {
+	switch ev.Line {
+	case e.LineA, e.LineB:
+		e.mu.Lock()
+		steps := 0
+		if dir := e.dec.Edge(ev.Line == e.LineA, ev.Rising); dir != 0 {
+			steps = e.accel.Steps(dir, ev.Time)
+		}
+		mode := e.Mode
+		e.mu.Unlock()
+
+		if steps != 0 {
+			e.turn(mode, steps)
+		}
+	case e.LineSW:
+		if e.LineSW <= 0 {
+			return
+		}
+		// active low like the buttons
+		e.mu.Lock()
+		presses := e.push.Edge(!ev.Rising, ev.Time)
+		e.mu.Unlock()
+		e.pressed(presses)
+	}
+}
+
+// Tick drives long-press detection of the push button.
+func (e *Encoder) Tick(now time.Time) {
+	e.mu.Lock()
+	presses := e.push.Tick(now)
+	e.mu.Unlock()
+	e.pressed(presses)
+}
+
+func (e *Encoder) CurrentMode() Mode {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.Mode
+}
+
+func (e *Encoder) turn(mode Mode, steps int) {
+	e.activity()
+	if mode == ModeSkip {
+		e.Player.Skip(steps * e.SkipSeconds)
+		return
+	}
+	e.Player.SetVolume(e.Player.Status().Volume + steps*e.VolumeStep)
+}
+
+func (e *Encoder) pressed(presses []button.Press) {
+	for _, p := range presses {
+		e.activity()
+		switch p {
+		case button.PressShort:
+			e.Player.Toggle()
+		case button.PressLong:
+			if e.ModeSwitch {
+				e.switchMode()
+			}
+		}
+	}
+}
+
+func (e *Encoder) switchMode() {
+	e.mu.Lock()
+	if e.Mode == ModeVolume {
+		e.Mode = ModeSkip
+	} else {
+		e.Mode = ModeVolume
+	}
+	mode := e.Mode
+	e.mu.Unlock()
+
+	if e.Bus != nil {
+		e.Bus.Publish("encoder.mode", map[string]any{"mode": mode})
+	}
+}
+
+func (e *Encoder) activity() {
+	if e.OnActivity != nil {
+		e.OnActivity()
+	}
+}
@@ -0,0 +1,90 @@
+package rotary
+
+import (
+	"testing"
+	"time"
+
+	"mupibox/internal/events"
+	"mupibox/internal/gpio"
+	"mupibox/internal/player"
+)
+
+func newTestEncoder(cfg Config) (*Encoder, *player.MemoryPlayer) {
+	p := player.NewMemoryPlayer()
+	p.Close()
+	p.Load(player.Media{ItemID: "bibi", AlbumID: "bibi_1", TrackCount: 3})
+	cfg.LineA, cfg.LineB = 17, 27
+	return NewEncoder(cfg, nil, p, events.NewBus()), p
+}
+
+// turn feeds detents to e as gpio edges 5ms apart, starting at t.
+func turn(e *Encoder, t time.Time, seqs ...[][2]bool) time.Time {
+	for _, seq := range seqs {
+		for _, s := range seq {
+			line := e.LineB
+			if s[0] {
+				line = e.LineA
+			}
+			t = t.Add(5 * time.Millisecond)
+			e.Edge(gpio.Edge{Line: line, Rising: s[1], Time: t})
+		}
+	}
+	return t
+}
+
+func TestEncoderVolume(t *testing.T) {
+	e, p := newTestEncoder(Config{VolumeStep: 5})
+	var activity int
+	e.OnActivity = func() { activity++ }
+	start := time.Date(2026, 1, 1, 7, 0, 0, 0, time.UTC)
+
+	// slow turns: one step per detent
+	t0 := turn(e, start, cw)
+	t0 = turn(e, t0.Add(time.Second), cw)
+	if v := p.Status().Volume; v != 50 {
+		t.Fatalf("volume = %d, want 50", v)
+	}
+
+	// fast turn back: the second detent comes 20ms after the first
+	turn(e, t0.Add(time.Second), ccw, ccw)
+	if v := p.Status().Volume; v != 25 {
+		t.Fatalf("volume = %d, want 25", v)
+	}
+	if activity != 4 {
+		t.Fatalf("activity = %d, want 4", activity)
+	}
+}
+
+func TestEncoderSkip(t *testing.T) {
+	e, p := newTestEncoder(Config{Mode: ModeSkip, SkipSeconds: 15})
+	p.Seek(60)
+	turn(e, time.Date(2026, 1, 1, 7, 0, 0, 0, time.UTC), ccw)
+	if st := p.Status(); st.Position != 45 || st.Volume != 40 {
+		t.Fatalf("position %d volume %d, want 45, unchanged volume", st.Position, st.Volume)
+	}
+}
+
+func TestEncoderPush(t *testing.T) {
+	e, p := newTestEncoder(Config{LineSW: 22, ModeSwitch: true})
+	start := time.Date(2026, 1, 1, 7, 0, 0, 0, time.UTC)
+
+	// short press (active low) toggles playback
+	e.Edge(gpio.Edge{Line: 22, Rising: false, Time: start})
+	e.Edge(gpio.Edge{Line: 22, Rising: true, Time: start.Add(100 * time.Millisecond)})
+	e.Tick(start.Add(200 * time.Millisecond))
+	if st := p.Status(); st.State != player.StatePlaying {
+		t.Fatalf("state = %s after a short press", st.State)
+	}
+
+	// long press switches to skip mode without touching playback
+	at := start.Add(2 * time.Second)
+	e.Edge(gpio.Edge{Line: 22, Rising: false, Time: at})
+	e.Tick(at.Add(time.Second))
+	e.Edge(gpio.Edge{Line: 22, Rising: true, Time: at.Add(1100 * time.Millisecond)})
+	if m := e.CurrentMode(); m != ModeSkip {
+		t.Fatalf("mode = %s after a long press", m)
+	}
+	if st := p.Status(); st.State != player.StatePlaying {
+		t.Fatalf("state = %s after a long press", st.State)
+	}
+}
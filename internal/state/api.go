@@ -0,0 +1,191 @@
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type API struct {
+	// Store returns the store to export from / import into.
+	Store func() *Store
+
+	// StoreFor returns the store of a profile for ?profile= (optional).
+	StoreFor func(profile string) (*Store, error)
+
+	// Orphan reports catalog items that no longer exist, for
+	// prune?orphans=1 (optional).
+	Orphan func(itemID string) bool
+}
+
+func NewAPI(store func() *Store) *API {
+	return &API{Store: store}
+}
+
+func (a *API) Register(mux *http.ServeMux) {
+	// Versioned JSON document (also used by peer sync); ?profile=ID
+	// selects the profile, default is the active one
+	mux.HandleFunc("/api/state/export", a.handleExport)
+
+	// POST /api/continue/prune?older_than_days=&item=&orphans=1&max_entries=
+	mux.HandleFunc("/api/continue/prune", a.handlePrune)
+}
+
+// RegisterAdmin adds the endpoints that change resume data wholesale. mux
+// is expected to be behind admin.Require.
+func (a *API) RegisterAdmin(mux *http.ServeMux) {
+	// Merge a document incl. tombstones; ?dry_run=1 only returns the diff
+	mux.HandleFunc("/api/admin/state/import", a.handleImport)
+
+	// POST /api/admin/continue/{key}/reset – von vorne, Verlauf bleibt
+	mux.HandleFunc("/api/admin/continue/", a.handleReset)
+}
+
+// store resolves ?profile=; it writes the error response if that fails.
+func (a *API) store(w http.ResponseWriter, r *http.Request) (*Store, string, bool) {
+	id := r.URL.Query().Get("profile")
+	if id == "" {
+		return a.Store(), "", true
+	}
+	if a.StoreFor == nil {
+		http.Error(w, "profiles not supported", http.StatusBadRequest)
+		return nil, "", false
+	}
+	st, err := a.StoreFor(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return nil, "", false
+	}
+	return st, id, true
+}
+
+func (a *API) handleExport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	st, id, ok := a.store(w, r)
+	if !ok {
+		return
+	}
+	if r.URL.Query().Get("download") == "1" {
+		w.Header().Set("Content-Disposition", `attachment; filename="mupibox-state.json"`)
+	}
+	doc := st.Export()
+	doc.Profile = id
+	writeJSON(w, doc)
+}
+
+func (a *API) handleImport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	st, _, ok := a.store(w, r)
+	if !ok {
+		return
+	}
+
+	var doc Document
+	if err := json.NewDecoder(r.Body).Decode(&doc); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+
+	dryRun := r.URL.Query().Get("dry_run") == "1"
+	diff, err := st.Merge(doc, dryRun)
+	if errors.Is(err, ErrUnsupportedVersion) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, diff)
+}
+
+func (a *API) handlePrune(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	st, _, ok := a.store(w, r)
+	if !ok {
+		return
+	}
+
+	q := r.URL.Query()
+	opts := PruneOptions{ItemID: q.Get("item")}
+	if v := q.Get("older_than_days"); v != "" {
+		days, err := strconv.Atoi(v)
+		if err != nil || days < 0 {
+			http.Error(w, "invalid int for older_than_days", http.StatusBadRequest)
+			return
+		}
+		opts.OlderThan = time.Duration(days) * 24 * time.Hour
+	}
+	if v := q.Get("max_entries"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			http.Error(w, "invalid int for max_entries", http.StatusBadRequest)
+			return
+		}
+		opts.MaxEntries = n
+	}
+	if q.Get("orphans") == "1" {
+		if a.Orphan == nil {
+			http.Error(w, "orphans not supported", http.StatusBadRequest)
+			return
+		}
+		opts.Orphan = a.Orphan
+	}
+	if opts.Empty() {
+		http.Error(w, "no prune criteria", http.StatusBadRequest)
+		return
+	}
+
+	removed, err := st.Prune(opts)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, map[string]any{"removed": removed})
+}
+
+func (a *API) handleReset(w http.ResponseWriter, r *http.Request) {
+	rest := strings.TrimPrefix(r.URL.Path, "/api/admin/continue/")
+	key := strings.TrimSuffix(rest, "/reset")
+	if key == rest || key == "" {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	st, _, ok := a.store(w, r)
+	if !ok {
+		return
+	}
+
+	found, err := st.Reset(key)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !found {
+		http.NotFound(w, r)
+		return
+	}
+	writeJSON(w, map[string]any{"ok": true})
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
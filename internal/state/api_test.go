@@ -0,0 +1,100 @@
+package state
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestImportIsAdminOnly(t *testing.T) {
+	s, err := NewStore(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Set("bibi_1", ResumeState{ItemID: "bibi", PositionSec: 10}); err != nil {
+		t.Fatal(err)
+	}
+	api := NewAPI(func() *Store { return s })
+	mux := http.NewServeMux()
+	api.Register(mux)
+	adminMux := http.NewServeMux()
+	api.RegisterAdmin(adminMux)
+
+	doc := `{"version":1,"entries":{},"deleted":{"bibi_1":"2999-01-01T00:00:00Z"}}`
+	post := func(h http.Handler, url string) int {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, url, strings.NewReader(doc)))
+		return rec.Code
+	}
+
+	if code := post(mux, "/api/state/import"); code != http.StatusNotFound {
+		t.Fatalf("public import: status %d, want 404", code)
+	}
+	if _, ok := s.Get("bibi_1"); !ok {
+		t.Fatal("public import deleted an entry")
+	}
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/state/export", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "bibi_1") {
+		t.Fatalf("export: %d %s", rec.Code, rec.Body)
+	}
+
+	if code := post(adminMux, "/api/admin/state/import"); code != http.StatusOK {
+		t.Fatalf("admin import: status %d", code)
+	}
+	if _, ok := s.Get("bibi_1"); ok {
+		t.Fatal("tombstone not applied")
+	}
+}
+
+func TestPruneAndResetAPI(t *testing.T) {
+	s, err := NewStore(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k, item := range map[string]string{"bibi_1": "bibi", "bibi_2": "bibi", "old_1": "old"} {
+		if err := s.Set(k, ResumeState{ItemID: item, PositionSec: 10}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	api := NewAPI(func() *Store { return s })
+	api.Orphan = func(id string) bool { return id == "old" }
+	mux := http.NewServeMux()
+	api.Register(mux)
+	adminMux := http.NewServeMux()
+	api.RegisterAdmin(adminMux)
+
+	tests := []struct {
+		name string
+		h    http.Handler
+		url  string
+		code int
+		body string
+	}{
+		{"prune without criteria", mux, "/api/continue/prune", http.StatusBadRequest, ""},
+		{"prune bad int", mux, "/api/continue/prune?max_entries=x", http.StatusBadRequest, ""},
+		{"prune orphans", mux, "/api/continue/prune?orphans=1", http.StatusOK, `{"removed":["old_1"]}`},
+		{"reset is admin only", mux, "/api/continue/bibi_1/reset", http.StatusNotFound, ""},
+		{"reset", adminMux, "/api/admin/continue/bibi_1/reset", http.StatusOK, `{"ok":true}`},
+		{"reset unknown", adminMux, "/api/admin/continue/nope/reset", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		tt.h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, tt.url, nil))
+		if rec.Code != tt.code {
+			t.Errorf("%s: status %d, want %d", tt.name, rec.Code, tt.code)
+		}
+		if tt.body != "" && strings.TrimSpace(rec.Body.String()) != tt.body {
+			t.Errorf("%s: body %s, want %s", tt.name, rec.Body, tt.body)
+		}
+	}
+	if st, _ := s.Get("bibi_1"); st.PositionSec != 0 {
+		t.Errorf("bibi_1 at %d after reset", st.PositionSec)
+	}
+	if st, _ := s.Get("bibi_2"); st.PositionSec != 10 {
+		t.Errorf("bibi_2 at %d, want it untouched", st.PositionSec)
+	}
+}
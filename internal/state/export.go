@@ -0,0 +1,134 @@
+package state
+
+import (
+	"errors"
+	"sort"
+	"time"
+)
+
+// ExportVersion is the version of the exchange document, independent of
+// the on-disk format.
+const ExportVersion = 1
+
+var ErrUnsupportedVersion = errors.New("unsupported export version")
+
+// Document is the versioned exchange format between boxes.
+type Document struct {
+	Version    int                    `json:"version"`
+	ExportedAt string                 `json:"exported_at"`       // RFC3339
+	Profile    string                 `json:"profile,omitempty"` // profile the entries belong to
+	Entries    map[string]ResumeState `json:"entries"`
+	Deleted    map[string]string      `json:"deleted,omitempty"` // key -> deleted at (RFC3339)
+}
+
+type ChangeAction string
+
+const (
+	ChangeAdd    ChangeAction = "add"    // key only exists remote
+	ChangeUpdate ChangeAction = "update" // remote is newer
+	ChangeDelete ChangeAction = "delete" // deleted remote after the local update
+)
+
+type Change struct {
+	Key       string       `json:"key"`
+	Action    ChangeAction `json:"action"`
+	Local     *ResumeState `json:"local,omitempty"`
+	Remote    ResumeState  `json:"remote"`
+	DeletedAt string       `json:"deleted_at,omitempty"` // ChangeDelete
+}
+
+// Diff describes what a merge changes (or would change on dry-run).
+type Diff struct {
+	DryRun    bool     `json:"dry_run"`
+	Changes   []Change `json:"changes"`
+	Unchanged int      `json:"unchanged"` // local is newer or equal
+}
+
+func (s *Store) Export() Document {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries := make(map[string]ResumeState, len(s.data))
+	for k, v := range s.data {
+		entries[k] = v
+	}
+	deleted := make(map[string]string, len(s.deleted))
+	for k, v := range s.deleted {
+		deleted[k] = v
+	}
+	return Document{
+		Version:    ExportVersion,
+		ExportedAt: s.now().UTC().Format(time.RFC3339),
+		Entries:    entries,
+		Deleted:    deleted,
+	}
+}
+
+// Merge merges doc per key; the entry with the newest UpdatedAt wins.
+// Deletions count like updates at their deletion time, on both sides.
+// Remote timestamps are kept, so merging back and forth is stable.
+func (s *Store) Merge(doc Document, dryRun bool) (Diff, error) {
+	if doc.Version < 1 || doc.Version > ExportVersion {
+		return Diff{}, ErrUnsupportedVersion
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.disabled != nil {
+		return Diff{}, s.disabled
+	}
+	diff := Diff{DryRun: dryRun, Changes: []Change{}}
+	for k, remote := range doc.Entries {
+		local, ok := s.data[k]
+		switch {
+		case !ok && s.deletedAfterLocked(k, remote.UpdatedAt):
+			diff.Unchanged++
+		case !ok:
+			diff.Changes = append(diff.Changes, Change{Key: k, Action: ChangeAdd, Remote: remote})
+		case parseTime(remote.UpdatedAt).After(parseTime(local.UpdatedAt)):
+			l := local
+			diff.Changes = append(diff.Changes, Change{Key: k, Action: ChangeUpdate, Local: &l, Remote: remote})
+		default:
+			diff.Unchanged++
+		}
+	}
+	newTombstones := map[string]string{}
+	for k, at := range doc.Deleted {
+		if _, ok := doc.Entries[k]; ok {
+			continue // re-added on the peer, handled above
+		}
+		local, ok := s.data[k]
+		if ok && !parseTime(at).Before(parseTime(local.UpdatedAt)) {
+			l := local
+			diff.Changes = append(diff.Changes, Change{Key: k, Action: ChangeDelete, Local: &l, DeletedAt: at})
+		} else if !ok && !s.deletedAfterLocked(k, at) {
+			newTombstones[k] = at
+		}
+	}
+	sort.Slice(diff.Changes, func(i, j int) bool { return diff.Changes[i].Key < diff.Changes[j].Key })
+
+	if dryRun || (len(diff.Changes) == 0 && len(newTombstones) == 0) {
+		return diff, nil
+	}
+	for _, c := range diff.Changes {
+		if c.Action == ChangeDelete {
+			s.deleteLocked(c.Key, parseTime(c.DeletedAt))
+			continue
+		}
+		s.data[c.Key] = c.Remote
+		delete(s.deleted, c.Key)
+	}
+	// keep the peer's tombstones, so a third box can't bring them back
+	for k, at := range newTombstones {
+		s.deleted[k] = at
+	}
+	return diff, s.persist()
+}
+
+// deletedAfterLocked reports whether key was deleted locally at or after
+// the time updatedAt.
+func (s *Store) deletedAfterLocked(key, updatedAt string) bool {
+	at, ok := s.deleted[key]
+	return ok && !parseTime(at).Before(parseTime(updatedAt))
+}
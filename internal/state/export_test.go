@@ -0,0 +1,116 @@
+package state
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func ts(t time.Time) string { return t.UTC().Format(time.RFC3339) }
+
+func TestMergeTombstones(t *testing.T) {
+	now := time.Now()
+	older, newer := ts(now.Add(-time.Hour)), ts(now.Add(time.Hour))
+
+	tests := []struct {
+		name     string
+		local    map[string]ResumeState
+		deleted  map[string]string
+		doc      Document
+		action   ChangeAction // "" = no change
+		wantKept bool
+	}{
+		{
+			name:    "deleted locally, older remote copy stays deleted",
+			deleted: map[string]string{"a": ts(now)},
+			doc:     Document{Entries: map[string]ResumeState{"a": {PositionSec: 5, UpdatedAt: older}}},
+		},
+		{
+			name:     "deleted locally, listened again on the peer",
+			deleted:  map[string]string{"a": ts(now)},
+			doc:      Document{Entries: map[string]ResumeState{"a": {PositionSec: 5, UpdatedAt: newer}}},
+			action:   ChangeAdd,
+			wantKept: true,
+		},
+		{
+			name:   "deleted on the peer after the local update",
+			local:  map[string]ResumeState{"a": {PositionSec: 5, UpdatedAt: older}},
+			doc:    Document{Deleted: map[string]string{"a": ts(now)}},
+			action: ChangeDelete,
+		},
+		{
+			name:     "deleted on the peer before the local update",
+			local:    map[string]ResumeState{"a": {PositionSec: 5, UpdatedAt: newer}},
+			doc:      Document{Deleted: map[string]string{"a": ts(now)}},
+			wantKept: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewStore(filepath.Join(t.TempDir(), "state.json"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for k, v := range tt.local {
+				s.data[k] = v
+			}
+			for k, v := range tt.deleted {
+				s.deleted[k] = v
+			}
+
+			tt.doc.Version = ExportVersion
+			diff, err := s.Merge(tt.doc, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got ChangeAction
+			if len(diff.Changes) == 1 {
+				got = diff.Changes[0].Action
+			} else if len(diff.Changes) > 1 {
+				t.Fatalf("%d changes", len(diff.Changes))
+			}
+			if got != tt.action {
+				t.Errorf("action = %q, want %q", got, tt.action)
+			}
+			if _, ok := s.Get("a"); ok != tt.wantKept {
+				t.Errorf("entry kept = %v, want %v", ok, tt.wantKept)
+			}
+		})
+	}
+}
+
+func TestDeleteSticksAcrossSync(t *testing.T) {
+	dir := t.TempDir()
+	a, _ := NewStore(filepath.Join(dir, "a.json"))
+	b, _ := NewStore(filepath.Join(dir, "b.json"))
+
+	mustSet(t, a, "album", ResumeState{PositionSec: 30})
+	if _, err := b.Merge(a.Export(), false); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := b.Delete("album"); !ok || err != nil {
+		t.Fatalf("delete: %v %v", ok, err)
+	}
+
+	// b pulls from a: the deleted entry must not come back
+	if _, err := b.Merge(a.Export(), false); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := b.Get("album"); ok {
+		t.Fatal("deleted entry came back from the peer")
+	}
+
+	// a pulls from b: the deletion reaches a
+	if _, err := a.Merge(b.Export(), false); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := a.Get("album"); ok {
+		t.Fatal("deletion was not synced to the peer")
+	}
+
+	// tombstones survive a restart
+	b2, _ := NewStore(filepath.Join(dir, "b.json"))
+	if _, ok := b2.deleted["album"]; !ok {
+		t.Fatal("tombstone not persisted")
+	}
+}
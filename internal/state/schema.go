@@ -0,0 +1,119 @@
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"mupibox/internal/atomicfile"
+)
+
+// SchemaVersion is the version of the on-disk state file.
+//
+//	1: bare map keyed by album id (no envelope)
+//	2: envelope {"schema_version": 2, "entries": {...}}
+const SchemaVersion = 2
+
+var ErrNewerSchema = errors.New("state file was written by a newer version")
+
+type envelope struct {
+	SchemaVersion int                    `json:"schema_version"`
+	Entries       map[string]ResumeState `json:"entries"`
+
+	// deleted keys -> deletion time (RFC3339), so peer sync doesn't
+	// bring them back
+	Deleted map[string]string `json:"deleted,omitempty"`
+}
+
+// migrations[v] upgrades a raw file from version v to v+1.
+var migrations = map[int]func(raw []byte) ([]byte, error){
+	1: migrateV1,
+}
+
+// decodeFile reads a state file of any known version. Older files are
+// upgraded step by step; before each step the current file is copied to
+// <path>.v<N>.bak so users can roll back.
+func decodeFile(path string, raw []byte) (envelope, error) {
+	v, err := detectVersion(raw)
+	if err != nil {
+		return envelope{}, err
+	}
+	if v > SchemaVersion {
+		return envelope{}, fmt.Errorf("%s: schema %d > %d: %w", path, v, SchemaVersion, ErrNewerSchema)
+	}
+
+	migrated := false
+	for ; v < SchemaVersion; v++ {
+		mig, ok := migrations[v]
+		if !ok {
+			return envelope{}, fmt.Errorf("%s: no migration from schema %d", path, v)
+		}
+		if err := os.WriteFile(fmt.Sprintf("%s.v%d.bak", path, v), raw, 0644); err != nil {
+			return envelope{}, err
+		}
+		if raw, err = mig(raw); err != nil {
+			return envelope{}, fmt.Errorf("%s: migrate schema %d: %w", path, v, err)
+		}
+		migrated = true
+	}
+
+	env, err := decodeEnvelope(raw)
+	if err != nil {
+		return envelope{}, err
+	}
+
+	if migrated {
+		if err := atomicfile.Write(path, raw); err != nil {
+			return envelope{}, err
+		}
+	}
+	return env, nil
+}
+
+// decodeEnvelope reads a current file. For files written by a newer
+// version it returns what this version understands, unknown fields are
+// ignored.
+func decodeEnvelope(raw []byte) (envelope, error) {
+	var env envelope
+	if err := json.Unmarshal(raw, &env); err != nil {
+		return envelope{}, err
+	}
+	if env.Entries == nil {
+		env.Entries = map[string]ResumeState{}
+	}
+	if env.Deleted == nil {
+		env.Deleted = map[string]string{}
+	}
+	return env, nil
+}
+
+func detectVersion(raw []byte) (int, error) {
+	var probe map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &probe); err != nil {
+		return 0, err
+	}
+	v, ok := probe["schema_version"]
+	if !ok {
+		return 1, nil
+	}
+	var n int
+	if err := json.Unmarshal(v, &n); err != nil {
+		return 0, fmt.Errorf("invalid schema_version: %w", err)
+	}
+	return n, nil
+}
+
+func encodeFile(data map[string]ResumeState, deleted map[string]string) []byte {
+	raw, _ := json.MarshalIndent(envelope{SchemaVersion: SchemaVersion, Entries: data, Deleted: deleted}, "", "  ")
+	return raw
+}
+
+// v1 -> v2: wrap the bare map in the envelope.
+func migrateV1(raw []byte) ([]byte, error) {
+	var entries map[string]ResumeState
+	if err := json.Unmarshal(raw, &entries); err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(envelope{SchemaVersion: 2, Entries: entries}, "", "  ")
+}
@@ -1,11 +1,14 @@
 package state
 
 import (
-	"encoding/json"
+	"errors"
+	"log"
 	"os"
 	"sort"
 	"sync"
 	"time"
+
+	"mupibox/internal/atomicfile"
 )
 
 type ResumeState struct {
@@ -18,6 +21,14 @@ type ResumeState struct {
 	TrackIndex  int    `json:"track_index,omitempty"` // 0-based
 	PositionSec int    `json:"position_sec"`
 
+	// Wiedergabegeschwindigkeit für dieses Hörbuch, 0 = Profil-Standard
+	Speed float64 `json:"speed,omitempty"`
+
+	// Zufallswiedergabe: Reihenfolge beim Fortsetzen beibehalten.
+	// ShuffleSeed 0 = alter Eintrag, dann gilt play_behavior des Katalogs.
+	Shuffle     bool  `json:"shuffle,omitempty"`
+	ShuffleSeed int64 `json:"shuffle_seed,omitempty"`
+
 	// Sortierung "Weiter abspielen"
 	UpdatedAt string `json:"updated_at,omitempty"` // RFC3339
 }
@@ -27,26 +38,79 @@ type Entry struct {
 	State ResumeState
 }
 
+// TombstoneTTL is how long deleted keys are remembered for peer sync.
+const TombstoneTTL = 180 * 24 * time.Hour
+
 type Store struct {
-	path string
-	mu   sync.Mutex
-	data map[string]ResumeState
+	path    string
+	mu      sync.Mutex
+	data    map[string]ResumeState
+	deleted map[string]string // key -> deleted at (RFC3339)
+
+	// memoryOnly: the file belongs to a newer version, changes are not
+	// written back
+	memoryOnly bool
+
+	// disabled: set by Disabled, every change fails with it
+	disabled error
+
+	now func() time.Time
+}
+
+// Disabled returns an empty store that refuses every change with err.
+// It stands in for a store that can't be opened, so writes fail instead
+// of ending up somewhere else.
+func Disabled(err error) *Store {
+	return &Store{
+		data:     map[string]ResumeState{},
+		deleted:  map[string]string{},
+		disabled: err,
+		now:      time.Now,
+	}
 }
 
 func NewStore(path string) (*Store, error) {
 	s := &Store{
-		path: path,
-		data: map[string]ResumeState{},
+		path:    path,
+		data:    map[string]ResumeState{},
+		deleted: map[string]string{},
+		now:     time.Now,
 	}
 
 	raw, err := os.ReadFile(path)
 	if err == nil {
-		_ = json.Unmarshal(raw, &s.data)
+		// migriert alte Formate; neuere Versionen werden nie überschrieben
+		env, derr := decodeFile(path, raw)
+		switch {
+		case derr == nil:
+			s.data = env.Entries
+			s.deleted = env.Deleted
+		case errors.Is(derr, ErrNewerSchema):
+			// after a downgrade: the file stays as it is for the next
+			// upgrade; entries this version understands are used, changes
+			// stay in memory
+			log.Printf("state: %v; using it read-only", derr)
+			s.memoryOnly = true
+			if env, err := decodeEnvelope(raw); err == nil {
+				s.data = env.Entries
+				s.deleted = env.Deleted
+			}
+		default:
+			// a broken file must not keep the box from booting: keep it
+			// for inspection and start empty
+			aside := path + ".corrupt"
+			log.Printf("state: %v; moving it to %s", derr, aside)
+			if err = os.Rename(path, aside); err == nil {
+				err = os.ErrNotExist // write a fresh file below
+			} else {
+				log.Printf("state: %v", err)
+			}
+		}
 	}
 
 	// wenn Datei nicht existiert: anlegen
-	if os.IsNotExist(err) {
-		_ = os.WriteFile(path, []byte("{}"), 0644)
+	if errors.Is(err, os.ErrNotExist) {
+		_ = s.persist()
 	}
 
 	return s, nil
@@ -63,8 +127,12 @@ func (s *Store) Set(key string, st ResumeState) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	st.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+	if s.disabled != nil {
+		return s.disabled
+	}
+	st.UpdatedAt = s.now().UTC().Format(time.RFC3339)
 	s.data[key] = st
+	delete(s.deleted, key)
 	return s.persist()
 }
 
@@ -102,7 +170,145 @@ func parseTime(s string) time.Time {
 	return t
 }
 
+// Flush writes the store and syncs it to disk. Use it before power-off.
+func (s *Store) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.disabled != nil {
+		return s.disabled
+	}
+	return s.persist()
+}
+
+// persist replaces the file atomically, so a power cut mid-write keeps
+// the old one: write a temp file, fsync, rename.
 func (s *Store) persist() error {
-	raw, _ := json.MarshalIndent(s.data, "", "  ")
-	return os.WriteFile(s.path, raw, 0644)
+	if s.memoryOnly {
+		return nil
+	}
+	return atomicfile.Write(s.path, encodeFile(s.data, s.deleted))
+}
+
+// Delete removes an entry; it reports whether it existed. The key is
+// remembered as deleted, so a merge doesn't bring back an older copy.
+func (s *Store) Delete(key string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.disabled != nil {
+		return false, s.disabled
+	}
+	if _, ok := s.data[key]; !ok {
+		return false, nil
+	}
+	s.deleteLocked(key, s.now())
+	return true, s.persist()
+}
+
+func (s *Store) deleteLocked(key string, at time.Time) {
+	delete(s.data, key)
+	s.deleted[key] = at.UTC().Format(time.RFC3339)
+}
+
+// Reset rewinds an entry to the beginning but keeps its context, so the
+// item starts from scratch without losing the listening history.
+func (s *Store) Reset(key string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.disabled != nil {
+		return false, s.disabled
+	}
+	st, ok := s.data[key]
+	if !ok {
+		return false, nil
+	}
+	st.TrackID = ""
+	st.TrackIndex = 0
+	st.PositionSec = 0
+	st.UpdatedAt = s.now().UTC().Format(time.RFC3339)
+	s.data[key] = st
+	return true, s.persist()
+}
+
+// PruneOptions select entries to drop. An entry is removed if any of the
+// set criteria match; MaxEntries is applied to what remains.
+type PruneOptions struct {
+	OlderThan  time.Duration            // not updated for longer than this
+	ItemID     string                   // all entries of this catalog item
+	Orphan     func(itemID string) bool // true if the item no longer exists
+	MaxEntries int                      // keep only the newest N
+}
+
+// Empty reports whether no criteria are set.
+func (o PruneOptions) Empty() bool {
+	return o.OlderThan <= 0 && o.ItemID == "" && o.Orphan == nil && o.MaxEntries <= 0
+}
+
+// Prune removes matching entries and returns their keys.
+func (s *Store) Prune(opts PruneOptions) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.disabled != nil {
+		return nil, s.disabled
+	}
+	now := s.now()
+	removed := []string{}
+	for k, v := range s.data {
+		drop := false
+		if opts.OlderThan > 0 {
+			// ohne Zeitstempel gilt der Eintrag als alt
+			drop = now.Sub(parseTime(v.UpdatedAt)) > opts.OlderThan
+		}
+		if opts.ItemID != "" && v.ItemID == opts.ItemID {
+			drop = true
+		}
+		if opts.Orphan != nil && v.ItemID != "" && opts.Orphan(v.ItemID) {
+			drop = true
+		}
+		if drop {
+			s.deleteLocked(k, now)
+			removed = append(removed, k)
+		}
+	}
+
+	if opts.MaxEntries > 0 && len(s.data) > opts.MaxEntries {
+		keys := make([]string, 0, len(s.data))
+		for k := range s.data {
+			keys = append(keys, k)
+		}
+		// newest first; on equal times the smaller key stays, so the
+		// result doesn't depend on map order
+		sort.Slice(keys, func(i, j int) bool {
+			ti, tj := parseTime(s.data[keys[i]].UpdatedAt), parseTime(s.data[keys[j]].UpdatedAt)
+			if !ti.Equal(tj) {
+				return ti.After(tj)
+			}
+			return keys[i] < keys[j]
+		})
+		for _, k := range keys[opts.MaxEntries:] {
+			s.deleteLocked(k, now)
+			removed = append(removed, k)
+		}
+	}
+
+	// forget old tombstones; peers have long pruned or synced them
+	expired := false
+	for k, at := range s.deleted {
+		if now.Sub(parseTime(at)) > TombstoneTTL {
+			delete(s.deleted, k)
+			expired = true
+		}
+	}
+
+	if len(removed) == 0 {
+		if expired {
+			return removed, s.persist()
+		}
+		return removed, nil
+	}
+	sort.Strings(removed)
+	return removed, s.persist()
 }
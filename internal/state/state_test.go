@@ -0,0 +1,228 @@
+package state
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestNewStoreBrokenFile(t *testing.T) {
+	tests := []struct {
+		name  string
+		raw   string
+		aside string
+	}{
+		{"empty", "", ".corrupt"},
+		{"truncated", `{"schema_version": 2, "entries": {"a": {"posi`, ".corrupt"},
+		{"garbage", "\x00\x00\x00", ".corrupt"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "state.json")
+			if err := os.WriteFile(path, []byte(tt.raw), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			s, err := NewStore(path)
+			if err != nil {
+				t.Fatalf("NewStore: %v", err)
+			}
+			if n := len(s.ListRecent(0)); n != 0 {
+				t.Errorf("%d entries, want an empty store", n)
+			}
+
+			kept, err := os.ReadFile(path + tt.aside)
+			if err != nil {
+				t.Fatalf("moved file: %v", err)
+			}
+			if string(kept) != tt.raw {
+				t.Errorf("moved file = %q, want the original", kept)
+			}
+
+			// the fresh file must load without complaints
+			if err := os.Remove(path + tt.aside); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := NewStore(path); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := os.Stat(path + tt.aside); !os.IsNotExist(err) {
+				t.Errorf("fresh file was moved aside again")
+			}
+		})
+	}
+}
+
+func TestNewStoreNewerSchema(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	newer := `{"schema_version": 3, "entries": {"bibi_1": {"item_id": "bibi", "position_sec": 42, "chapter": 7}}, "profiles": {}}`
+	if err := os.WriteFile(path, []byte(newer), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := NewStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st, ok := s.Get("bibi_1"); !ok || st.PositionSec != 42 {
+		t.Fatalf("bibi_1 = %+v, %v; want the entry of the newer file", st, ok)
+	}
+
+	// playing on the old version must not touch the file
+	mustSet(t, s, "tkkg_1", ResumeState{ItemID: "tkkg", PositionSec: 5})
+	if ok, err := s.Delete("bibi_1"); !ok || err != nil {
+		t.Fatalf("Delete = %v, %v", ok, err)
+	}
+	if err := s.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if got := string(mustRead(t, path)); got != newer {
+		t.Fatalf("file = %s, want it unchanged", got)
+	}
+	if _, err := os.Stat(path + ".newer"); !os.IsNotExist(err) {
+		t.Errorf("file moved aside: %v", err)
+	}
+
+	// the next start (or the upgrade) still finds it
+	if st, ok := mustLoad(t, path).Get("bibi_1"); !ok || st.PositionSec != 42 {
+		t.Fatalf("after restart: bibi_1 = %+v, %v", st, ok)
+	}
+}
+
+func TestNewStoreMigratesV1(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "state.json")
+	v1 := `{"album_1": {"item_id": "bibi", "position_sec": 42}}`
+	if err := os.WriteFile(path, []byte(v1), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := NewStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st, ok := s.Get("album_1"); !ok || st.PositionSec != 42 {
+		t.Fatalf("album_1 = %+v, %v", st, ok)
+	}
+	if bak, err := os.ReadFile(path + ".v1.bak"); err != nil || string(bak) != v1 {
+		t.Fatalf("backup = %q, %v", bak, err)
+	}
+	if v, err := detectVersion(mustRead(t, path)); err != nil || v != SchemaVersion {
+		t.Fatalf("version after migration = %d, %v", v, err)
+	}
+}
+
+func mustRead(t *testing.T, path string) []byte {
+	t.Helper()
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+func TestPersistAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "state.json")
+	s, err := NewStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a stale temp file from an earlier power cut doesn't matter
+	if err := os.WriteFile(path+".tmp", []byte("{half"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	mustSet(t, s, "a", ResumeState{PositionSec: 7})
+
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("temp file left behind: %v", err)
+	}
+	if st, ok := mustLoad(t, path).Get("a"); !ok || st.PositionSec != 7 {
+		t.Fatalf("a = %+v, %v", st, ok)
+	}
+
+	// the old file stays in place when the write fails
+	if err := os.Mkdir(path+".tmp", 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Set("b", ResumeState{PositionSec: 1}); err == nil {
+		t.Fatal("expected an error")
+	}
+	if _, ok := mustLoad(t, path).Get("a"); !ok {
+		t.Fatal("old file lost")
+	}
+}
+
+func mustLoad(t *testing.T, path string) *Store {
+	t.Helper()
+	s, err := NewStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestPrune(t *testing.T) {
+	now := time.Date(2026, 3, 14, 19, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+	at := func(age time.Duration) string { return ts(now.Add(-age)) }
+	entries := map[string]ResumeState{
+		"limit": {ItemID: "bibi", PositionSec: 1, UpdatedAt: at(30 * day)},
+		"older": {ItemID: "bibi", PositionSec: 1, UpdatedAt: at(30*day + time.Second)},
+		"new":   {ItemID: "tkkg", PositionSec: 1, UpdatedAt: at(time.Hour)},
+		"tie_a": {ItemID: "tkkg", PositionSec: 1, UpdatedAt: at(2 * day)},
+		"tie_b": {ItemID: "tkkg", PositionSec: 1, UpdatedAt: at(2 * day)},
+		"no_ts": {ItemID: "gone", PositionSec: 1},
+	}
+
+	tests := []struct {
+		name    string
+		opts    PruneOptions
+		removed []string
+	}{
+		{"age: exactly at the limit stays", PruneOptions{OlderThan: 30 * day}, []string{"no_ts", "older"}},
+		{"count: ties keep the smaller key", PruneOptions{MaxEntries: 2}, []string{"limit", "no_ts", "older", "tie_b"}},
+		{"count: all ties kept", PruneOptions{MaxEntries: 3}, []string{"limit", "no_ts", "older"}},
+		{"age and count", PruneOptions{OlderThan: 30 * day, MaxEntries: 3}, []string{"limit", "no_ts", "older"}},
+		{"age and count, count first to bite", PruneOptions{OlderThan: 30 * day, MaxEntries: 1}, []string{"limit", "no_ts", "older", "tie_a", "tie_b"}},
+		{"item", PruneOptions{ItemID: "bibi"}, []string{"limit", "older"}},
+		{"orphans", PruneOptions{Orphan: func(id string) bool { return id == "gone" }}, []string{"no_ts"}},
+		{"no timestamp counts as old", PruneOptions{OlderThan: 365 * day}, []string{"no_ts"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := mustLoad(t, filepath.Join(t.TempDir(), "state.json"))
+			s.now = func() time.Time { return now }
+			for k, v := range entries {
+				s.data[k] = v
+			}
+
+			removed, err := s.Prune(tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			sort.Strings(removed)
+			if !reflect.DeepEqual(removed, tt.removed) {
+				t.Fatalf("removed %q, want %q", removed, tt.removed)
+			}
+
+			// pruned entries get tombstones, so a peer doesn't bring them back
+			reloaded := mustLoad(t, s.path)
+			for _, k := range tt.removed {
+				if _, ok := reloaded.Get(k); ok {
+					t.Errorf("%s still stored", k)
+				}
+				if reloaded.deleted[k] != ts(now) {
+					t.Errorf("%s: tombstone %q, want %q", k, reloaded.deleted[k], ts(now))
+				}
+			}
+			if n := len(reloaded.data) + len(tt.removed); n != len(entries) {
+				t.Errorf("%d entries left, want %d", len(reloaded.data), len(entries)-len(tt.removed))
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+var errNoProfile = errors.New("profile not on peer")
+
+// Syncer periodically pulls the export of a peer box and merges it. Each
+// profile is synced with the profile of the same id on the peer, so the
+// resume states of different children never mix.
+type Syncer struct {
+	// Profiles lists the local profile ids to sync.
+	Profiles func() []string
+	// StoreFor returns the local store of a profile.
+	StoreFor func(profile string) (*Store, error)
+
+	Peer   string // base URL, e.g. http://mupibox-kinderzimmer.local:8080
+	Client *http.Client
+
+	ticker *time.Ticker
+	done   chan struct{}
+}
+
+func NewSyncer(profiles func() []string, storeFor func(string) (*Store, error), peer string) *Syncer {
+	return &Syncer{
+		Profiles: profiles,
+		StoreFor: storeFor,
+		Peer:     strings.TrimRight(peer, "/"),
+		Client:   &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Start pulls once right away and then on every interval.
+func (y *Syncer) Start(interval time.Duration) {
+	if interval <= 0 {
+		interval = 5 * time.Minute
+	}
+	y.ticker = time.NewTicker(interval)
+	y.done = make(chan struct{})
+	go y.loop()
+}
+
+func (y *Syncer) Close() {
+	close(y.done)
+	y.ticker.Stop()
+}
+
+func (y *Syncer) loop() {
+	y.logPull()
+	for {
+		select {
+		case <-y.done:
+			return
+		case <-y.ticker.C:
+			y.logPull()
+		}
+	}
+}
+
+func (y *Syncer) logPull() {
+	diffs, err := y.Pull()
+	if err != nil {
+		log.Println("state sync:", err)
+	}
+	for id, diff := range diffs {
+		if len(diff.Changes) > 0 {
+			log.Printf("state sync: %d entries of profile %s from %s", len(diff.Changes), id, y.Peer)
+		}
+	}
+}
+
+// Pull merges the peer's export of every local profile into that
+// profile's store. Profiles the peer doesn't have are skipped; the first
+// other error is returned after all profiles were tried.
+func (y *Syncer) Pull() (map[string]Diff, error) {
+	diffs := map[string]Diff{}
+	var first error
+	for _, id := range y.Profiles() {
+		diff, err := y.pullProfile(id)
+		if errors.Is(err, errNoProfile) {
+			continue
+		}
+		if err != nil {
+			if first == nil {
+				first = fmt.Errorf("profile %s: %w", id, err)
+			}
+			continue
+		}
+		diffs[id] = diff
+	}
+	return diffs, first
+}
+
+func (y *Syncer) pullProfile(id string) (Diff, error) {
+	resp, err := y.Client.Get(y.Peer + "/api/state/export?profile=" + url.QueryEscape(id))
+	if err != nil {
+		return Diff{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return Diff{}, errNoProfile
+	}
+	if resp.StatusCode != http.StatusOK {
+		return Diff{}, fmt.Errorf("peer %s: %s", y.Peer, resp.Status)
+	}
+
+	var doc Document
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return Diff{}, err
+	}
+	// older peers ignore ?profile= and send their active profile
+	if doc.Profile != id {
+		return Diff{}, fmt.Errorf("peer %s does not support per-profile sync", y.Peer)
+	}
+
+	st, err := y.StoreFor(id)
+	if err != nil {
+		return Diff{}, err
+	}
+	return st.Merge(doc, false)
+}
@@ -0,0 +1,130 @@
+package state
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+)
+
+// profileStores is a minimal profile manager for the tests.
+type profileStores map[string]*Store
+
+func (p profileStores) ids() []string {
+	ids := make([]string, 0, len(p))
+	for id := range p {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func (p profileStores) storeFor(id string) (*Store, error) {
+	if st, ok := p[id]; ok {
+		return st, nil
+	}
+	return nil, errors.New("profile not found")
+}
+
+func newStores(t *testing.T, ids ...string) profileStores {
+	t.Helper()
+	dir := t.TempDir()
+	p := profileStores{}
+	for _, id := range ids {
+		st, err := NewStore(filepath.Join(dir, id+".json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		p[id] = st
+	}
+	return p
+}
+
+func newPeer(t *testing.T, p profileStores, active string) *httptest.Server {
+	t.Helper()
+	api := NewAPI(func() *Store { return p[active] })
+	api.StoreFor = p.storeFor
+	mux := http.NewServeMux()
+	api.Register(mux)
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestSyncPerProfile(t *testing.T) {
+	peer := newStores(t, "default", "lena", "tom")
+	mustSet(t, peer["lena"], "album_lena", ResumeState{ItemID: "bibi", PositionSec: 10})
+	mustSet(t, peer["tom"], "album_tom", ResumeState{ItemID: "tkkg", PositionSec: 20})
+	// the peer's active profile must not matter
+	srv := newPeer(t, peer, "tom")
+
+	local := newStores(t, "default", "lena", "paul")
+	y := NewSyncer(local.ids, local.storeFor, srv.URL+"/")
+
+	diffs, err := y.Pull()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := diffs["paul"]; ok {
+		t.Error("paul does not exist on the peer and should be skipped")
+	}
+	if n := len(diffs["lena"].Changes); n != 1 {
+		t.Errorf("lena: %d changes, want 1", n)
+	}
+	if _, ok := local["lena"].Get("album_lena"); !ok {
+		t.Error("lena: entry missing")
+	}
+	if _, ok := local["lena"].Get("album_tom"); ok {
+		t.Error("lena got tom's entry")
+	}
+	if _, ok := local["default"].Get("album_tom"); ok {
+		t.Error("default got tom's entry")
+	}
+}
+
+func TestSyncOldPeer(t *testing.T) {
+	// a peer without ?profile= support exports its active profile
+	peer := newStores(t, "default")
+	mustSet(t, peer["default"], "album", ResumeState{PositionSec: 5})
+	mux := http.NewServeMux()
+	NewAPI(func() *Store { return peer["default"] }).Register(mux)
+	old := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.URL.RawQuery = ""
+		mux.ServeHTTP(w, r)
+	})
+	srv := httptest.NewServer(old)
+	defer srv.Close()
+
+	local := newStores(t, "default", "lena")
+	_, err := NewSyncer(local.ids, local.storeFor, srv.URL).Pull()
+	if err == nil {
+		t.Fatal("expected an error for a peer without per-profile sync")
+	}
+	for id, st := range local {
+		if _, ok := st.Get("album"); ok {
+			t.Errorf("%s: entry of an unknown profile was merged", id)
+		}
+	}
+}
+
+func TestSyncPeerDown(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+
+	local := newStores(t, "default")
+	y := NewSyncer(local.ids, local.storeFor, srv.URL)
+	y.Client.Timeout = time.Second
+	if _, err := y.Pull(); err == nil {
+		t.Fatal("expected an error")
+	}
+}
+
+func mustSet(t *testing.T, st *Store, key string, rs ResumeState) {
+	t.Helper()
+	if err := st.Set(key, rs); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,42 @@
+package status
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+type API struct {
+	S *Service
+}
+
+func NewAPI(s *Service) *API {
+	return &API{S: s}
+}
+
+func (a *API) Register(mux *http.ServeMux) {
+	mux.HandleFunc("/api/status", a.handleStatus)
+
+	// Health only (temperature, load, memory, disk, throttling)
+	mux.HandleFunc("/api/system", a.handleSystem)
+}
+
+func (a *API) handleStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	writeJSON(w, a.S.Status())
+}
+
+func (a *API) handleSystem(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	writeJSON(w, a.S.Status().System)
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,155 @@
+package status
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// batteryAlpha is the weight of a new capacity reading. Cheap fuel gauges
+// jump by several percent under load; smoothing keeps the icon calm.
+const batteryAlpha = 0.3
+
+// BatteryProvider reads the first battery below
+// <Root>/sys/class/power_supply. Root is "/" on the box and a fake sysfs
+// tree in tests.
+type BatteryProvider struct {
+	Root string
+
+	mu       sync.Mutex
+	smoothed float64
+	have     bool
+	charging bool
+}
+
+func NewBatteryProvider(root string) *BatteryProvider {
+	return &BatteryProvider{Root: root}
+}
+
+// Read returns the current, smoothed battery state. Without a battery it
+// returns Battery{Present: false} and no error.
+func (b *BatteryProvider) Read() (Battery, error) {
+	dir, err := b.findBattery()
+	if err != nil || dir == "" {
+		return Battery{}, err
+	}
+
+	capacity, err := readInt(filepath.Join(dir, "capacity"))
+	if err != nil {
+		return Battery{}, err
+	}
+	state, _ := readString(filepath.Join(dir, "status"))
+	voltage, _ := readInt(filepath.Join(dir, "voltage_now")) // µV
+
+	charging := state == "Charging" || state == "Full"
+
+	b.mu.Lock()
+	// on plug/unplug the level really changes direction: start over
+	if !b.have || charging != b.charging {
+		b.smoothed = float64(capacity)
+		b.have = true
+		b.charging = charging
+	} else {
+		b.smoothed += batteryAlpha * (float64(capacity) - b.smoothed)
+	}
+	percent := int(b.smoothed + 0.5)
+	b.mu.Unlock()
+
+	if percent < 0 {
+		percent = 0
+	}
+	if percent > 100 {
+		percent = 100
+	}
+
+	bat := Battery{
+		Present:   true,
+		Percent:   percent,
+		Charging:  charging,
+		State:     state,
+		VoltageMV: voltage / 1000,
+	}
+	bat.Icon = BatteryIcon(bat)
+	return bat, nil
+}
+
+func (b *BatteryProvider) findBattery() (string, error) {
+	base := filepath.Join(b.Root, "sys/class/power_supply")
+	entries, err := os.ReadDir(base)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		dir := filepath.Join(base, name)
+		if typ, _ := readString(filepath.Join(dir, "type")); typ == "Battery" {
+			return dir, nil
+		}
+	}
+	return "", nil
+}
+
+// BatteryIcon maps a battery state to the icon names shipped with the UI.
+// The icon doubles as the level bucket for change events.
+func BatteryIcon(b Battery) string {
+	if !b.Present {
+		return ""
+	}
+	p := b.Percent
+	if b.Charging {
+		switch {
+		case p >= 95:
+			return "battery_charging_full"
+		case p >= 85:
+			return "battery_charging_90"
+		case p >= 50:
+			return "battery_charging_80"
+		case p >= 25:
+			return "battery_charging_30"
+		default:
+			return "battery_charging_20"
+		}
+	}
+	switch {
+	case p >= 95:
+		return "battery_full"
+	case p >= 75:
+		return "battery_6_bar"
+	case p >= 50:
+		return "battery_5_bar"
+	case p >= 25:
+		return "battery_2_bar"
+	case p >= 10:
+		return "battery_1_bar"
+	default:
+		return "battery_0_bar"
+	}
+}
+
+func readString(path string) (string, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(raw)), nil
+}
+
+func readInt(path string) (int, error) {
+	s, err := readString(path)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(s)
+}
@@ -0,0 +1,168 @@
+package status
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeSupply creates <root>/sys/class/power_supply/<name> with the given
+// attribute files.
+func writeSupply(t *testing.T, root, name string, attrs map[string]string) {
+	t.Helper()
+	dir := filepath.Join(root, "sys/class/power_supply", name)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for k, v := range attrs {
+		if err := os.WriteFile(filepath.Join(dir, k), []byte(v+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestBatteryRead(t *testing.T) {
+	tests := []struct {
+		name     string
+		supplies map[string]map[string]string
+		want     Battery
+		wantErr  bool
+	}{
+		{
+			name: "no power_supply class",
+			want: Battery{},
+		},
+		{
+			name:     "mains only",
+			supplies: map[string]map[string]string{"AC": {"type": "Mains", "online": "1"}},
+			want:     Battery{},
+		},
+		{
+			name: "discharging",
+			supplies: map[string]map[string]string{
+				"AC":   {"type": "Mains"},
+				"BAT0": {"type": "Battery", "capacity": "57", "status": "Discharging", "voltage_now": "3712000"},
+			},
+			want: Battery{Present: true, Percent: 57, State: "Discharging", VoltageMV: 3712, Icon: "battery_5_bar"},
+		},
+		{
+			name:     "full counts as charging",
+			supplies: map[string]map[string]string{"battery": {"type": "Battery", "capacity": "100", "status": "Full"}},
+			want:     Battery{Present: true, Percent: 100, Charging: true, State: "Full", Icon: "battery_charging_full"},
+		},
+		{
+			name:     "gauge over 100",
+			supplies: map[string]map[string]string{"BAT0": {"type": "Battery", "capacity": "104", "status": "Charging"}},
+			want:     Battery{Present: true, Percent: 100, Charging: true, State: "Charging", Icon: "battery_charging_full"},
+		},
+		{
+			name:     "first battery by name",
+			supplies: map[string]map[string]string{"BAT1": {"type": "Battery", "capacity": "20"}, "BAT0": {"type": "Battery", "capacity": "90"}},
+			want:     Battery{Present: true, Percent: 90, Icon: "battery_6_bar"},
+		},
+		{
+			name:     "unreadable capacity",
+			supplies: map[string]map[string]string{"BAT0": {"type": "Battery", "capacity": "n/a"}},
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			for name, attrs := range tt.supplies {
+				writeSupply(t, root, name, attrs)
+			}
+			got, err := NewBatteryProvider(root).Read()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("Read() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBatterySmoothing(t *testing.T) {
+	root := t.TempDir()
+	set := func(capacity, status string) {
+		writeSupply(t, root, "BAT0", map[string]string{"type": "Battery", "capacity": capacity, "status": status})
+	}
+	b := NewBatteryProvider(root)
+	read := func() Battery {
+		t.Helper()
+		bat, err := b.Read()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return bat
+	}
+
+	set("80", "Discharging")
+	if p := read().Percent; p != 80 {
+		t.Fatalf("first reading = %d, want it unsmoothed", p)
+	}
+
+	// a dip under load moves the level only part of the way
+	set("60", "Discharging")
+	if p := read().Percent; p != 74 {
+		t.Fatalf("after one dip = %d, want 74", p)
+	}
+
+	// a lasting drop converges without overshooting
+	last := 74
+	for i := 0; i < 20; i++ {
+		p := read().Percent
+		if p > last || p < 60 {
+			t.Fatalf("reading %d = %d after %d", i, p, last)
+		}
+		last = p
+	}
+	if last != 60 {
+		t.Fatalf("converged to %d, want 60", last)
+	}
+
+	// plugging in starts over at the raw value
+	set("62", "Charging")
+	if bat := read(); bat.Percent != 62 || !bat.Charging {
+		t.Fatalf("after plugging in = %+v", bat)
+	}
+}
+
+func TestBatteryIcon(t *testing.T) {
+	tests := []struct {
+		percent  int
+		charging bool
+		want     string
+	}{
+		{100, false, "battery_full"},
+		{95, false, "battery_full"},
+		{94, false, "battery_6_bar"},
+		{75, false, "battery_6_bar"},
+		{74, false, "battery_5_bar"},
+		{50, false, "battery_5_bar"},
+		{49, false, "battery_2_bar"},
+		{25, false, "battery_2_bar"},
+		{24, false, "battery_1_bar"},
+		{10, false, "battery_1_bar"},
+		{9, false, "battery_0_bar"},
+		{0, false, "battery_0_bar"},
+		{95, true, "battery_charging_full"},
+		{94, true, "battery_charging_90"},
+		{85, true, "battery_charging_90"},
+		{84, true, "battery_charging_80"},
+		{50, true, "battery_charging_80"},
+		{49, true, "battery_charging_30"},
+		{25, true, "battery_charging_30"},
+		{24, true, "battery_charging_20"},
+		{0, true, "battery_charging_20"},
+	}
+	for _, tt := range tests {
+		if got := BatteryIcon(Battery{Present: true, Percent: tt.percent, Charging: tt.charging}); got != tt.want {
+			t.Errorf("BatteryIcon(%d, charging %v) = %q, want %q", tt.percent, tt.charging, got, tt.want)
+		}
+	}
+	if got := BatteryIcon(Battery{Percent: 50}); got != "" {
+		t.Errorf("no battery: %q, want no icon", got)
+	}
+}
@@ -0,0 +1,167 @@
+package status
+
+import (
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"mupibox/internal/events"
+)
+
+// Service polls the providers, keeps the latest Status and publishes an
+// event whenever a bucket the UI cares about changes.
+type Service struct {
+	Bus     *events.Bus
+	Battery *BatteryProvider // optional
+	Wifi    *WifiProvider    // optional
+	System  *SystemProvider  // optional
+
+	// ShutdownIn reports a running idle countdown in seconds (optional).
+	ShutdownIn func() int
+
+	mu       sync.Mutex
+	st       Status
+	watchers []func(Status)
+
+	ticker *time.Ticker
+	done   chan struct{}
+}
+
+func NewService(bus *events.Bus, battery *BatteryProvider, wifi *WifiProvider, system *SystemProvider) *Service {
+	return &Service{
+		Bus:     bus,
+		Battery: battery,
+		Wifi:    wifi,
+		System:  system,
+	}
+}
+
+// Start refreshes once right away and then on every interval.
+func (s *Service) Start(interval time.Duration) {
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+	s.Refresh()
+
+	s.ticker = time.NewTicker(interval)
+	s.done = make(chan struct{})
+	go s.loop()
+}
+
+func (s *Service) Close() {
+	close(s.done)
+	s.ticker.Stop()
+}
+
+func (s *Service) loop() {
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-s.ticker.C:
+			s.Refresh()
+		}
+	}
+}
+
+func (s *Service) Status() Status {
+	s.mu.Lock()
+	st := s.st
+	fn := s.ShutdownIn
+	s.mu.Unlock()
+
+	if fn != nil {
+		st.ShutdownIn = fn()
+	}
+	return st
+}
+
+// Watch registers fn to be called with the status after every refresh,
+// e.g. for policies that need each battery reading, not only bucket changes.
+func (s *Service) Watch(fn func(Status)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.watchers = append(s.watchers, fn)
+}
+
+// Refresh reads all providers now.
+func (s *Service) Refresh() {
+	s.refreshBattery()
+	s.refreshWifi()
+	s.refreshSystem()
+
+	s.mu.Lock()
+	st := s.st
+	watchers := append([]func(Status){}, s.watchers...)
+	s.mu.Unlock()
+
+	for _, fn := range watchers {
+		fn(st)
+	}
+}
+
+func (s *Service) refreshBattery() {
+	if s.Battery == nil {
+		return
+	}
+	bat, err := s.Battery.Read()
+	if err != nil {
+		log.Println("status: battery:", err)
+		return
+	}
+
+	s.mu.Lock()
+	prev := s.st.Battery
+	s.st.Battery = bat
+	s.mu.Unlock()
+
+	if bat.Icon != prev.Icon || bat.Present != prev.Present {
+		s.publish("battery", bat)
+	}
+}
+
+func (s *Service) refreshWifi() {
+	if s.Wifi == nil {
+		return
+	}
+	w, err := s.Wifi.Read()
+	if err != nil {
+		log.Println("status: wifi:", err)
+	}
+
+	s.mu.Lock()
+	prev := s.st.Wifi
+	s.st.Wifi = w
+	s.mu.Unlock()
+
+	if w.Connected != prev.Connected || w.Strength != prev.Strength || w.SSID != prev.SSID || w.IP != prev.IP {
+		s.publish("wifi", w)
+	}
+}
+
+func (s *Service) refreshSystem() {
+	if s.System == nil {
+		return
+	}
+	sys, err := s.System.Read()
+	if err != nil {
+		log.Println("status: system:", err)
+		return
+	}
+
+	s.mu.Lock()
+	prev := s.st.System
+	s.st.System = sys
+	s.mu.Unlock()
+
+	if strings.Join(sys.Warnings, ",") != strings.Join(prev.Warnings, ",") {
+		s.publish("system", sys)
+	}
+}
+
+func (s *Service) publish(typ string, data any) {
+	if s.Bus != nil {
+		s.Bus.Publish(typ, data)
+	}
+}
@@ -1,16 +1,29 @@
 package status
 
 type Battery struct {
-	Percent  int  `json:"percent"`
-	Charging bool `json:"charging"`
+	Present   bool   `json:"present"`
+	Percent   int    `json:"percent"`
+	Charging  bool   `json:"charging"`
+	State     string `json:"state,omitempty"` // sysfs status: Charging, Discharging, Full, ...
+	VoltageMV int    `json:"voltage_mv,omitempty"`
+	Icon      string `json:"icon,omitempty"` // z.B. battery_5_bar, battery_charging_80
 }
 
 type Wifi struct {
-	Connected bool `json:"connected"`
-	Strength  int  `json:"strength"`
+	Connected bool   `json:"connected"`
+	Strength  int    `json:"strength"` // 0 = off, 1..3 = UI bars
+	Quality   int    `json:"quality"`  // link quality 0..100
+	Interface string `json:"interface,omitempty"`
+	SSID      string `json:"ssid,omitempty"`
+	IP        string `json:"ip,omitempty"`
+	Icon      string `json:"icon,omitempty"` // z.B. wifi_2_bar
 }
 
 type Status struct {
 	Battery Battery `json:"battery"`
 	Wifi    Wifi    `json:"wifi"`
+	System  System  `json:"system"`
+
+	// seconds until idle power-off, only while the warning is shown
+	ShutdownIn int `json:"shutdown_in,omitempty"`
 }
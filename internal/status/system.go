@@ -0,0 +1,156 @@
+package status
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// warning thresholds for the parents' UI
+const (
+	hotTempC       = 75.0
+	lowDiskPercent = 5
+	lowDiskBytes   = 200 << 20
+)
+
+type Throttling struct {
+	Raw                  string `json:"raw"` // hex, wie vcgencmd get_throttled
+	UnderVoltage         bool   `json:"under_voltage"`
+	FreqCapped           bool   `json:"freq_capped"`
+	Throttled            bool   `json:"throttled"`
+	SoftTempLimit        bool   `json:"soft_temp_limit"`
+	UnderVoltageOccurred bool   `json:"under_voltage_occurred"`
+	ThrottledOccurred    bool   `json:"throttled_occurred"`
+}
+
+type System struct {
+	CPUTempC   float64     `json:"cpu_temp_c,omitempty"`
+	Load       [3]float64  `json:"load"`
+	UptimeSec  int64       `json:"uptime_sec"`
+	MemTotalKB int64       `json:"mem_total_kb"`
+	MemAvailKB int64       `json:"mem_available_kb"`
+	DiskTotal  uint64      `json:"disk_total_bytes"`
+	DiskFree   uint64      `json:"disk_free_bytes"`
+	Throttling *Throttling `json:"throttling,omitempty"` // nur auf dem Pi
+	Warnings   []string    `json:"warnings"`             // overheating, under_voltage, throttled, disk_low
+}
+
+// SystemProvider reads health data below Root (procfs, sysfs) and the
+// free space of DataDir.
+type SystemProvider struct {
+	Root    string
+	DataDir string
+
+	// Statfs returns total and free bytes of the filesystem at path.
+	Statfs func(path string) (total, free uint64, err error)
+}
+
+func NewSystemProvider(root, dataDir string) *SystemProvider {
+	return &SystemProvider{
+		Root:    root,
+		DataDir: dataDir,
+		Statfs:  statfs,
+	}
+}
+
+// Read collects whatever is available; missing sources are left empty.
+func (p *SystemProvider) Read() (System, error) {
+	var s System
+
+	if milli, err := readInt(p.path("sys/class/thermal/thermal_zone0/temp")); err == nil {
+		s.CPUTempC = float64(milli) / 1000
+	}
+
+	if raw, err := readString(p.path("proc/loadavg")); err == nil {
+		f := strings.Fields(raw)
+		for i := 0; i < 3 && i < len(f); i++ {
+			s.Load[i], _ = strconv.ParseFloat(f[i], 64)
+		}
+	}
+
+	if raw, err := readString(p.path("proc/uptime")); err == nil {
+		if f := strings.Fields(raw); len(f) > 0 {
+			up, _ := strconv.ParseFloat(f[0], 64)
+			s.UptimeSec = int64(up)
+		}
+	}
+
+	if raw, err := os.ReadFile(p.path("proc/meminfo")); err == nil {
+		s.MemTotalKB, s.MemAvailKB = parseMeminfo(raw)
+	}
+
+	if p.Statfs != nil && p.DataDir != "" {
+		dir := p.DataDir
+		if abs, err := filepath.Abs(dir); err == nil {
+			dir = abs
+		}
+		if total, free, err := p.Statfs(p.path(dir)); err == nil {
+			s.DiskTotal, s.DiskFree = total, free
+		}
+	}
+
+	if raw, err := readString(p.path("sys/devices/platform/soc/soc:firmware/get_throttled")); err == nil {
+		if v, err := strconv.ParseUint(strings.TrimPrefix(raw, "0x"), 16, 32); err == nil {
+			s.Throttling = &Throttling{
+				Raw:                  "0x" + strconv.FormatUint(v, 16),
+				UnderVoltage:         v&(1<<0) != 0,
+				FreqCapped:           v&(1<<1) != 0,
+				Throttled:            v&(1<<2) != 0,
+				SoftTempLimit:        v&(1<<3) != 0,
+				UnderVoltageOccurred: v&(1<<16) != 0,
+				ThrottledOccurred:    v&(1<<18) != 0,
+			}
+		}
+	}
+
+	s.Warnings = systemWarnings(s)
+	return s, nil
+}
+
+func (p *SystemProvider) path(rel string) string {
+	return filepath.Join(p.Root, rel)
+}
+
+// MemTotal / MemAvailable in kB
+func parseMeminfo(raw []byte) (total, avail int64) {
+	sc := bufio.NewScanner(bytes.NewReader(raw))
+	for sc.Scan() {
+		f := strings.Fields(sc.Text())
+		if len(f) < 2 {
+			continue
+		}
+		v, err := strconv.ParseInt(f[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		switch f[0] {
+		case "MemTotal:":
+			total = v
+		case "MemAvailable:":
+			avail = v
+		}
+	}
+	return total, avail
+}
+
+func systemWarnings(s System) []string {
+	out := []string{}
+	if s.CPUTempC >= hotTempC {
+		out = append(out, "overheating")
+	}
+	if t := s.Throttling; t != nil {
+		if t.UnderVoltage || t.UnderVoltageOccurred {
+			out = append(out, "under_voltage")
+		}
+		if t.Throttled || t.FreqCapped || t.SoftTempLimit {
+			out = append(out, "throttled")
+		}
+	}
+	if s.DiskTotal > 0 && (s.DiskFree < lowDiskBytes || s.DiskFree*100/s.DiskTotal < lowDiskPercent) {
+		out = append(out, "disk_low")
+	}
+	return out
+}
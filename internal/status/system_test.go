@@ -0,0 +1,131 @@
+package status
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"mupibox/internal/events"
+)
+
+func TestSystemWarnings(t *testing.T) {
+	const gb = 1 << 30
+	tests := []struct {
+		name string
+		sys  System
+		want []string
+	}{
+		{"nothing known", System{}, []string{}},
+		{"warm", System{CPUTempC: 74.9}, []string{}},
+		{"hot", System{CPUTempC: 75}, []string{"overheating"}},
+		{"under voltage now", System{Throttling: &Throttling{UnderVoltage: true}}, []string{"under_voltage"}},
+		{"under voltage since boot", System{Throttling: &Throttling{UnderVoltageOccurred: true}}, []string{"under_voltage"}},
+		{"throttled since boot only", System{Throttling: &Throttling{ThrottledOccurred: true}}, []string{}},
+		{"freq capped", System{Throttling: &Throttling{FreqCapped: true}}, []string{"throttled"}},
+		{"soft temp limit", System{Throttling: &Throttling{SoftTempLimit: true}}, []string{"throttled"}},
+		{"disk at 5%", System{DiskTotal: 10 * gb, DiskFree: 10 * gb / 20}, []string{}},
+		{"disk below 5%", System{DiskTotal: 10 * gb, DiskFree: 10*gb/20 - 1}, []string{"disk_low"}},
+		{"disk at 200 MB", System{DiskTotal: 2 * gb, DiskFree: lowDiskBytes}, []string{}},
+		{"disk below 200 MB", System{DiskTotal: 2 * gb, DiskFree: lowDiskBytes - 1}, []string{"disk_low"}},
+		{
+			"everything",
+			System{CPUTempC: 82, Throttling: &Throttling{UnderVoltage: true, Throttled: true}, DiskTotal: gb},
+			[]string{"overheating", "under_voltage", "throttled", "disk_low"},
+		},
+	}
+	for _, tt := range tests {
+		if got := systemWarnings(tt.sys); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: warnings = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSystemRead(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"sys/class/thermal/thermal_zone0/temp":                "76312",
+		"sys/devices/platform/soc/soc:firmware/get_throttled": "0x50005",
+		"proc/loadavg": "0.52 0.31 0.12 1/180 2412",
+		"proc/uptime":  "3605.27 12000.10",
+		"proc/meminfo": "MemTotal:        949448 kB\nMemFree:         512000 kB\nMemAvailable:    700000 kB\n",
+	}
+	for name, data := range files {
+		writeFile(t, filepath.Join(root, name), data)
+	}
+
+	p := &SystemProvider{Root: root}
+	got, err := p.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := System{
+		CPUTempC:   76.312,
+		Load:       [3]float64{0.52, 0.31, 0.12},
+		UptimeSec:  3605,
+		MemTotalKB: 949448,
+		MemAvailKB: 700000,
+		Throttling: &Throttling{
+			Raw:                  "0x50005",
+			UnderVoltage:         true,
+			Throttled:            true,
+			UnderVoltageOccurred: true,
+			ThrottledOccurred:    true,
+		},
+		Warnings: []string{"overheating", "under_voltage", "throttled"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("system = %+v\nwant %+v", got, want)
+	}
+}
+
+// TestSystemWarningEvents checks that an event goes out when the set of
+// warnings changes, including when the last one clears, and not on every
+// refresh while it stays the same.
+func TestSystemWarningEvents(t *testing.T) {
+	root := t.TempDir()
+	temp := filepath.Join(root, "sys/class/thermal/thermal_zone0/temp")
+	bus := events.NewBus()
+	ch, unsub := bus.Subscribe()
+	defer unsub()
+	s := NewService(bus, nil, nil, &SystemProvider{Root: root})
+
+	steps := []struct {
+		milliC string
+		want   []string // nil: no event
+	}{
+		{"50000", nil},
+		{"80000", []string{"overheating"}},
+		{"80000", nil},
+		{"76000", nil},
+		{"60000", []string{}},
+		{"60000", nil},
+	}
+	for i, step := range steps {
+		writeFile(t, temp, step.milliC)
+		s.Refresh()
+
+		var got []string
+		select {
+		case ev := <-ch:
+			if ev.Type != "system" {
+				t.Fatalf("step %d: event %q, want system", i, ev.Type)
+			}
+			got = ev.Data.(System).Warnings
+		default:
+		}
+		if !reflect.DeepEqual(got, step.want) {
+			t.Fatalf("step %d (%s): event warnings = %q, want %q", i, step.milliC, got, step.want)
+		}
+	}
+}
+
+func writeFile(t *testing.T, path, data string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(data+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
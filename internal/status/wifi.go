@@ -0,0 +1,197 @@
+package status
+
+import (
+	"bufio"
+	"bytes"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// maxLinkQuality is the usual scale of the link column in
+// /proc/net/wireless (brcmfmac and most other drivers report 0..70).
+const maxLinkQuality = 70
+
+// WifiProvider reads <Root>/proc/net/wireless and the interface operstate.
+// SSID and IP address do not live in procfs; they come from Run and Addrs,
+// which tests can replace. Run (iwgetid) is only asked once per connection,
+// not on every poll.
+type WifiProvider struct {
+	Root      string
+	Interface string // leer = erstes Interface aus /proc/net/wireless
+
+	Run   func(name string, args ...string) ([]byte, error)
+	Addrs func(iface string) ([]string, error)
+
+	mu      sync.Mutex
+	ssid    string
+	ssidFor string // interface ssid was read for, "" = not connected
+}
+
+func NewWifiProvider(root, iface string) *WifiProvider {
+	return &WifiProvider{
+		Root:      root,
+		Interface: iface,
+		Run: func(name string, args ...string) ([]byte, error) {
+			return exec.Command(name, args...).Output()
+		},
+		Addrs: interfaceAddrs,
+	}
+}
+
+func (p *WifiProvider) Read() (Wifi, error) {
+	iface, link, err := p.readWireless()
+	if err != nil {
+		return Wifi{Icon: WifiIcon(Wifi{})}, err
+	}
+	if iface == "" {
+		p.forgetSSID()
+		return Wifi{Icon: WifiIcon(Wifi{})}, nil
+	}
+
+	w := Wifi{Interface: iface}
+
+	oper, _ := readString(filepath.Join(p.Root, "sys/class/net", iface, "operstate"))
+	w.Connected = oper == "up" && link > 0
+
+	if w.Connected {
+		w.Quality = link * 100 / maxLinkQuality
+		if w.Quality > 100 {
+			w.Quality = 100
+		}
+		w.Strength = WifiStrength(w.Quality)
+
+		w.SSID = p.connectedSSID(iface)
+		if p.Addrs != nil {
+			if addrs, err := p.Addrs(iface); err == nil && len(addrs) > 0 {
+				w.IP = addrs[0]
+			}
+		}
+	} else {
+		p.forgetSSID()
+	}
+
+	w.Icon = WifiIcon(w)
+	return w, nil
+}
+
+// connectedSSID returns the SSID of iface, asking iwgetid only on the
+// first poll of a connection.
+func (p *WifiProvider) connectedSSID(iface string) string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.ssidFor == iface {
+		return p.ssid
+	}
+	p.ssid, p.ssidFor = "", iface
+	if p.Run != nil {
+		if out, err := p.Run("iwgetid", iface, "-r"); err == nil {
+			p.ssid = strings.TrimSpace(string(out))
+		}
+	}
+	return p.ssid
+}
+
+// forgetSSID makes the next connection ask iwgetid again.
+func (p *WifiProvider) forgetSSID() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.ssid, p.ssidFor = "", ""
+}
+
+// readWireless returns the interface and its link quality.
+//
+//	Inter-| sta-|   Quality        |   Discarded packets ...
+//	 face | tus | link level noise |  nwid  crypt ...
+//	 wlan0: 0000   54.  -56.  -256        0      0 ...
+func (p *WifiProvider) readWireless() (string, int, error) {
+	raw, err := os.ReadFile(filepath.Join(p.Root, "proc/net/wireless"))
+	if os.IsNotExist(err) {
+		return "", 0, nil
+	}
+	if err != nil {
+		return "", 0, err
+	}
+
+	sc := bufio.NewScanner(bytes.NewReader(raw))
+	for sc.Scan() {
+		name, rest, ok := strings.Cut(sc.Text(), ":")
+		if !ok {
+			continue
+		}
+		name = strings.TrimSpace(name)
+		if p.Interface != "" && name != p.Interface {
+			continue
+		}
+		fields := strings.Fields(rest)
+		if len(fields) < 2 {
+			continue
+		}
+		link, err := strconv.ParseFloat(strings.TrimSuffix(fields[1], "."), 64)
+		if err != nil {
+			continue
+		}
+		return name, int(link), nil
+	}
+
+	// Interface exists but is not associated: no line in /proc/net/wireless
+	return p.Interface, 0, nil
+}
+
+// WifiStrength maps link quality (0..100) to the UI levels 1..3.
+// 0 is reserved for "not connected".
+func WifiStrength(quality int) int {
+	switch {
+	case quality >= 67:
+		return 3
+	case quality >= 34:
+		return 2
+	default:
+		return 1
+	}
+}
+
+// WifiIcon maps the wifi state to the icon names shipped with the UI.
+func WifiIcon(w Wifi) string {
+	if !w.Connected {
+		return "wifi_3_off"
+	}
+	switch w.Strength {
+	case 3:
+		return "wifi"
+	case 2:
+		return "wifi_2_bar"
+	default:
+		return "wifi_1_bar"
+	}
+}
+
+func interfaceAddrs(iface string) ([]string, error) {
+	ifi, err := net.InterfaceByName(iface)
+	if err != nil {
+		return nil, err
+	}
+	addrs, err := ifi.Addrs()
+	if err != nil {
+		return nil, err
+	}
+
+	var out []string
+	for _, a := range addrs {
+		ipnet, ok := a.(*net.IPNet)
+		if !ok {
+			continue
+		}
+		// IPv4 first, that is what parents type into a browser
+		if ipnet.IP.To4() != nil {
+			out = append([]string{ipnet.IP.String()}, out...)
+		} else {
+			out = append(out, ipnet.IP.String())
+		}
+	}
+	return out, nil
+}
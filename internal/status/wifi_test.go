@@ -0,0 +1,182 @@
+package status
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const wirelessHeader = "Inter-| sta-|   Quality        |   Discarded packets               | Missed | WE\n" +
+	" face | tus | link level noise |  nwid  crypt   frag  retry   misc | beacon | 22\n"
+
+// wifiFixture writes <root>/proc/net/wireless (omitted if wireless is
+// "-") and the operstate of wlan0.
+func wifiFixture(t *testing.T, root, wireless, operstate string) {
+	t.Helper()
+	if wireless != "-" {
+		if err := os.MkdirAll(filepath.Join(root, "proc/net"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(root, "proc/net/wireless"), []byte(wirelessHeader+wireless), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	dir := filepath.Join(root, "sys/class/net/wlan0")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "operstate"), []byte(operstate+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// fakeIwgetid answers iwgetid and counts the calls.
+type fakeIwgetid struct {
+	ssid  string
+	calls int
+}
+
+func (f *fakeIwgetid) run(name string, args ...string) ([]byte, error) {
+	f.calls++
+	if f.ssid == "" {
+		return nil, errors.New("exit status 255")
+	}
+	return []byte(f.ssid + "\n"), nil
+}
+
+func newTestWifi(root, iface string) (*WifiProvider, *fakeIwgetid) {
+	f := &fakeIwgetid{ssid: "Zuhause"}
+	p := NewWifiProvider(root, iface)
+	p.Run = f.run
+	p.Addrs = func(string) ([]string, error) { return []string{"192.168.1.23", "fe80::1"}, nil }
+	return p, f
+}
+
+func TestWifiRead(t *testing.T) {
+	tests := []struct {
+		name      string
+		iface     string
+		wireless  string
+		operstate string
+		want      Wifi
+	}{
+		{
+			name:     "no wireless extensions",
+			wireless: "-",
+			want:     Wifi{Icon: "wifi_3_off"},
+		},
+		{
+			name:      "configured interface missing",
+			iface:     "wlan1",
+			wireless:  " wlan0: 0000   54.  -56.  -256        0      0      0      0      0        0\n",
+			operstate: "up",
+			want:      Wifi{Interface: "wlan1", Icon: "wifi_3_off"},
+		},
+		{
+			name:      "not associated",
+			wireless:  "",
+			iface:     "wlan0",
+			operstate: "dormant",
+			want:      Wifi{Interface: "wlan0", Icon: "wifi_3_off"},
+		},
+		{
+			name:      "link quality 0",
+			wireless:  " wlan0: 0000    0.  -256.  -256        0      0      0      0      0        0\n",
+			operstate: "up",
+			want:      Wifi{Interface: "wlan0", Icon: "wifi_3_off"},
+		},
+		{
+			name:      "interface down",
+			wireless:  " wlan0: 0000   54.  -56.  -256        0      0      0      0      0        0\n",
+			operstate: "down",
+			want:      Wifi{Interface: "wlan0", Icon: "wifi_3_off"},
+		},
+		{
+			name:      "medium link",
+			wireless:  " wlan0: 0000   35.  -75.  -256        0      0      0      0      0        0\n",
+			operstate: "up",
+			want:      Wifi{Connected: true, Strength: 2, Quality: 50, Interface: "wlan0", SSID: "Zuhause", IP: "192.168.1.23", Icon: "wifi_2_bar"},
+		},
+		{
+			name:      "link quality 70",
+			wireless:  " wlan0: 0000   70.  -40.  -256        0      0      0      0      0        0\n",
+			operstate: "up",
+			want:      Wifi{Connected: true, Strength: 3, Quality: 100, Interface: "wlan0", SSID: "Zuhause", IP: "192.168.1.23", Icon: "wifi"},
+		},
+		{
+			name:      "driver scale above 70",
+			wireless:  " wlan0: 0000   94.  -30.  -256        0      0      0      0      0        0\n",
+			operstate: "up",
+			want:      Wifi{Connected: true, Strength: 3, Quality: 100, Interface: "wlan0", SSID: "Zuhause", IP: "192.168.1.23", Icon: "wifi"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			wifiFixture(t, root, tt.wireless, tt.operstate)
+			p, _ := newTestWifi(root, tt.iface)
+			got, err := p.Read()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Fatalf("Read() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWifiSSIDOncePerConnection(t *testing.T) {
+	root := t.TempDir()
+	up := " wlan0: 0000   54.  -56.  -256        0      0      0      0      0        0\n"
+	p, f := newTestWifi(root, "")
+
+	wifiFixture(t, root, up, "up")
+	for i := 0; i < 3; i++ {
+		if w, _ := p.Read(); w.SSID != "Zuhause" {
+			t.Fatalf("poll %d: ssid %q", i, w.SSID)
+		}
+	}
+	if f.calls != 1 {
+		t.Fatalf("iwgetid ran %d times while connected, want once", f.calls)
+	}
+
+	// reconnect to another network
+	wifiFixture(t, root, "", "dormant")
+	if w, _ := p.Read(); w.SSID != "" {
+		t.Fatalf("ssid %q while disconnected", w.SSID)
+	}
+	f.ssid = "Oma"
+	wifiFixture(t, root, up, "up")
+	if w, _ := p.Read(); w.SSID != "Oma" || f.calls != 2 {
+		t.Fatalf("ssid %q after %d calls, want Oma after 2", w.SSID, f.calls)
+	}
+}
+
+func TestWifiStrengthAndIcon(t *testing.T) {
+	tests := []struct {
+		quality  int
+		strength int
+		icon     string
+	}{
+		{100, 3, "wifi"},
+		{67, 3, "wifi"},
+		{66, 2, "wifi_2_bar"},
+		{34, 2, "wifi_2_bar"},
+		{33, 1, "wifi_1_bar"},
+		{1, 1, "wifi_1_bar"},
+	}
+	for _, tt := range tests {
+		s := WifiStrength(tt.quality)
+		if s != tt.strength {
+			t.Errorf("WifiStrength(%d) = %d, want %d", tt.quality, s, tt.strength)
+		}
+		if icon := WifiIcon(Wifi{Connected: true, Strength: s}); icon != tt.icon {
+			t.Errorf("WifiIcon(quality %d) = %q, want %q", tt.quality, icon, tt.icon)
+		}
+	}
+	if icon := WifiIcon(Wifi{Strength: 3}); icon != "wifi_3_off" {
+		t.Errorf("disconnected: %q", icon)
+	}
+}
@@ -0,0 +1,76 @@
+package volume
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+type API struct {
+	P *Policy
+
+	// Effective returns the cap that applies right now, including other
+	// limits like the profile or a low battery (optional).
+	Effective func() int
+}
+
+func NewAPI(p *Policy, effective func() int) *API {
+	return &API{P: p, Effective: effective}
+}
+
+// Register adds the read-only view for the UI (e.g. the slider maximum).
+func (a *API) Register(mux *http.ServeMux) {
+	mux.HandleFunc("/api/volume", a.handleVolume)
+}
+
+// RegisterAdmin adds editing of the limits. mux is expected to be behind
+// admin.Require.
+func (a *API) RegisterAdmin(mux *http.ServeMux) {
+	// GET / PUT settings
+	mux.HandleFunc("/api/admin/volume", a.handleSettings)
+}
+
+func (a *API) handleVolume(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	limit := a.P.Limit()
+	if a.Effective != nil {
+		limit = a.Effective()
+	}
+	resp := map[string]any{"max_volume": limit}
+	if q, ok := a.P.QuietActive(); ok {
+		resp["quiet_hours"] = q
+	}
+	writeJSON(w, resp)
+}
+
+func (a *API) handleSettings(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, a.P.Settings())
+	case http.MethodPut, http.MethodPost:
+		var s Settings
+		if err := json.NewDecoder(r.Body).Decode(&s); err != nil {
+			http.Error(w, "invalid json", http.StatusBadRequest)
+			return
+		}
+		if err := a.P.Set(s); err != nil {
+			if errors.Is(err, ErrInvalid) {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, a.P.Settings())
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,222 @@
+package volume
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"mupibox/internal/atomicfile"
+)
+
+var ErrInvalid = errors.New("invalid volume settings")
+
+// QuietHours caps the volume during a time of day. From after To spans
+// midnight, e.g. 20:00-07:00.
+type QuietHours struct {
+	From      string `json:"from"` // HH:MM
+	To        string `json:"to"`   // HH:MM
+	MaxVolume int    `json:"max_volume"`
+}
+
+// Settings are the parent-controlled volume limits (0 = no limit).
+type Settings struct {
+	MaxVolume     int          `json:"max_volume"`
+	StartupVolume int          `json:"startup_volume"` // level after boot, 0 = keep (e.g. the restored ALSA level)
+	Quiet         []QuietHours `json:"quiet_hours"`
+}
+
+func (s Settings) Validate() error {
+	if !percent(s.MaxVolume) || !percent(s.StartupVolume) {
+		return ErrInvalid
+	}
+	if s.MaxVolume > 0 && s.StartupVolume > s.MaxVolume {
+		return fmt.Errorf("%w: startup volume above the maximum", ErrInvalid)
+	}
+	for _, q := range s.Quiet {
+		if !percent(q.MaxVolume) || q.MaxVolume == 0 {
+			return ErrInvalid
+		}
+		if _, err := parseClock(q.From); err != nil {
+			return err
+		}
+		if _, err := parseClock(q.To); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Active reports whether now lies within the quiet hours.
+func (q QuietHours) Active(now time.Time) bool {
+	from, err1 := parseClock(q.From)
+	to, err2 := parseClock(q.To)
+	if err1 != nil || err2 != nil || from == to {
+		return false
+	}
+	m := now.Hour()*60 + now.Minute()
+	if from < to {
+		return m >= from && m < to
+	}
+	return m >= from || m < to
+}
+
+// Limit returns the cap at time now: the global maximum or the lower cap
+// of active quiet hours (0 = none).
+func (s Settings) Limit(now time.Time) int {
+	limit := s.MaxVolume
+	for _, q := range s.Quiet {
+		if q.Active(now) && (limit == 0 || q.MaxVolume < limit) {
+			limit = q.MaxVolume
+		}
+	}
+	return limit
+}
+
+// Policy keeps the settings in a file, so changes by parents survive a
+// restart. Until then the defaults from the config file apply.
+type Policy struct {
+	path string
+
+	// OnChange is called when the limit changed, through new settings or
+	// quiet hours starting/ending (optional).
+	OnChange func(limit int)
+
+	mu     sync.Mutex
+	s      Settings
+	last   int
+	ticker *time.Ticker
+	done   chan struct{}
+}
+
+// NewPolicy loads the saved settings. A broken or invalid file must not
+// keep the box from booting: it is moved aside and the defaults apply.
+func NewPolicy(path string, defaults Settings) *Policy {
+	p := &Policy{path: path, s: defaults}
+
+	raw, err := os.ReadFile(path)
+	if err == nil {
+		var s Settings
+		err = json.Unmarshal(raw, &s)
+		if err == nil {
+			err = s.Validate()
+		}
+		if err == nil {
+			p.s = s
+		} else {
+			aside := path + ".corrupt"
+			log.Printf("volume: %s: %v; moving it to %s", path, err, aside)
+			if err := os.Rename(path, aside); err != nil {
+				log.Println("volume:", err)
+			}
+		}
+	} else if !os.IsNotExist(err) {
+		log.Println("volume:", err)
+	}
+	if p.s.Quiet == nil {
+		p.s.Quiet = []QuietHours{}
+	}
+	p.last = p.s.Limit(time.Now())
+	return p
+}
+
+func (p *Policy) Settings() Settings {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	s := p.s
+	s.Quiet = append([]QuietHours{}, p.s.Quiet...)
+	return s
+}
+
+func (p *Policy) Set(s Settings) error {
+	if err := s.Validate(); err != nil {
+		return err
+	}
+	if s.Quiet == nil {
+		s.Quiet = []QuietHours{}
+	}
+
+	p.mu.Lock()
+	p.s = s
+	raw, _ := json.MarshalIndent(s, "", "  ")
+	err := atomicfile.Write(p.path, raw)
+	p.mu.Unlock()
+
+	p.check(time.Now())
+	return err
+}
+
+// Limit returns the current cap (0 = none).
+func (p *Policy) Limit() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.s.Limit(time.Now())
+}
+
+// QuietActive returns the quiet hours in effect right now.
+func (p *Policy) QuietActive() (QuietHours, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := time.Now()
+	for _, q := range p.s.Quiet {
+		if q.Active(now) {
+			return q, true
+		}
+	}
+	return QuietHours{}, false
+}
+
+func (p *Policy) StartupVolume() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.s.StartupVolume
+}
+
+// Start watches the clock for quiet hours.
+func (p *Policy) Start() {
+	p.ticker = time.NewTicker(30 * time.Second)
+	p.done = make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-p.done:
+				return
+			case now := <-p.ticker.C:
+				p.check(now)
+			}
+		}
+	}()
+}
+
+func (p *Policy) Close() {
+	close(p.done)
+	p.ticker.Stop()
+}
+
+func (p *Policy) check(now time.Time) {
+	p.mu.Lock()
+	limit := p.s.Limit(now)
+	changed := limit != p.last
+	p.last = limit
+	p.mu.Unlock()
+
+	if changed && p.OnChange != nil {
+		p.OnChange(limit)
+	}
+}
+
+func percent(n int) bool {
+	return n >= 0 && n <= 100
+}
+
+// parseClock parses HH:MM into minutes after midnight.
+func parseClock(s string) (int, error) {
+	var h, m int
+	if _, err := fmt.Sscanf(s, "%d:%d", &h, &m); err != nil || h < 0 || h > 23 || m < 0 || m > 59 {
+		return 0, fmt.Errorf("%w: time %q", ErrInvalid, s)
+	}
+	return h*60 + m, nil
+}
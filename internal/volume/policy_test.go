@@ -0,0 +1,142 @@
+package volume
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func at(clock string) time.Time {
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		panic(err)
+	}
+	return time.Date(2026, 3, 14, t.Hour(), t.Minute(), 0, 0, time.Local)
+}
+
+func TestQuietHoursOverMidnight(t *testing.T) {
+	q := QuietHours{From: "19:00", To: "07:00", MaxVolume: 30}
+	tests := []struct {
+		clock string
+		want  bool
+	}{
+		{"18:59", false},
+		{"19:00", true},
+		{"23:59", true},
+		{"00:00", true},
+		{"06:59", true},
+		{"07:00", false},
+		{"12:00", false},
+	}
+	for _, tt := range tests {
+		if got := q.Active(at(tt.clock)); got != tt.want {
+			t.Errorf("19:00-07:00 at %s: active %v, want %v", tt.clock, got, tt.want)
+		}
+	}
+
+	day := QuietHours{From: "13:00", To: "15:00", MaxVolume: 30}
+	if !day.Active(at("14:00")) || day.Active(at("15:00")) || day.Active(at("00:00")) {
+		t.Error("13:00-15:00: wrong window")
+	}
+}
+
+func TestLimitClampsToMax(t *testing.T) {
+	tests := []struct {
+		name  string
+		s     Settings
+		clock string
+		want  int
+	}{
+		{"no limits", Settings{}, "12:00", 0},
+		{"global max", Settings{MaxVolume: 60}, "12:00", 60},
+		{"quiet hours only", Settings{Quiet: []QuietHours{{"19:00", "07:00", 30}}}, "23:59", 30},
+		{"quiet hours lower", Settings{MaxVolume: 60, Quiet: []QuietHours{{"19:00", "07:00", 30}}}, "00:00", 30},
+		{"quiet hours never raise the max", Settings{MaxVolume: 60, Quiet: []QuietHours{{"19:00", "07:00", 80}}}, "23:59", 60},
+		{"quiet hours over", Settings{MaxVolume: 60, Quiet: []QuietHours{{"19:00", "07:00", 30}}}, "07:00", 60},
+		{"overlapping", Settings{Quiet: []QuietHours{{"19:00", "07:00", 30}, {"21:00", "06:00", 20}}}, "23:59", 20},
+	}
+	for _, tt := range tests {
+		if got := tt.s.Limit(at(tt.clock)); got != tt.want {
+			t.Errorf("%s: limit %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		s    Settings
+		ok   bool
+	}{
+		{"defaults", Settings{}, true},
+		{"max and startup", Settings{MaxVolume: 80, StartupVolume: 40}, true},
+		{"negative max", Settings{MaxVolume: -1}, false},
+		{"max over 100", Settings{MaxVolume: 101}, false},
+		{"startup above max", Settings{MaxVolume: 40, StartupVolume: 60}, false},
+		{"quiet without cap", Settings{Quiet: []QuietHours{{"19:00", "07:00", 0}}}, false},
+		{"bad clock", Settings{Quiet: []QuietHours{{"25:00", "07:00", 30}}}, false},
+		{"no clock", Settings{Quiet: []QuietHours{{"", "07:00", 30}}}, false},
+	}
+	for _, tt := range tests {
+		err := tt.s.Validate()
+		if (err == nil) != tt.ok || err != nil && !errors.Is(err, ErrInvalid) {
+			t.Errorf("%s: err = %v", tt.name, err)
+		}
+	}
+}
+
+func TestNewPolicyBrokenFile(t *testing.T) {
+	defaults := Settings{MaxVolume: 70}
+	tests := []struct {
+		name string
+		raw  string
+	}{
+		{"not json", `{"max_volume": 5`},
+		{"negative max", `{"max_volume": -10}`},
+		{"inverted", `{"max_volume": 30, "startup_volume": 80}`},
+		{"bad quiet hours", `{"quiet_hours": [{"from": "7pm", "to": "07:00", "max_volume": 30}]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "volume.json")
+			if err := os.WriteFile(path, []byte(tt.raw), 0644); err != nil {
+				t.Fatal(err)
+			}
+			p := NewPolicy(path, defaults)
+			if got := p.Settings(); got.MaxVolume != 70 || len(got.Quiet) != 0 {
+				t.Fatalf("settings = %+v, want the defaults", got)
+			}
+			if raw, err := os.ReadFile(path + ".corrupt"); err != nil || string(raw) != tt.raw {
+				t.Fatalf("broken file not kept: %v", err)
+			}
+		})
+	}
+}
+
+func TestSetRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "volume.json")
+	p := NewPolicy(path, Settings{})
+
+	var limits []int
+	p.OnChange = func(l int) { limits = append(limits, l) }
+
+	s := Settings{MaxVolume: 80, StartupVolume: 30, Quiet: []QuietHours{{"19:00", "07:00", 30}}}
+	if err := p.Set(s); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Set(Settings{MaxVolume: -5}); err == nil {
+		t.Fatal("invalid settings accepted")
+	}
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("temp file left behind: %v", err)
+	}
+	if got := NewPolicy(path, Settings{}).Settings(); !reflect.DeepEqual(got, s) {
+		t.Fatalf("reloaded = %+v, want %+v", got, s)
+	}
+	if len(limits) != 1 {
+		t.Fatalf("OnChange calls = %v, want one", limits)
+	}
+}
@@ -0,0 +1,135 @@
+package wifi
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type API struct {
+	M *Manager
+}
+
+func NewAPI(m *Manager) *API {
+	return &API{M: m}
+}
+
+// Register adds the wifi endpoints. They belong to the parent API, so
+// mux is expected to be behind admin.Require: without admin.pin in the
+// config every call is refused with 403 "admin pin not configured".
+func (a *API) Register(mux *http.ServeMux) {
+	// POST starts a scan, GET returns the latest results
+	mux.HandleFunc("/api/admin/wifi/scan", a.handleScan)
+
+	// Saved networks: GET list, POST add {ssid, psk, connect}
+	mux.HandleFunc("/api/admin/wifi/networks", a.handleNetworks)
+
+	// DELETE /api/admin/wifi/networks/{id}
+	// POST   /api/admin/wifi/networks/{id}/connect
+	mux.HandleFunc("/api/admin/wifi/networks/", a.handleNetwork)
+}
+
+func (a *API) handleScan(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		res, err := a.M.ScanResults()
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, res)
+	case http.MethodPost:
+		if err := a.M.Scan(); err != nil {
+			writeError(w, err)
+			return
+		}
+		writeOK(w)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (a *API) handleNetworks(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		nets, err := a.M.Networks()
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, nets)
+	case http.MethodPost:
+		var body struct {
+			SSID    string `json:"ssid"`
+			PSK     string `json:"psk"`
+			Connect bool   `json:"connect"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, "invalid json", http.StatusBadRequest)
+			return
+		}
+		id, err := a.M.Add(body.SSID, body.PSK)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		if body.Connect {
+			if err := a.M.Connect(id); err != nil {
+				writeError(w, err)
+				return
+			}
+		}
+		writeJSON(w, map[string]any{"ok": true, "id": id})
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (a *API) handleNetwork(w http.ResponseWriter, r *http.Request) {
+	rest := strings.TrimPrefix(r.URL.Path, "/api/admin/wifi/networks/")
+	idStr, action, _ := strings.Cut(rest, "/")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		http.Error(w, "invalid network id", http.StatusBadRequest)
+		return
+	}
+
+	switch {
+	case action == "" && r.Method == http.MethodDelete:
+		err = a.M.Remove(id)
+	case action == "connect" && r.Method == http.MethodPost:
+		err = a.M.Connect(id)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeOK(w)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrInvalidNetwork):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, ErrCommandFailed):
+		http.Error(w, err.Error(), http.StatusBadGateway)
+	default:
+		// socket missing / wpa_supplicant not running
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+	}
+}
+
+func writeOK(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{"ok": true})
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,93 @@
+package wifi
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+var ErrCommandFailed = errors.New("wpa_supplicant: command failed")
+
+var localSeq uint64
+
+// Client talks to wpa_supplicant over its unix datagram control socket
+// (e.g. /var/run/wpa_supplicant/wlan0). Every client binds its own
+// local socket, wpa_supplicant answers to that address.
+type Client struct {
+	conn    *net.UnixConn
+	local   string
+	Timeout time.Duration
+}
+
+func Dial(ctrlPath string) (*Client, error) {
+	local := filepath.Join(os.TempDir(),
+		fmt.Sprintf("mupibox-wpa-%d-%d", os.Getpid(), atomic.AddUint64(&localSeq, 1)))
+	_ = os.Remove(local)
+
+	conn, err := net.DialUnix("unixgram",
+		&net.UnixAddr{Name: local, Net: "unixgram"},
+		&net.UnixAddr{Name: ctrlPath, Net: "unixgram"})
+	if err != nil {
+		return nil, err
+	}
+	return &Client{conn: conn, local: local, Timeout: 5 * time.Second}, nil
+}
+
+func (c *Client) Close() error {
+	err := c.conn.Close()
+	_ = os.Remove(c.local)
+	return err
+}
+
+// Request sends a command and returns the raw reply. Unsolicited event
+// messages ("<3>CTRL-EVENT-...") are skipped.
+func (c *Client) Request(cmd string) (string, error) {
+	if _, err := c.conn.Write([]byte(cmd)); err != nil {
+		return "", err
+	}
+
+	buf := make([]byte, 16*1024)
+	for {
+		if err := c.conn.SetReadDeadline(time.Now().Add(c.Timeout)); err != nil {
+			return "", err
+		}
+		n, err := c.conn.Read(buf)
+		if err != nil {
+			return "", err
+		}
+		reply := string(buf[:n])
+		if strings.HasPrefix(reply, "<") {
+			continue
+		}
+		return reply, nil
+	}
+}
+
+// requestOK sends a command that answers with OK or FAIL.
+func (c *Client) requestOK(cmd string) error {
+	reply, err := c.Request(cmd)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(reply) != "OK" {
+		return fmt.Errorf("%w: %s: %s", ErrCommandFailed, commandName(cmd), strings.TrimSpace(reply))
+	}
+	return nil
+}
+
+// commandName strips arguments so passphrases never end up in errors/logs.
+func commandName(cmd string) string {
+	f := strings.Fields(cmd)
+	if len(f) >= 3 && f[0] == "SET_NETWORK" {
+		return strings.Join(f[:3], " ")
+	}
+	if len(f) > 0 {
+		return f[0]
+	}
+	return cmd
+}
@@ -0,0 +1,268 @@
+package wifi
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"mupibox/internal/status"
+)
+
+var ErrInvalidNetwork = errors.New("invalid network")
+
+type ScanResult struct {
+	BSSID     string `json:"bssid"`
+	SSID      string `json:"ssid"`
+	Frequency int    `json:"frequency"` // MHz
+	Signal    int    `json:"signal"`    // dBm
+	Quality   int    `json:"quality"`   // 0..100
+	Strength  int    `json:"strength"`  // 1..3, wie die Statusleiste
+	Secured   bool   `json:"secured"`
+	Flags     string `json:"flags"`
+}
+
+type Network struct {
+	ID      int    `json:"id"`
+	SSID    string `json:"ssid"`
+	BSSID   string `json:"bssid,omitempty"`
+	Current bool   `json:"current"`
+	Flags   string `json:"flags,omitempty"`
+}
+
+// Manager wraps the wpa_supplicant commands the UI needs. It dials a new
+// control connection per call, so a restarted wpa_supplicant is no problem.
+type Manager struct {
+	CtrlPath string
+}
+
+func NewManager(ctrlPath string) *Manager {
+	return &Manager{CtrlPath: ctrlPath}
+}
+
+func (m *Manager) with(fn func(c *Client) error) error {
+	c, err := Dial(m.CtrlPath)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	return fn(c)
+}
+
+// Scan triggers a new scan; results arrive a few seconds later.
+func (m *Manager) Scan() error {
+	return m.with(func(c *Client) error {
+		err := c.requestOK("SCAN")
+		// a scan is already running: fine for us
+		if err != nil && strings.Contains(err.Error(), "FAIL-BUSY") {
+			return nil
+		}
+		return err
+	})
+}
+
+// ScanResults lists visible networks, one entry per SSID (strongest BSS),
+// strongest first. Hidden networks (empty or all-NUL SSID) are skipped.
+func (m *Manager) ScanResults() ([]ScanResult, error) {
+	var reply string
+	err := m.with(func(c *Client) error {
+		var err error
+		reply, err = c.Request("SCAN_RESULTS")
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return parseScanResults(reply), nil
+}
+
+func (m *Manager) Networks() ([]Network, error) {
+	var reply string
+	err := m.with(func(c *Client) error {
+		var err error
+		reply, err = c.Request("LIST_NETWORKS")
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return parseNetworks(reply), nil
+}
+
+// Add saves a network and returns its id. An empty psk adds an open network.
+func (m *Manager) Add(ssid, psk string) (int, error) {
+	if ssid == "" || strings.ContainsAny(psk, "\r\n") || (psk != "" && (len(psk) < 8 || len(psk) > 63)) {
+		return 0, ErrInvalidNetwork
+	}
+
+	var id int
+	err := m.with(func(c *Client) error {
+		reply, err := c.Request("ADD_NETWORK")
+		if err != nil {
+			return err
+		}
+		id, err = strconv.Atoi(strings.TrimSpace(reply))
+		if err != nil {
+			return fmt.Errorf("%w: ADD_NETWORK: %s", ErrCommandFailed, strings.TrimSpace(reply))
+		}
+
+		// SSID hex-encoded: no quoting problems with umlauts or quotes
+		cmds := []string{fmt.Sprintf("SET_NETWORK %d ssid %s", id, hex.EncodeToString([]byte(ssid)))}
+		if psk == "" {
+			cmds = append(cmds, fmt.Sprintf("SET_NETWORK %d key_mgmt NONE", id))
+		} else {
+			cmds = append(cmds, fmt.Sprintf("SET_NETWORK %d psk \"%s\"", id, psk))
+		}
+		cmds = append(cmds, fmt.Sprintf("ENABLE_NETWORK %d", id), "SAVE_CONFIG")
+
+		for _, cmd := range cmds {
+			if err := c.requestOK(cmd); err != nil {
+				_ = c.requestOK(fmt.Sprintf("REMOVE_NETWORK %d", id))
+				return err
+			}
+		}
+		return nil
+	})
+	return id, err
+}
+
+func (m *Manager) Remove(id int) error {
+	return m.with(func(c *Client) error {
+		if err := c.requestOK(fmt.Sprintf("REMOVE_NETWORK %d", id)); err != nil {
+			return err
+		}
+		return c.requestOK("SAVE_CONFIG")
+	})
+}
+
+// Connect selects a saved network (and disables the others until reconfigure).
+func (m *Manager) Connect(id int) error {
+	return m.with(func(c *Client) error {
+		return c.requestOK(fmt.Sprintf("SELECT_NETWORK %d", id))
+	})
+}
+
+// bssid / frequency / signal level / flags / ssid
+func parseScanResults(reply string) []ScanResult {
+	best := map[string]ScanResult{}
+	for i, line := range strings.Split(reply, "\n") {
+		if i == 0 || line == "" {
+			continue
+		}
+		f := strings.SplitN(line, "\t", 5)
+		if len(f) < 5 || f[4] == "" {
+			continue
+		}
+		ssid := decodeSSID(f[4])
+		if strings.Trim(ssid, "\x00") == "" {
+			// hidden network that beacons NULs instead of its name
+			continue
+		}
+		freq, _ := strconv.Atoi(f[1])
+		signal, _ := strconv.Atoi(f[2])
+
+		r := ScanResult{
+			BSSID:     f[0],
+			SSID:      ssid,
+			Frequency: freq,
+			Signal:    signal,
+			Quality:   signalQuality(signal),
+			Secured:   strings.Contains(f[3], "WPA") || strings.Contains(f[3], "WEP") || strings.Contains(f[3], "SAE"),
+			Flags:     f[3],
+		}
+		r.Strength = status.WifiStrength(r.Quality)
+
+		if prev, ok := best[r.SSID]; !ok || r.Signal > prev.Signal {
+			best[r.SSID] = r
+		}
+	}
+
+	out := make([]ScanResult, 0, len(best))
+	for _, r := range best {
+		out = append(out, r)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Signal != out[j].Signal {
+			return out[i].Signal > out[j].Signal
+		}
+		return out[i].SSID < out[j].SSID
+	})
+	return out
+}
+
+// network id / ssid / bssid / flags
+func parseNetworks(reply string) []Network {
+	out := []Network{}
+	for i, line := range strings.Split(reply, "\n") {
+		if i == 0 || line == "" {
+			continue
+		}
+		f := strings.SplitN(line, "\t", 4)
+		if len(f) < 2 {
+			continue
+		}
+		id, err := strconv.Atoi(f[0])
+		if err != nil {
+			continue
+		}
+		n := Network{ID: id, SSID: decodeSSID(f[1])}
+		if len(f) > 2 && f[2] != "any" {
+			n.BSSID = f[2]
+		}
+		if len(f) > 3 {
+			n.Flags = f[3]
+			n.Current = strings.Contains(f[3], "[CURRENT]")
+		}
+		out = append(out, n)
+	}
+	return out
+}
+
+// signalQuality maps dBm to 0..100 (-90 dBm and below = 0, -30 and up = 100).
+func signalQuality(dbm int) int {
+	q := (dbm + 90) * 100 / 60
+	if q < 0 {
+		return 0
+	}
+	if q > 100 {
+		return 100
+	}
+	return q
+}
+
+// decodeSSID undoes wpa_supplicant's printf_encode (\xNN, \\, \").
+func decodeSSID(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 >= len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		switch s[i+1] {
+		case 'x':
+			if i+3 < len(s) {
+				if v, err := strconv.ParseUint(s[i+2:i+4], 16, 8); err == nil {
+					b.WriteByte(byte(v))
+					i += 3
+					continue
+				}
+			}
+			b.WriteByte(s[i])
+		case 'n':
+			b.WriteByte('\n')
+			i++
+		case 't':
+			b.WriteByte('\t')
+			i++
+		default:
+			b.WriteByte(s[i+1])
+			i++
+		}
+	}
+	return b.String()
+}
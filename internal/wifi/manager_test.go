@@ -0,0 +1,321 @@
+package wifi
+
+import (
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeSupplicant answers on a unixgram socket like wpa_supplicant's
+// control interface and records the commands it got.
+type fakeSupplicant struct {
+	path    string
+	conn    *net.UnixConn
+	replies map[string]string // command prefix -> reply, default "OK"
+	events  bool              // send an unsolicited event before each reply
+
+	mu   sync.Mutex
+	cmds []string
+}
+
+func newFakeSupplicant(t *testing.T, replies map[string]string) *fakeSupplicant {
+	t.Helper()
+	// unix socket paths are limited to ~100 bytes, t.TempDir can be longer
+	dir, err := os.MkdirTemp("", "wpa")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	f := &fakeSupplicant{path: filepath.Join(dir, "wlan0"), replies: replies}
+	f.conn, err = net.ListenUnixgram("unixgram", &net.UnixAddr{Name: f.path, Net: "unixgram"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { f.conn.Close() })
+	go f.serve()
+	return f
+}
+
+func (f *fakeSupplicant) serve() {
+	buf := make([]byte, 4096)
+	for {
+		n, addr, err := f.conn.ReadFromUnix(buf)
+		if err != nil {
+			return
+		}
+		cmd := string(buf[:n])
+		f.mu.Lock()
+		f.cmds = append(f.cmds, cmd)
+		f.mu.Unlock()
+
+		reply := "OK\n"
+		for prefix, r := range f.replies {
+			if strings.HasPrefix(cmd, prefix) {
+				reply = r
+			}
+		}
+		if f.events {
+			_, _ = f.conn.WriteToUnix([]byte("<3>CTRL-EVENT-SCAN-STARTED "), addr)
+		}
+		_, _ = f.conn.WriteToUnix([]byte(reply), addr)
+	}
+}
+
+func (f *fakeSupplicant) commands() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.cmds...)
+}
+
+const scanReply = "bssid / frequency / signal level / flags / ssid\n" +
+	"aa:aa:aa:aa:aa:01\t2412\t-70\t[WPA2-PSK-CCMP][ESS]\tOma\n" +
+	"aa:aa:aa:aa:aa:02\t5180\t-50\t[WPA2-PSK-CCMP][ESS]\tOma\n" +
+	"bb:bb:bb:bb:bb:01\t2437\t-80\t[ESS]\tCaf\\xc3\\xa9\n" +
+	"cc:cc:cc:cc:cc:01\t2462\t-60\t[WPA2-PSK-CCMP][ESS]\t\n"
+
+func TestScanResults(t *testing.T) {
+	f := newFakeSupplicant(t, map[string]string{"SCAN_RESULTS": scanReply})
+	f.events = true
+
+	res, err := NewManager(f.path).ScanResults()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != 2 {
+		t.Fatalf("%d results, want 2 (one per ssid, hidden skipped): %+v", len(res), res)
+	}
+	if res[0].SSID != "Oma" || res[0].Signal != -50 || !res[0].Secured {
+		t.Errorf("first = %+v, want strongest Oma BSS", res[0])
+	}
+	if res[1].SSID != "Café" || res[1].Secured {
+		t.Errorf("second = %+v, want open Café", res[1])
+	}
+}
+
+func TestScanBusy(t *testing.T) {
+	f := newFakeSupplicant(t, map[string]string{"SCAN": "FAIL-BUSY\n"})
+	if err := NewManager(f.path).Scan(); err != nil {
+		t.Fatalf("busy scan: %v", err)
+	}
+}
+
+func TestNetworks(t *testing.T) {
+	f := newFakeSupplicant(t, map[string]string{"LIST_NETWORKS": "network id / ssid / bssid / flags\n" +
+		"0\tZuhause\tany\t[CURRENT]\n" +
+		"1\tOma\tany\t[DISABLED]\n"})
+
+	nets, err := NewManager(f.path).Networks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Network{
+		{ID: 0, SSID: "Zuhause", Current: true, Flags: "[CURRENT]"},
+		{ID: 1, SSID: "Oma", Flags: "[DISABLED]"},
+	}
+	if !reflect.DeepEqual(nets, want) {
+		t.Fatalf("networks = %+v, want %+v", nets, want)
+	}
+}
+
+func TestAdd(t *testing.T) {
+	f := newFakeSupplicant(t, map[string]string{"ADD_NETWORK": "3\n"})
+
+	id, err := NewManager(f.path).Add("Oma's WLAN", "geheim123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != 3 {
+		t.Fatalf("id = %d, want 3", id)
+	}
+	want := []string{
+		"ADD_NETWORK",
+		"SET_NETWORK 3 ssid 4f6d61277320574c414e",
+		`SET_NETWORK 3 psk "geheim123"`,
+		"ENABLE_NETWORK 3",
+		"SAVE_CONFIG",
+	}
+	if got := f.commands(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("commands = %q, want %q", got, want)
+	}
+}
+
+func TestAddFailureRemovesNetwork(t *testing.T) {
+	f := newFakeSupplicant(t, map[string]string{
+		"ADD_NETWORK":       "4\n",
+		"SET_NETWORK 4 psk": "FAIL\n",
+	})
+
+	_, err := NewManager(f.path).Add("Oma", "geheim123")
+	if !errors.Is(err, ErrCommandFailed) {
+		t.Fatalf("err = %v, want ErrCommandFailed", err)
+	}
+	if strings.Contains(err.Error(), "geheim123") {
+		t.Fatalf("passphrase in error: %v", err)
+	}
+	cmds := f.commands()
+	if last := cmds[len(cmds)-1]; last != "REMOVE_NETWORK 4" {
+		t.Fatalf("last command = %q, want REMOVE_NETWORK 4", last)
+	}
+}
+
+func TestAddValidation(t *testing.T) {
+	tests := []struct {
+		name      string
+		ssid, psk string
+		ok        bool
+	}{
+		{"open network", "Café", "", true},
+		{"psk of 8", "Oma", "12345678", true},
+		{"psk of 63", "Oma", strings.Repeat("x", 63), true},
+		{"no ssid", "", "geheim123", false},
+		{"psk of 7", "Oma", "1234567", false},
+		{"psk of 64", "Oma", strings.Repeat("x", 64), false},
+		{"psk with lf", "Oma", "geheim\n123", false},
+		{"psk with cr", "Oma", "geheim\r123", false},
+		{"psk ending in lf", "Oma", "geheim123\n", false},
+	}
+	for _, tt := range tests {
+		f := newFakeSupplicant(t, map[string]string{"ADD_NETWORK": "0\n"})
+		_, err := NewManager(f.path).Add(tt.ssid, tt.psk)
+		if tt.ok && err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+		}
+		if !tt.ok {
+			if !errors.Is(err, ErrInvalidNetwork) {
+				t.Errorf("%s: err = %v, want ErrInvalidNetwork", tt.name, err)
+			}
+			if cmds := f.commands(); len(cmds) != 0 {
+				t.Errorf("%s: sent %q to wpa_supplicant", tt.name, cmds)
+			}
+		}
+	}
+}
+
+func TestParseScanResults(t *testing.T) {
+	const hdr = "bssid / frequency / signal level / flags / ssid\n"
+	tests := []struct {
+		name  string
+		reply string
+		want  []ScanResult
+	}{
+		{
+			name:  "empty",
+			reply: hdr,
+			want:  []ScanResult{},
+		},
+		{
+			name: "strongest bss per ssid",
+			reply: hdr +
+				"aa:aa:aa:aa:aa:01\t2412\t-70\t[WPA2-PSK-CCMP][ESS]\tOma\n" +
+				"aa:aa:aa:aa:aa:02\t5180\t-50\t[WPA2-PSK-CCMP][ESS]\tOma\n" +
+				"aa:aa:aa:aa:aa:03\t2437\t-65\t[WPA2-PSK-CCMP][ESS]\tOma\n",
+			want: []ScanResult{
+				{BSSID: "aa:aa:aa:aa:aa:02", SSID: "Oma", Frequency: 5180, Signal: -50, Quality: 66, Strength: 2, Secured: true, Flags: "[WPA2-PSK-CCMP][ESS]"},
+			},
+		},
+		{
+			name: "escaped ssids, equal signal sorted by name",
+			reply: hdr +
+				"bb:bb:bb:bb:bb:01\t2437\t-80\t[ESS]\tCaf\\xc3\\xa9\n" +
+				"bb:bb:bb:bb:bb:02\t2437\t-80\t[WEP][ESS]\tOma\\'s \\\"WLAN\\\"\n" +
+				"bb:bb:bb:bb:bb:03\t2437\t-80\t[SAE][ESS]\ta\\\\b\\tc\n",
+			want: []ScanResult{
+				{BSSID: "bb:bb:bb:bb:bb:01", SSID: "Café", Frequency: 2437, Signal: -80, Quality: 16, Strength: 1, Flags: "[ESS]"},
+				{BSSID: "bb:bb:bb:bb:bb:02", SSID: "Oma's \"WLAN\"", Frequency: 2437, Signal: -80, Quality: 16, Strength: 1, Secured: true, Flags: "[WEP][ESS]"},
+				{BSSID: "bb:bb:bb:bb:bb:03", SSID: "a\\b\tc", Frequency: 2437, Signal: -80, Quality: 16, Strength: 1, Secured: true, Flags: "[SAE][ESS]"},
+			},
+		},
+		{
+			name: "hidden and broken lines",
+			reply: hdr +
+				"cc:cc:cc:cc:cc:01\t2462\t-60\t[WPA2-PSK-CCMP][ESS]\t\n" +
+				"cc:cc:cc:cc:cc:02\t2462\t-60\t[WPA2-PSK-CCMP][ESS]\t\\x00\\x00\\x00\n" +
+				"cc:cc:cc:cc:cc:03\t2462\t-60\n" +
+				"\n",
+			want: []ScanResult{},
+		},
+	}
+	for _, tt := range tests {
+		if got := parseScanResults(tt.reply); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s:\n got  %+v\n want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseNetworks(t *testing.T) {
+	reply := "network id / ssid / bssid / flags\n" +
+		"0\tCaf\\xc3\\xa9\tany\t[CURRENT]\n" +
+		"1\tOma\t11:22:33:44:55:66\t[DISABLED]\n" +
+		"2\tNachbar\n" +
+		"x\tkaputt\tany\t\n" +
+		"3\n"
+	want := []Network{
+		{ID: 0, SSID: "Café", Current: true, Flags: "[CURRENT]"},
+		{ID: 1, SSID: "Oma", BSSID: "11:22:33:44:55:66", Flags: "[DISABLED]"},
+		{ID: 2, SSID: "Nachbar"},
+	}
+	if got := parseNetworks(reply); !reflect.DeepEqual(got, want) {
+		t.Fatalf("networks = %+v, want %+v", got, want)
+	}
+	if got := parseNetworks("network id / ssid / bssid / flags\n"); got == nil || len(got) != 0 {
+		t.Fatalf("no networks = %#v, want an empty list", got)
+	}
+}
+
+func TestDecodeSSID(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"Zuhause", "Zuhause"},
+		{`Caf\xc3\xa9`, "Café"},
+		{`\xC3\xA9`, "é"},
+		{`a\\b`, `a\b`},
+		{`\"x\"`, `"x"`},
+		{`a\nb\tc`, "a\nb\tc"},
+		{`\x00\x00`, "\x00\x00"},
+		{`\xzz`, `\xzz`},   // not hex: kept
+		{`ab\x4`, `ab\x4`}, // cut off
+		{`ab\`, `ab\`},     // trailing backslash
+	}
+	for _, tt := range tests {
+		if got := decodeSSID(tt.in); got != tt.want {
+			t.Errorf("decodeSSID(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSignalQuality(t *testing.T) {
+	tests := []struct{ dbm, want int }{
+		{-100, 0},
+		{-90, 0},
+		{-89, 1},
+		{-60, 50},
+		{-31, 98},
+		{-30, 100},
+		{-10, 100},
+	}
+	for _, tt := range tests {
+		if got := signalQuality(tt.dbm); got != tt.want {
+			t.Errorf("signalQuality(%d) = %d, want %d", tt.dbm, got, tt.want)
+		}
+	}
+}
+
+func TestRemoveAndConnect(t *testing.T) {
+	f := newFakeSupplicant(t, nil)
+	m := NewManager(f.path)
+	if err := m.Remove(2); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Connect(1); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"REMOVE_NETWORK 2", "SAVE_CONFIG", "SELECT_NETWORK 1"}
+	if got := f.commands(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("commands = %q, want %q", got, want)
+	}
+}